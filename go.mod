@@ -9,4 +9,5 @@ require (
 	github.com/smartystreets/goconvey v1.6.4
 	golang.org/x/net v0.0.0-20210917221730-978cfadd31cf // indirect
 	golang.org/x/sys v0.0.0-20210921065528-437939a70204 // indirect
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,145 @@
+package hyperscan
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// AllocFunc allocates a memory region of the given size for Hyperscan.
+//
+// The returned memory must not be managed by the Go runtime,
+// and must be aligned for the largest representable data type on this platform.
+type AllocFunc func(size uint) unsafe.Pointer
+
+// FreeFunc frees a memory region previously allocated by the paired AllocFunc.
+type FreeFunc func(ptr unsafe.Pointer)
+
+// DefaultAlloc is the allocator used by Hyperscan if no custom one was set.
+func DefaultAlloc(size uint) unsafe.Pointer { return hsDefaultAlloc(size) }
+
+// DefaultFree frees a memory region previously allocated by DefaultAlloc.
+func DefaultFree(ptr unsafe.Pointer) { hsDefaultFree(ptr) }
+
+// SetAllocator sets the allocate and free functions used by Hyperscan for all its memory allocation.
+//
+// The allocators must be set before creating any object that they would allocate,
+// and it is not safe to change them while other goroutines use Hyperscan.
+func SetAllocator(alloc AllocFunc, free FreeFunc) error {
+	for _, set := range []func(AllocFunc, FreeFunc) error{
+		SetDatabaseAllocator, SetMiscAllocator, SetScratchAllocator, SetStreamAllocator,
+	} {
+		if err := set(alloc, free); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ClearAllocator restores the default allocator for all Hyperscan memory allocation.
+func ClearAllocator() error {
+	for _, reset := range []func() error{
+		ClearScratchAllocator, ClearDatabaseAllocator, ClearMiscAllocator, ClearStreamAllocator,
+	} {
+		if err := reset(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetDatabaseAllocator sets the allocate and free functions used for compiled pattern databases.
+func SetDatabaseAllocator(alloc AllocFunc, free FreeFunc) error {
+	if err := hsSetDatabaseAllocator(hsAllocFunc(alloc), hsFreeFunc(free)); err != nil {
+		return fmt.Errorf("set database allocator, %w", err)
+	}
+
+	return nil
+}
+
+// ClearDatabaseAllocator restores the default allocator for compiled pattern databases.
+func ClearDatabaseAllocator() error {
+	if err := hsClearDatabaseAllocator(); err != nil {
+		return fmt.Errorf("clear database allocator, %w", err)
+	}
+
+	return nil
+}
+
+// SetMiscAllocator sets the allocate and free functions used for the miscellaneous data,
+// such as the compile error structures and the informational strings.
+func SetMiscAllocator(alloc AllocFunc, free FreeFunc) error {
+	if err := hsSetMiscAllocator(hsAllocFunc(alloc), hsFreeFunc(free)); err != nil {
+		return fmt.Errorf("set misc allocator, %w", err)
+	}
+
+	return nil
+}
+
+// ClearMiscAllocator restores the default allocator for the miscellaneous data.
+func ClearMiscAllocator() error {
+	if err := hsClearMiscAllocator(); err != nil {
+		return fmt.Errorf("clear misc allocator, %w", err)
+	}
+
+	return nil
+}
+
+// SetScratchAllocator sets the allocate and free functions used for scratch spaces.
+func SetScratchAllocator(alloc AllocFunc, free FreeFunc) error {
+	if err := hsSetScratchAllocator(hsAllocFunc(alloc), hsFreeFunc(free)); err != nil {
+		return fmt.Errorf("set scratch allocator, %w", err)
+	}
+
+	return nil
+}
+
+// ClearScratchAllocator restores the default allocator for scratch spaces,
+// it returns `ErrInvalid` while the scratch spaces allocated by `NewScratchWith` are not freed.
+func ClearScratchAllocator() error {
+	if err := hsClearScratchAllocator(); err != nil {
+		return fmt.Errorf("clear scratch allocator, %w", err)
+	}
+
+	return nil
+}
+
+// NewScratchWith allocates a scratch space for the database with a dedicated allocator,
+// such as a NUMA-local arena for the worker, without affecting the other allocations.
+//
+// The memory is freed with the paired free function when the scratch is freed,
+// but the scratch reallocated for another database or cloned uses the scratch allocator.
+// The scratch allocation is routed through Go afterward, so the scratch allocator can't be cleared
+// until the scratch spaces allocated with the dedicated allocators are freed.
+func NewScratchWith(db Database, alloc AllocFunc, free FreeFunc) (*Scratch, error) {
+	d, ok := db.(database)
+	if !ok {
+		return nil, fmt.Errorf("database %v, %w", db, ErrUnexpected)
+	}
+
+	s, err := hsAllocScratchWith(d.Db(), hsAllocFunc(alloc), hsFreeFunc(free))
+	if err != nil {
+		return nil, fmt.Errorf("allocate scratch, %w", err)
+	}
+
+	return trackScratch(&Scratch{s}), nil
+}
+
+// SetStreamAllocator sets the allocate and free functions used for stream states.
+func SetStreamAllocator(alloc AllocFunc, free FreeFunc) error {
+	if err := hsSetStreamAllocator(hsAllocFunc(alloc), hsFreeFunc(free)); err != nil {
+		return fmt.Errorf("set stream allocator, %w", err)
+	}
+
+	return nil
+}
+
+// ClearStreamAllocator restores the default allocator for stream states.
+func ClearStreamAllocator() error {
+	if err := hsClearStreamAllocator(); err != nil {
+		return fmt.Errorf("clear stream allocator, %w", err)
+	}
+
+	return nil
+}
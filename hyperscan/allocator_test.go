@@ -0,0 +1,94 @@
+package hyperscan_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"unsafe"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestScratchAllocator(t *testing.T) {
+	Convey("Given a block database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`test`))
+
+		So(err, ShouldBeNil)
+
+		Convey("When allocate a scratch with a custom allocator", func() {
+			var allocated, freed int64
+
+			So(hyperscan.SetScratchAllocator(func(size uint) unsafe.Pointer {
+				atomic.AddInt64(&allocated, int64(size))
+
+				return hyperscan.DefaultAlloc(size)
+			}, func(ptr unsafe.Pointer) {
+				atomic.AddInt64(&freed, 1)
+
+				hyperscan.DefaultFree(ptr)
+			}), ShouldBeNil)
+
+			s, err := hyperscan.NewScratch(bdb)
+
+			So(err, ShouldBeNil)
+
+			size, err := s.Size()
+
+			So(err, ShouldBeNil)
+			So(atomic.LoadInt64(&allocated), ShouldBeGreaterThanOrEqualTo, size)
+
+			So(s.Free(), ShouldBeNil)
+			So(atomic.LoadInt64(&freed), ShouldBeGreaterThan, 0)
+
+			So(hyperscan.ClearScratchAllocator(), ShouldBeNil)
+		})
+
+		Convey("When allocate a scratch with a dedicated allocator", func() {
+			var allocated, freed int64
+
+			s, err := hyperscan.NewScratchWith(bdb, func(size uint) unsafe.Pointer {
+				atomic.AddInt64(&allocated, 1)
+
+				return hyperscan.DefaultAlloc(size)
+			}, func(ptr unsafe.Pointer) {
+				atomic.AddInt64(&freed, 1)
+
+				hyperscan.DefaultFree(ptr)
+			})
+
+			So(err, ShouldBeNil)
+			So(atomic.LoadInt64(&allocated), ShouldBeGreaterThan, 0)
+
+			Convey("Then the other scratch spaces are not affected", func() {
+				n := atomic.LoadInt64(&allocated)
+
+				other, err := hyperscan.NewScratch(bdb)
+
+				So(err, ShouldBeNil)
+				So(atomic.LoadInt64(&allocated), ShouldEqual, n)
+				So(other.Free(), ShouldBeNil)
+				So(atomic.LoadInt64(&freed), ShouldEqual, 0)
+			})
+
+			Convey("Then the scratch allocator can't be cleared until it is freed", func() {
+				So(errors.Is(hyperscan.ClearScratchAllocator(), hyperscan.ErrInvalid), ShouldBeTrue)
+			})
+
+			So(s.Free(), ShouldBeNil)
+			So(atomic.LoadInt64(&freed), ShouldEqual, atomic.LoadInt64(&allocated))
+			So(hyperscan.ClearScratchAllocator(), ShouldBeNil)
+		})
+
+		Convey("When allocate a scratch for a wrapped database with a dedicated allocator", func() {
+			db := hyperscan.NewSharedDatabase(bdb)
+
+			_, err := hyperscan.NewScratchWith(db, hyperscan.DefaultAlloc, hyperscan.DefaultFree)
+
+			So(errors.Is(err, hyperscan.ErrUnexpected), ShouldBeTrue)
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
@@ -110,5 +110,5 @@ func MatchReader(pattern string, reader io.Reader) (bool, error) {
 
 // MatchString reports whether the string s contains any match of the regular expression pattern.
 func MatchString(pattern string, s string) (matched bool, err error) {
-	return Match(pattern, []byte(s))
+	return Match(pattern, stringBytes(s))
 }
@@ -0,0 +1,58 @@
+package hyperscan
+
+import (
+	"fmt"
+)
+
+// AutoScratchDatabase is a block database which manages the scratch spaces transparently,
+// so it could be scanned concurrently without passing a scratch.
+//
+// Since goroutines have no identity in Go, the scratch spaces are kept in a pool,
+// each scanning takes one exclusively and returns it when done.
+// The explicitly passed scratch is used as is.
+type AutoScratchDatabase struct {
+	BlockDatabase
+
+	pool *ScratchPool
+}
+
+// NewAutoScratchDatabase wraps the block database, and takes its ownership.
+func NewAutoScratchDatabase(db BlockDatabase) (*AutoScratchDatabase, error) {
+	pool, err := NewScratchPool(db)
+	if err != nil {
+		return nil, fmt.Errorf("create scratch pool, %w", err)
+	}
+
+	return &AutoScratchDatabase{db, pool}, nil
+}
+
+func (db *AutoScratchDatabase) withScratch(s *Scratch, scan func(*Scratch) error) error {
+	if s != nil {
+		return scan(s)
+	}
+
+	s, err := db.pool.Get()
+	if err != nil {
+		return err
+	}
+
+	defer db.pool.Put(s)
+
+	return scan(s)
+}
+
+// Scan the data with a pooled scratch if the scratch is nil.
+func (db *AutoScratchDatabase) Scan(data []byte, s *Scratch, handler MatchHandler, context interface{}) error {
+	return db.withScratch(s, func(s *Scratch) error {
+		return db.BlockDatabase.Scan(data, s, handler, context)
+	})
+}
+
+// Close the scratch pool and the database.
+func (db *AutoScratchDatabase) Close() error {
+	if err := db.pool.Close(); err != nil {
+		return err
+	}
+
+	return db.BlockDatabase.Close() // nolint: wrapcheck
+}
@@ -0,0 +1,53 @@
+package hyperscan_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestAutoScratchDatabase(t *testing.T) {
+	Convey("Given a block database with automatic scratch", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`, 0))
+		So(err, ShouldBeNil)
+
+		db, err := hyperscan.NewAutoScratchDatabase(bdb)
+		So(err, ShouldBeNil)
+
+		Convey("When scan it concurrently without scratch", func() {
+			var (
+				wg      sync.WaitGroup
+				matches int32
+				failed  int32
+			)
+
+			for i := 0; i < 16; i++ {
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					if err := hyperscan.ScanString(db, "foo foo", nil, func(id uint, from, to uint64, flags uint,
+						context interface{}) error {
+						atomic.AddInt32(&matches, 1)
+
+						return nil
+					}, nil); err != nil {
+						atomic.AddInt32(&failed, 1)
+					}
+				}()
+			}
+
+			wg.Wait()
+
+			So(failed, ShouldEqual, 0)
+			So(matches, ShouldEqual, 32)
+		})
+
+		So(db.Close(), ShouldBeNil)
+	})
+}
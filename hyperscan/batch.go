@@ -0,0 +1,43 @@
+package hyperscan
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// BatchMatchHandler handles match events with the index of block in the batch.
+type BatchMatchHandler func(index int, id uint, from, to uint64, flags uint, context interface{}) error
+
+// ScanBatch scans many small independent blocks with the block database in one cgo call,
+// and reports the matches with the index of block, the offsets are relative to the block.
+//
+// The scanning stops at the first block which failed or was terminated by the handler.
+func ScanBatch(db BlockDatabase, blocks [][]byte, scratch *Scratch, handler BatchMatchHandler,
+	context interface{}) (err error) {
+	d, ok := db.(database)
+	if !ok {
+		return fmt.Errorf("database %v, %w", db, ErrUnexpected)
+	}
+
+	if scratch == nil {
+		scratch, err = NewScratch(db)
+		if err != nil {
+			return fmt.Errorf("create scratch, %w", err)
+		}
+
+		defer func() {
+			_ = scratch.Free()
+		}()
+	}
+
+	n, err := hsScanBatch(d.Db(), blocks, 0, scratch.s, hsBatchMatchEventHandler(handler), context)
+
+	runtime.KeepAlive(db)
+	runtime.KeepAlive(scratch)
+
+	if err != nil {
+		return fmt.Errorf("block %d, %w", n, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package hyperscan_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestScanBatch(t *testing.T) {
+	Convey("Given a block database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		blocks := [][]byte{[]byte("foo"), nil, []byte("bar"), []byte("xfoo foo")}
+
+		Convey("When scan the blocks in a batch", func() {
+			var matches [][]int
+
+			err := hyperscan.ScanBatch(bdb, blocks, nil, func(index int, id uint, from, to uint64, flags uint,
+				context interface{}) error {
+				matches = append(matches, []int{index, int(from), int(to)})
+
+				return nil
+			}, nil)
+
+			Convey("Then the matches are tagged with the block index", func() {
+				So(err, ShouldBeNil)
+				So(matches, ShouldResemble, [][]int{{0, 0, 3}, {3, 1, 4}, {3, 5, 8}})
+			})
+		})
+
+		Convey("When the handler terminates the scanning", func() {
+			err := hyperscan.ScanBatch(bdb, blocks, nil, func(index int, id uint, from, to uint64, flags uint,
+				context interface{}) error {
+				if index == 3 {
+					return errors.New("stop")
+				}
+
+				return nil
+			}, nil)
+
+			So(errors.Is(err, hyperscan.ErrScanTerminated), ShouldBeTrue)
+			So(err.Error(), ShouldStartWith, "block 3")
+		})
+
+		Convey("When scan the blocks with a wrapped database", func() {
+			wrapped, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`))
+			So(err, ShouldBeNil)
+
+			db, err := hyperscan.NewAutoScratchDatabase(wrapped)
+			So(err, ShouldBeNil)
+
+			err = hyperscan.ScanBatch(db, blocks, nil, func(index int, id uint, from, to uint64, flags uint,
+				context interface{}) error {
+				return nil
+			}, nil)
+
+			So(errors.Is(err, hyperscan.ErrUnexpected), ShouldBeTrue)
+			So(db.Close(), ShouldBeNil)
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
@@ -0,0 +1,67 @@
+package hyperscan
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrBudgetExceeded means the scanning stopped since the time budget was exceeded.
+var ErrBudgetExceeded = errors.New("time budget exceeded")
+
+// DefaultChunkSize is the default size of chunks which the data is split into
+// when scanning a stream with the time budget or context.
+const DefaultChunkSize = 64 * 1024
+
+// ScanWithBudget scans the data with the stream in chunks, and stops between the chunks
+// once the wall-clock budget was exceeded, zero or negative chunk size means `DefaultChunkSize`.
+//
+// Hyperscan can't be interrupted in a call, so the budget could be exceeded by scanning the last chunk.
+// It returns the number of bytes scanned, and `ErrBudgetExceeded` if the data was partially scanned,
+// the remaining data could be scanned later with the same stream since the matches across chunks are kept.
+func ScanWithBudget(stream Stream, data []byte, chunkSize int, budget time.Duration) (int, error) {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	deadline := time.Now().Add(budget)
+	scanned := 0
+
+	for scanned < len(data) {
+		if scanned > 0 && !time.Now().Before(deadline) {
+			return scanned, fmt.Errorf("%d of %d bytes scanned, %w", scanned, len(data), ErrBudgetExceeded)
+		}
+
+		n := len(data) - scanned
+		if n > chunkSize {
+			n = chunkSize
+		}
+
+		if err := stream.Scan(data[scanned : scanned+n]); err != nil {
+			return scanned, err // nolint: wrapcheck
+		}
+
+		scanned += n
+	}
+
+	return scanned, nil
+}
+
+// ScanBlockWithBudget scans the data with the block scanner, and terminates the scanning
+// once the wall-clock budget was exceeded.
+//
+// Hyperscan can't interrupt a block scan, so the budget is only checked on each match,
+// a scan without matches always runs to the end of data. It returns `ErrBudgetExceeded`
+// if the scanning was terminated, a block scan can't be resumed like `ScanWithBudget`.
+func ScanBlockWithBudget(scanner BlockScanner, data []byte, scratch *Scratch, handler MatchHandler,
+	context interface{}, budget time.Duration) error {
+	deadline := time.Now().Add(budget)
+
+	return scanner.Scan(data, scratch, func(id uint, from, to uint64, flags uint, context interface{}) error {
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("match at %d, %w", to, ErrBudgetExceeded)
+		}
+
+		return handler(id, from, to, flags, context)
+	}, context)
+}
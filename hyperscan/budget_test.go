@@ -0,0 +1,87 @@
+package hyperscan_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestScanWithBudget(t *testing.T) {
+	Convey("Given a stream", t, func() {
+		sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`foobar`))
+		So(err, ShouldBeNil)
+
+		var ends []uint64
+
+		s, err := sdb.Open(0, nil, func(id uint, from, to uint64, flags uint, context interface{}) error {
+			ends = append(ends, to)
+
+			return nil
+		}, nil)
+		So(err, ShouldBeNil)
+
+		data := []byte(strings.Repeat("x", 1021) + "foobar" + strings.Repeat("x", 1021))
+
+		Convey("When scan with enough budget", func() {
+			n, err := hyperscan.ScanWithBudget(s, data, 256, time.Minute)
+
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, len(data))
+			So(ends, ShouldResemble, []uint64{1027})
+		})
+
+		Convey("When scan without budget", func() {
+			n, err := hyperscan.ScanWithBudget(s, data, 1024, 0)
+
+			So(errors.Is(err, hyperscan.ErrBudgetExceeded), ShouldBeTrue)
+			So(n, ShouldEqual, 1024)
+
+			Convey("Then resume the scanning with the remaining data", func() {
+				m, err := hyperscan.ScanWithBudget(s, data[n:], 0, time.Minute)
+
+				So(err, ShouldBeNil)
+				So(n+m, ShouldEqual, len(data))
+				So(ends, ShouldResemble, []uint64{1027})
+			})
+		})
+
+		So(s.Close(), ShouldBeNil)
+		So(sdb.Close(), ShouldBeNil)
+	})
+}
+
+func TestScanBlockWithBudget(t *testing.T) {
+	Convey("Given a block database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`))
+		So(err, ShouldBeNil)
+
+		data := []byte(strings.Repeat("foo ", 10))
+		matches := 0
+		handler := func(id uint, from, to uint64, flags uint, context interface{}) error {
+			matches++
+
+			return nil
+		}
+
+		Convey("When scan with enough budget", func() {
+			err := hyperscan.ScanBlockWithBudget(bdb, data, nil, handler, nil, time.Minute)
+
+			So(err, ShouldBeNil)
+			So(matches, ShouldEqual, 10)
+		})
+
+		Convey("When scan without budget", func() {
+			err := hyperscan.ScanBlockWithBudget(bdb, data, nil, handler, nil, 0)
+
+			So(errors.Is(err, hyperscan.ErrBudgetExceeded), ShouldBeTrue)
+			So(matches, ShouldEqual, 0)
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
@@ -0,0 +1,153 @@
+package hyperscan
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// BufferedStream batches the small writes into the larger chunks of the stream to amortize the cost of scanning,
+// the offsets of matches are the same as scanning the writes one by one, only reported later.
+//
+// The buffer is scanned when it reaches the size, or after the delay of the first buffered write if positive,
+// in which case the handler may be called from another goroutine. The buffer never grows over the size,
+// the writes block while the buffer is scanning, and they are rejected with `ErrInvalid` after closed.
+type BufferedStream struct {
+	stream Stream
+	size   int
+	delay  time.Duration
+
+	mu     sync.Mutex
+	buf    []byte
+	timer  *time.Timer
+	err    error
+	closed bool
+}
+
+// NewBufferedStream returns a stream buffering the writes up to the size before scanning them.
+func NewBufferedStream(s Stream, size int, delay time.Duration) *BufferedStream {
+	return &BufferedStream{stream: s, size: size, delay: delay, buf: make([]byte, 0, size)}
+}
+
+// Write buffers the data, it returns the error of the previous scanning if any,
+// or the error of scanning the buffer once the data has been appended to it.
+func (bs *BufferedStream) Write(p []byte) (int, error) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if bs.closed {
+		return 0, fmt.Errorf("buffered stream closed, %w", ErrInvalid)
+	}
+
+	if bs.err != nil {
+		return 0, bs.err
+	}
+
+	if len(bs.buf)+len(p) > bs.size {
+		if err := bs.flush(); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(p) >= bs.size {
+		if bs.err = bs.stream.Scan(p); bs.err != nil {
+			return 0, bs.err
+		}
+
+		return len(p), nil
+	}
+
+	bs.buf = append(bs.buf, p...)
+
+	if len(bs.buf) >= bs.size {
+		if err := bs.flush(); err != nil {
+			return len(p), err
+		}
+	} else if bs.delay > 0 && bs.timer == nil {
+		var timer *time.Timer
+
+		timer = time.AfterFunc(bs.delay, func() {
+			bs.mu.Lock()
+			defer bs.mu.Unlock()
+
+			// the timer may fire after it was stopped by flushing or closing.
+			if bs.timer != timer || bs.closed {
+				return
+			}
+
+			bs.timer = nil
+
+			_ = bs.flush()
+		})
+
+		bs.timer = timer
+	}
+
+	return len(p), nil
+}
+
+// Scan buffers the data as Write.
+func (bs *BufferedStream) Scan(data []byte) error {
+	_, err := bs.Write(data)
+
+	return err
+}
+
+func (bs *BufferedStream) flush() error {
+	if bs.timer != nil {
+		bs.timer.Stop()
+		bs.timer = nil
+	}
+
+	if bs.err != nil || len(bs.buf) == 0 {
+		return bs.err
+	}
+
+	bs.err = bs.stream.Scan(bs.buf)
+	bs.buf = bs.buf[:0]
+
+	return bs.err
+}
+
+// Flush scans the buffered data.
+func (bs *BufferedStream) Flush() error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if bs.closed {
+		return fmt.Errorf("buffered stream closed, %w", ErrInvalid)
+	}
+
+	return bs.flush()
+}
+
+// Buffered returns the number of bytes buffered.
+func (bs *BufferedStream) Buffered() int {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	return len(bs.buf)
+}
+
+// Close stops the timer, scans the buffered data, and closes the stream.
+func (bs *BufferedStream) Close() error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if bs.closed {
+		return fmt.Errorf("buffered stream closed, %w", ErrInvalid)
+	}
+
+	bs.closed = true
+
+	err := bs.flush()
+
+	if e := bs.stream.Close(); err == nil {
+		err = e
+	}
+
+	return err // nolint: wrapcheck
+}
+
+var _ io.WriteCloser = (*BufferedStream)(nil)
@@ -0,0 +1,124 @@
+package hyperscan_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestBufferedStream(t *testing.T) {
+	Convey("Given a stream database", t, func() {
+		sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`foobar`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		var (
+			mu      sync.Mutex
+			matches [][]uint64
+		)
+
+		s, err := sdb.Open(0, nil, func(id uint, from, to uint64, flags uint, context interface{}) error {
+			mu.Lock()
+			matches = append(matches, []uint64{from, to})
+			mu.Unlock()
+
+			return nil
+		}, nil)
+		So(err, ShouldBeNil)
+
+		Convey("When write the small chunks to the buffered stream", func() {
+			bs := hyperscan.NewBufferedStream(s, 8, 0)
+
+			for _, chunk := range []string{"abc", "foo", "bar", "foobar"} {
+				So(bs.Scan([]byte(chunk)), ShouldBeNil)
+			}
+
+			Convey("Then they are scanned in the larger chunks with the same offsets", func() {
+				So(bs.Buffered(), ShouldEqual, 6)
+				So(matches, ShouldResemble, [][]uint64{{3, 9}})
+
+				So(bs.Close(), ShouldBeNil)
+				So(matches, ShouldResemble, [][]uint64{{3, 9}, {9, 15}})
+			})
+		})
+
+		Convey("When the buffer is flushed after the delay", func() {
+			bs := hyperscan.NewBufferedStream(s, 1024, time.Millisecond)
+
+			_, err := bs.Write([]byte("foobar"))
+			So(err, ShouldBeNil)
+
+			Convey("Then the buffered data is scanned", func() {
+				for i := 0; i < 1000 && bs.Buffered() > 0; i++ {
+					time.Sleep(time.Millisecond)
+				}
+
+				So(bs.Buffered(), ShouldEqual, 0)
+				So(bs.Close(), ShouldBeNil)
+				So(matches, ShouldResemble, [][]uint64{{0, 6}})
+			})
+		})
+
+		Convey("When the scanning of the buffer is terminated", func() {
+			ts, err := sdb.Open(0, nil, func(id uint, from, to uint64, flags uint, context interface{}) error {
+				return hyperscan.ErrScanTerminated
+			}, nil)
+			So(err, ShouldBeNil)
+
+			bs := hyperscan.NewBufferedStream(ts, 8, 0)
+
+			n, err := bs.Write([]byte("foob"))
+			So(n, ShouldEqual, 4)
+			So(err, ShouldBeNil)
+
+			n, err = bs.Write([]byte("ar12"))
+
+			Convey("Then the appended data is counted with the error", func() {
+				So(n, ShouldEqual, 4)
+				So(err, ShouldNotBeNil)
+			})
+
+			_ = bs.Close()
+			So(s.Close(), ShouldBeNil)
+		})
+
+		Convey("When write the chunks over the remaining buffer", func() {
+			bs := hyperscan.NewBufferedStream(s, 8, 0)
+
+			So(bs.Scan([]byte("abcfoo")), ShouldBeNil)
+			So(bs.Scan([]byte("bar")), ShouldBeNil)
+
+			Convey("Then the buffer is scanned before it grows over the size", func() {
+				So(bs.Buffered(), ShouldEqual, 3)
+				So(matches, ShouldBeEmpty)
+
+				So(bs.Close(), ShouldBeNil)
+				So(matches, ShouldResemble, [][]uint64{{3, 9}})
+			})
+		})
+
+		Convey("When the buffered stream is closed", func() {
+			bs := hyperscan.NewBufferedStream(s, 1024, time.Millisecond)
+
+			So(bs.Scan([]byte("foo")), ShouldBeNil)
+			So(bs.Close(), ShouldBeNil)
+
+			time.Sleep(5 * time.Millisecond)
+
+			Convey("Then the following writes are rejected", func() {
+				_, err := bs.Write([]byte("bar"))
+
+				So(errors.Is(err, hyperscan.ErrInvalid), ShouldBeTrue)
+				So(errors.Is(bs.Flush(), hyperscan.ErrInvalid), ShouldBeTrue)
+				So(errors.Is(bs.Close(), hyperscan.ErrInvalid), ShouldBeTrue)
+				So(matches, ShouldBeEmpty)
+			})
+		})
+
+		So(sdb.Close(), ShouldBeNil)
+	})
+}
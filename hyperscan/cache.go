@@ -0,0 +1,133 @@
+package hyperscan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// CacheErrorHandler is called when the cached database at the path failed to be loaded or saved.
+type CacheErrorHandler func(path string, err error)
+
+// CompileCache caches the serialized databases in a directory,
+// which keyed by the hash of the normalized patterns, mode, platform and Hyperscan version.
+type CompileCache struct {
+	dir string
+
+	// OnError is called when the cache failed to load or save a database if not nil,
+	// the patterns are compiled or the compiled database is returned regardless.
+	OnError CacheErrorHandler
+}
+
+// NewCompileCache returns a compile cache in the directory, the directory will be created if not exists.
+func NewCompileCache(dir string) (*CompileCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create cache directory, %w", err)
+	}
+
+	return &CompileCache{dir: dir}, nil
+}
+
+// Key returns the cache key of the patterns compiled in the mode for the platform.
+//
+// The host platform will be used if the platform is nil.
+func (c *CompileCache) Key(mode ModeFlag, platform Platform, patterns ...*Pattern) string {
+	if platform == nil {
+		platform = PopulatePlatform()
+	}
+
+	exprs := make([]string, len(patterns))
+
+	for i, p := range patterns {
+		exprs[i] = p.String()
+	}
+
+	sort.Strings(exprs)
+
+	h := sha256.New()
+
+	fmt.Fprintf(h, "%s\n%d\n%d:%d\n", Version(), mode, platform.Tune(), platform.CpuFeatures())
+
+	for _, expr := range exprs {
+		fmt.Fprintf(h, "%d:%s\n", len(expr), expr)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Path returns the path of cached database with the key.
+func (c *CompileCache) Path(key string) string { return filepath.Join(c.dir, key+".db") }
+
+// Build the database in the mode for the host platform,
+// the cached database will be used if exists, otherwise it will be compiled and saved to the cache.
+//
+// The errors of the cache are reported to `OnError`, they don't fail the build.
+func (c *CompileCache) Build(mode ModeFlag, patterns ...*Pattern) (Database, error) {
+	if mode == 0 {
+		mode = BlockMode
+	}
+
+	path := c.Path(c.Key(mode, nil, patterns...))
+
+	if data, err := ioutil.ReadFile(path); err == nil {
+		db, err := deserializeDatabase(data)
+		if err == nil {
+			return newDatabase(db, mode)
+		}
+
+		c.report(path, fmt.Errorf("load cache, %w", err))
+	} else if !os.IsNotExist(err) {
+		c.report(path, fmt.Errorf("read cache, %w", err))
+	}
+
+	db, err := Patterns(patterns).Build(mode)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := db.Marshal()
+	if err != nil {
+		c.report(path, fmt.Errorf("marshal database, %w", err))
+
+		return db, nil
+	}
+
+	if err = writeFileAtomic(path, data); err != nil {
+		c.report(path, fmt.Errorf("write cache, %w", err))
+	}
+
+	return db, nil
+}
+
+func (c *CompileCache) report(path string, err error) {
+	if c.OnError != nil {
+		c.OnError(path, err)
+	}
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err // nolint: wrapcheck
+	}
+
+	if _, err = f.Write(data); err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
+
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+
+	if err != nil {
+		os.Remove(f.Name())
+	}
+
+	return err // nolint: wrapcheck
+}
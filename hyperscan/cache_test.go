@@ -0,0 +1,76 @@
+package hyperscan_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestCompileCache(t *testing.T) {
+	Convey("Given a compile cache", t, func() {
+		dir, err := ioutil.TempDir("", "gohs")
+
+		So(err, ShouldBeNil)
+
+		defer os.RemoveAll(dir)
+
+		cache, err := hyperscan.NewCompileCache(dir)
+
+		So(err, ShouldBeNil)
+
+		foo := hyperscan.NewPattern(`foo`)
+		bar := hyperscan.NewPattern(`bar\d+`, hyperscan.Caseless)
+
+		Convey("The key should be independent of the patterns order", func() {
+			So(cache.Key(hyperscan.BlockMode, nil, foo, bar), ShouldEqual, cache.Key(hyperscan.BlockMode, nil, bar, foo))
+			So(cache.Key(hyperscan.BlockMode, nil, foo, bar), ShouldNotEqual, cache.Key(hyperscan.StreamMode, nil, foo, bar))
+			So(cache.Key(hyperscan.BlockMode, nil, foo), ShouldNotEqual, cache.Key(hyperscan.BlockMode, nil, foo, bar))
+		})
+
+		Convey("When build the patterns", func() {
+			db, err := cache.Build(hyperscan.StreamMode, foo, bar)
+
+			So(err, ShouldBeNil)
+			So(db.Close(), ShouldBeNil)
+
+			_, err = os.Stat(cache.Path(cache.Key(hyperscan.StreamMode, nil, foo, bar)))
+
+			So(err, ShouldBeNil)
+
+			Convey("Then build it again from the cache", func() {
+				db, err := cache.Build(hyperscan.StreamMode, bar, foo)
+
+				So(err, ShouldBeNil)
+
+				_, ok := db.(hyperscan.StreamDatabase)
+
+				So(ok, ShouldBeTrue)
+				So(db.Close(), ShouldBeNil)
+			})
+		})
+
+		Convey("When the cache failed to save the database", func() {
+			var errs []error
+
+			cache.OnError = func(path string, err error) {
+				errs = append(errs, err)
+			}
+
+			So(os.Mkdir(cache.Path(cache.Key(hyperscan.BlockMode, nil, foo)), 0o755), ShouldBeNil)
+
+			db, err := cache.Build(hyperscan.BlockMode, foo)
+
+			Convey("Then the compiled database is returned with the error reported", func() {
+				So(err, ShouldBeNil)
+				So(db.(hyperscan.BlockDatabase).MatchString("foo"), ShouldBeTrue)
+				So(errs, ShouldNotBeEmpty)
+				So(errs[len(errs)-1].Error(), ShouldStartWith, "write cache")
+				So(db.Close(), ShouldBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,61 @@
+package hyperscan
+
+import (
+	"context"
+	"io"
+)
+
+// MatchChan delivers the matches of a scanning running on a dedicated goroutine.
+type MatchChan struct {
+	// C is the buffered channel of matches, which is closed when the scanning completed or failed.
+	C <-chan MatchEvent
+
+	done chan struct{}
+	err  error
+}
+
+func newMatchChan(ctx context.Context, size int, scan func(MatchHandler) error) *MatchChan {
+	c := make(chan MatchEvent, size)
+	m := &MatchChan{C: c, done: make(chan struct{})}
+
+	go func() {
+		defer close(m.done)
+		defer close(c)
+
+		m.err = scanContext(ctx, func(id uint, from, to uint64, flags uint, context interface{}) error {
+			select {
+			case c <- &matchEvent{id, from, to, ScanFlag(flags)}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err() // nolint: wrapcheck
+			}
+		}, scan)
+	}()
+
+	return m
+}
+
+// Err waits for the scanning completed, and returns its error.
+func (m *MatchChan) Err() error {
+	<-m.done
+
+	return m.err
+}
+
+// ScanChan scans the data with the block scanner on a dedicated goroutine, and delivers the matches on the channel.
+//
+// The consumer should cancel the context if it stops receiving before the channel closed,
+// and the scratch must not be used by others until the scanning completed.
+func ScanChan(ctx context.Context, scanner BlockScanner, data []byte, scratch *Scratch, size int) *MatchChan {
+	return newMatchChan(ctx, size, func(handler MatchHandler) error {
+		return scanner.Scan(data, scratch, handler, nil)
+	})
+}
+
+// ScanReaderChan scans the data read from the reader with the stream scanner on a dedicated goroutine,
+// and delivers the matches on the channel.
+func ScanReaderChan(ctx context.Context, scanner StreamScanner, reader io.Reader, scratch *Scratch, size int) *MatchChan {
+	return newMatchChan(ctx, size, func(handler MatchHandler) error {
+		return ScanReaderContext(ctx, scanner, reader, scratch, handler, nil)
+	})
+}
@@ -0,0 +1,62 @@
+package hyperscan_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestScanChan(t *testing.T) {
+	Convey("Given a block database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`))
+		So(err, ShouldBeNil)
+
+		Convey("When receive all the matches from the channel", func() {
+			m := hyperscan.ScanChan(context.Background(), bdb, []byte("foo bar foo"), nil, 1)
+
+			var ends []uint64
+
+			for e := range m.C {
+				ends = append(ends, e.To())
+			}
+
+			So(m.Err(), ShouldBeNil)
+			So(ends, ShouldResemble, []uint64{3, 11})
+		})
+
+		Convey("When cancel the scanning before receiving all the matches", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+
+			m := hyperscan.ScanChan(ctx, bdb, []byte(strings.Repeat("foo", 100)), nil, 0)
+
+			<-m.C
+			cancel()
+
+			So(errors.Is(m.Err(), context.Canceled), ShouldBeTrue)
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+
+	Convey("Given a stream database", t, func() {
+		sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`foo`))
+		So(err, ShouldBeNil)
+
+		m := hyperscan.ScanReaderChan(context.Background(), sdb, strings.NewReader("foo bar foo"), nil, 4)
+
+		n := 0
+
+		for range m.C {
+			n++
+		}
+
+		So(m.Err(), ShouldBeNil)
+		So(n, ShouldEqual, 2)
+		So(sdb.Close(), ShouldBeNil)
+	})
+}
@@ -0,0 +1,99 @@
+package hyperscan
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ChunkSpan is the chunks which a stream match spanned, with the chunk-local offsets.
+//
+// The chunks are numbered from zero in the order scanned, From is local to the First chunk,
+// and To is local to the Last chunk. The start of a match is only known with the `SomLeftMost` flag.
+type ChunkSpan struct {
+	First, Last int
+	From, To    uint64
+}
+
+// ChunkMatchHandler handles the stream match with the absolute offsets and the chunks it spanned.
+type ChunkMatchHandler func(id uint, from, to uint64, flags uint, span ChunkSpan, context interface{}) error
+
+// ChunkStream is a stream that reports the chunks which the matches spanned,
+// so the matches could be correlated to the packet boundaries.
+type ChunkStream struct {
+	stream  Stream
+	handler ChunkMatchHandler
+	history int
+	base    int      // the number of the chunk at starts[0]
+	starts  []uint64 // the absolute offsets of the retained chunks
+	total   uint64
+}
+
+// NewChunkStream opens a stream reporting the chunks of matches to the handler.
+//
+// The offsets of the last history chunks are retained, the older are dropped when history is positive,
+// and the span of a match starting before them is clamped to the oldest retained chunk.
+func NewChunkStream(db StreamDatabase, flags ScanFlag, scratch *Scratch, history int,
+	handler ChunkMatchHandler, context interface{},
+) (*ChunkStream, error) {
+	cs := &ChunkStream{handler: handler, history: history}
+
+	s, err := db.Open(flags, scratch, cs.handle, context)
+	if err != nil {
+		return nil, fmt.Errorf("open stream, %w", err)
+	}
+
+	cs.stream = s
+
+	return cs, nil
+}
+
+// locate returns the number of chunk containing the offset and the local offset in it.
+func (cs *ChunkStream) locate(off uint64) (int, uint64) {
+	i := sort.Search(len(cs.starts), func(i int) bool { return cs.starts[i] > off }) - 1
+	if i < 0 {
+		if len(cs.starts) == 0 {
+			return 0, off
+		}
+
+		i = 0
+		off = cs.starts[0]
+	}
+
+	return cs.base + i, off - cs.starts[i]
+}
+
+func (cs *ChunkStream) handle(id uint, from, to uint64, flags uint, context interface{}) error {
+	var span ChunkSpan
+
+	span.First, span.From = cs.locate(from)
+
+	if to > from {
+		// the match ending at the chunk boundary belongs to the previous chunk.
+		span.Last, span.To = cs.locate(to - 1)
+		span.To++
+	} else {
+		span.Last, span.To = span.First, span.From
+	}
+
+	return cs.handler(id, from, to, flags, span, context)
+}
+
+// Scan the data as the next chunk of the stream.
+func (cs *ChunkStream) Scan(data []byte) error {
+	cs.starts = append(cs.starts, cs.total)
+	cs.total += uint64(len(data))
+
+	if cs.history > 0 && len(cs.starts) > cs.history {
+		n := len(cs.starts) - cs.history
+		cs.starts = append(cs.starts[:0], cs.starts[n:]...)
+		cs.base += n
+	}
+
+	return cs.stream.Scan(data) // nolint: wrapcheck
+}
+
+// Chunks returns the number of chunks scanned.
+func (cs *ChunkStream) Chunks() int { return cs.base + len(cs.starts) }
+
+// Close the stream, the end of data matches are reported to the handler.
+func (cs *ChunkStream) Close() error { return cs.stream.Close() } // nolint: wrapcheck
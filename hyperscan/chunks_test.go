@@ -0,0 +1,61 @@
+package hyperscan_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestChunkStream(t *testing.T) {
+	Convey("Given a stream database", t, func() {
+		sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`foobar`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		var spans []hyperscan.ChunkSpan
+		var offsets [][]uint64
+
+		handler := func(id uint, from, to uint64, flags uint, span hyperscan.ChunkSpan, context interface{}) error {
+			offsets = append(offsets, []uint64{from, to})
+			spans = append(spans, span)
+
+			return nil
+		}
+
+		scan := func(cs *hyperscan.ChunkStream) {
+			for _, chunk := range []string{"abcfo", "ob", "ar"} {
+				So(cs.Scan([]byte(chunk)), ShouldBeNil)
+			}
+
+			So(cs.Chunks(), ShouldEqual, 3)
+			So(cs.Close(), ShouldBeNil)
+		}
+
+		Convey("When scan a match spanning the chunks", func() {
+			cs, err := hyperscan.NewChunkStream(sdb, 0, nil, 0, handler, nil)
+			So(err, ShouldBeNil)
+
+			scan(cs)
+
+			Convey("Then the match reports the absolute offsets and the chunks", func() {
+				So(offsets, ShouldResemble, [][]uint64{{3, 9}})
+				So(spans, ShouldResemble, []hyperscan.ChunkSpan{{First: 0, Last: 2, From: 3, To: 2}})
+			})
+		})
+
+		Convey("When the history is limited", func() {
+			cs, err := hyperscan.NewChunkStream(sdb, 0, nil, 1, handler, nil)
+			So(err, ShouldBeNil)
+
+			scan(cs)
+
+			Convey("Then the span is clamped to the retained chunk", func() {
+				So(offsets, ShouldResemble, [][]uint64{{3, 9}})
+				So(spans, ShouldResemble, []hyperscan.ChunkSpan{{First: 2, Last: 2, From: 0, To: 2}})
+			})
+		})
+
+		So(sdb.Close(), ShouldBeNil)
+	})
+}
@@ -0,0 +1,111 @@
+package hyperscan
+
+// CoalesceMode is the mode to coalesce the overlapping or adjacent matches.
+type CoalesceMode int
+
+const (
+	// CoalescePerPattern merges the overlapping or adjacent matches of the same pattern.
+	CoalescePerPattern CoalesceMode = iota
+	// CoalesceAcrossPatterns merges the overlapping or adjacent matches of all patterns,
+	// the merged match is reported with the id and flags of the first match.
+	CoalesceAcrossPatterns
+)
+
+type coalescedMatch struct {
+	id       uint
+	from, to uint64
+	flags    uint
+	context  interface{}
+}
+
+// Coalescer merges the overlapping or adjacent matches before passing them to the handler.
+//
+// The start of match is only accurate with the SomLeftMost flag, otherwise all the matches of a pattern are merged.
+// The pending matches must be flushed after the scanning.
+//
+//	c := hyperscan.NewCoalescer(hyperscan.CoalescePerPattern, handler)
+//	err := c.Done(db.Scan(data, nil, c.Handle, nil))
+type Coalescer struct {
+	mode    CoalesceMode
+	handler MatchHandler
+	pending []coalescedMatch
+}
+
+// NewCoalescer returns a coalescer which passes the merged matches to the handler.
+func NewCoalescer(mode CoalesceMode, handler MatchHandler) *Coalescer {
+	return &Coalescer{mode: mode, handler: handler}
+}
+
+// Handle the match event, the pending match is passed to the handler when it can't be merged any more.
+func (c *Coalescer) Handle(id uint, from, to uint64, flags uint, context interface{}) error {
+	for i := range c.pending {
+		m := &c.pending[i]
+
+		if c.mode == CoalescePerPattern && m.id != id {
+			continue
+		}
+
+		if from <= m.to {
+			if from < m.from {
+				m.from = from
+			}
+
+			if to > m.to {
+				m.to = to
+			}
+
+			m.context = context
+
+			return nil
+		}
+
+		if err := c.handler(m.id, m.from, m.to, m.flags, m.context); err != nil {
+			return err
+		}
+
+		c.pending = append(c.pending[:i], c.pending[i+1:]...)
+
+		break
+	}
+
+	c.pending = append(c.pending, coalescedMatch{id, from, to, flags, context})
+
+	return nil
+}
+
+// Flush passes the pending matches to the handler.
+func (c *Coalescer) Flush() error {
+	for len(c.pending) > 0 {
+		m := c.pending[0]
+		c.pending = c.pending[1:]
+
+		if err := c.handler(m.id, m.from, m.to, m.flags, m.context); err != nil {
+			c.pending = c.pending[:0]
+
+			return err
+		}
+	}
+
+	c.pending = c.pending[:0]
+
+	return nil
+}
+
+// Done flushes the pending matches if the scanning succeeded, or drops them and returns the error.
+func (c *Coalescer) Done(err error) error {
+	if err != nil {
+		c.pending = c.pending[:0]
+
+		return err
+	}
+
+	return c.Flush()
+}
+
+// ScanCoalesced scans the data with the block scanner, and passes the coalesced matches to the handler.
+func ScanCoalesced(scanner BlockScanner, data []byte, scratch *Scratch, mode CoalesceMode,
+	handler MatchHandler, context interface{}) error {
+	c := NewCoalescer(mode, handler)
+
+	return c.Done(scanner.Scan(data, scratch, c.Handle, context))
+}
@@ -0,0 +1,68 @@
+package hyperscan_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestCoalescer(t *testing.T) {
+	Convey("Given a coalescer", t, func() {
+		var matches [][]uint64
+
+		handler := func(id uint, from, to uint64, flags uint, context interface{}) error {
+			matches = append(matches, []uint64{uint64(id), from, to})
+
+			return nil
+		}
+
+		events := [][]uint64{{0, 0, 3}, {1, 2, 5}, {0, 3, 6}, {0, 8, 10}, {1, 9, 12}}
+
+		Convey("When coalesce the matches per pattern", func() {
+			c := hyperscan.NewCoalescer(hyperscan.CoalescePerPattern, handler)
+
+			for _, e := range events {
+				So(c.Handle(uint(e[0]), e[1], e[2], 0, nil), ShouldBeNil)
+			}
+
+			So(c.Flush(), ShouldBeNil)
+
+			So(matches, ShouldResemble, [][]uint64{{0, 0, 6}, {1, 2, 5}, {0, 8, 10}, {1, 9, 12}})
+		})
+
+		Convey("When coalesce the matches across patterns", func() {
+			c := hyperscan.NewCoalescer(hyperscan.CoalesceAcrossPatterns, handler)
+
+			for _, e := range events {
+				So(c.Handle(uint(e[0]), e[1], e[2], 0, nil), ShouldBeNil)
+			}
+
+			So(c.Done(nil), ShouldBeNil)
+
+			So(matches, ShouldResemble, [][]uint64{{0, 0, 6}, {0, 8, 12}})
+		})
+	})
+
+	Convey("Given a block database with SOM", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`a+`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		Convey("When scan the data with coalescing", func() {
+			var matches [][]uint64
+
+			err := hyperscan.ScanCoalesced(bdb, []byte("aaa b aa"), nil, hyperscan.CoalescePerPattern,
+				func(id uint, from, to uint64, flags uint, context interface{}) error {
+					matches = append(matches, []uint64{from, to})
+
+					return nil
+				}, nil)
+
+			So(err, ShouldBeNil)
+			So(matches, ShouldResemble, [][]uint64{{0, 3}, {6, 8}})
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
@@ -2,10 +2,18 @@ package hyperscan
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"regexp"
+	"strings"
+	"sync"
 )
 
 // Database is an immutable database that can be used by the Hyperscan scanning API.
+//
+// The databases created by this package also implement `io.WriterTo`, which serializes the database
+// without holding the whole bytes in Go memory, and `io.ReaderFrom`, which replaces the database
+// with a serialized database in the same mode read from the reader.
 type Database interface {
 	// Provides information about a database.
 	Info() (DbInfo, error)
@@ -82,6 +90,58 @@ func (i DbInfo) Mode() (ModeFlag, error) {
 	return ParseModeFlag(matched[3])
 }
 
+// Platform is the target platform for the supplied database.
+//
+// The database info only contains the CPU features, so the tuning flag will always be `Generic`.
+func (i DbInfo) Platform() (Platform, error) {
+	features, err := i.Features()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPlatform(Generic, features), nil
+}
+
+// Features is the CPU features required by the supplied database, the unknown features are ignored.
+func (i DbInfo) Features() (CpuFeature, error) {
+	matched := regexInfo.FindStringSubmatch(string(i))
+
+	if len(matched) != infoMatches {
+		return 0, fmt.Errorf("database info, %w", ErrInvalid)
+	}
+
+	var features CpuFeature
+
+	for _, name := range strings.Fields(matched[2]) {
+		for feature, s := range cpuFeatures {
+			if s == name {
+				features |= feature
+			}
+		}
+	}
+
+	return features, nil
+}
+
+// Compatible checks the supplied database could run on the current host.
+func (i DbInfo) Compatible() error {
+	features, err := i.Features()
+	if err != nil {
+		return err
+	}
+
+	host, err := hsPopulatePlatform()
+	if err != nil {
+		return err
+	}
+
+	if missing := features &^ host.CpuFeatures(); missing != 0 {
+		return fmt.Errorf("missing CPU features `%s`, %w", missing, ErrDatabasePlatformError)
+	}
+
+	return nil
+}
+
 // Version identify this release version. The return version is a string
 // containing the version number of this release build and the date of the build.
 func Version() string { return hsVersion() }
@@ -93,27 +153,79 @@ type database interface {
 	Db() hsDatabase
 }
 
+type baseDatabaser interface {
+	base() *baseDatabase
+}
+
 type baseDatabase struct {
-	db hsDatabase
+	db        hsDatabase
+	patterns  int
+	inventory Patterns     // the patterns compiled into the database, if known.
+	gen       uint64       // the generation of database, increased when it is replaced by unmarshaling.
+	release   func() error // release the memory of database which isn't allocated by Hyperscan.
 }
 
 func newBaseDatabase(db hsDatabase) *baseDatabase {
-	return &baseDatabase{db}
+	return trackDatabase(&baseDatabase{db: db})
+}
+
+func (d *baseDatabase) base() *baseDatabase { return d }
+
+// compatiblePlatform checks the serialized database could be run on the current host.
+func compatiblePlatform(data []byte) error {
+	info, err := SerializedDatabaseInfo(data)
+	if err != nil {
+		return err
+	}
+
+	return info.Compatible()
+}
+
+func deserializeDatabase(data []byte) (hsDatabase, error) {
+	data, err := decompress(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := compatiblePlatform(data); err != nil {
+		return nil, err
+	}
+
+	return hsDeserializeDatabase(data)
+}
+
+// deserializeDatabaseFor reconstructs a database from a stream of bytes after checking it was built for the mode.
+func deserializeDatabaseFor(data []byte, expected ModeFlag) (hsDatabase, error) {
+	info, err := SerializedDatabaseInfo(data)
+	if err != nil {
+		return nil, err
+	}
+
+	mode, err := info.Mode()
+	if err != nil {
+		return nil, err
+	}
+
+	if mode != expected {
+		return nil, fmt.Errorf("%s database expected but got %s, %w", expected, mode, ErrDatabaseModeError)
+	}
+
+	return deserializeDatabase(data)
 }
 
 // UnmarshalDatabase reconstruct a pattern database from a stream of bytes.
 func UnmarshalDatabase(data []byte) (Database, error) {
-	db, err := hsDeserializeDatabase(data)
+	db, err := deserializeDatabase(data)
 	if err != nil {
 		return nil, err
 	}
 
-	return &baseDatabase{db}, nil
+	return newBaseDatabase(db), nil
 }
 
 // UnmarshalBlockDatabase reconstruct a block database from a stream of bytes.
 func UnmarshalBlockDatabase(data []byte) (BlockDatabase, error) {
-	db, err := hsDeserializeDatabase(data)
+	db, err := deserializeDatabaseFor(data, BlockMode)
 	if err != nil {
 		return nil, err
 	}
@@ -123,7 +235,7 @@ func UnmarshalBlockDatabase(data []byte) (BlockDatabase, error) {
 
 // UnmarshalStreamDatabase reconstruct a stream database from a stream of bytes.
 func UnmarshalStreamDatabase(data []byte) (StreamDatabase, error) {
-	db, err := hsDeserializeDatabase(data)
+	db, err := deserializeDatabaseFor(data, StreamMode)
 	if err != nil {
 		return nil, err
 	}
@@ -133,7 +245,7 @@ func UnmarshalStreamDatabase(data []byte) (StreamDatabase, error) {
 
 // UnmarshalVectoredDatabase reconstruct a vectored database from a stream of bytes.
 func UnmarshalVectoredDatabase(data []byte) (VectoredDatabase, error) {
-	db, err := hsDeserializeDatabase(data)
+	db, err := deserializeDatabaseFor(data, VectoredMode)
 	if err != nil {
 		return nil, err
 	}
@@ -142,10 +254,22 @@ func UnmarshalVectoredDatabase(data []byte) (VectoredDatabase, error) {
 }
 
 // SerializedDatabaseSize reports the size that would be required by a database if it were deserialized.
-func SerializedDatabaseSize(data []byte) (int, error) { return hsSerializedDatabaseSize(data) }
+func SerializedDatabaseSize(data []byte) (int, error) {
+	data, err := decompress(data)
+	if err != nil {
+		return 0, err
+	}
+
+	return hsSerializedDatabaseSize(data)
+}
 
 // SerializedDatabaseInfo provides information about a serialized database.
 func SerializedDatabaseInfo(data []byte) (DbInfo, error) {
+	data, err := decompress(data)
+	if err != nil {
+		return "", err
+	}
+
 	i, err := hsSerializedDatabaseInfo(data)
 
 	return DbInfo(i), err
@@ -161,18 +285,188 @@ func (d *baseDatabase) Info() (DbInfo, error) {
 	return DbInfo(i), err
 }
 
-func (d *baseDatabase) Close() error { return hsFreeDatabase(d.db) }
+// DatabasePlatform provides the target platform of the database.
+func DatabasePlatform(db Database) (Platform, error) {
+	i, err := db.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	return i.Platform()
+}
+
+func (d *baseDatabase) Close() error {
+	if d.db == nil {
+		return fmt.Errorf("database closed, %w", ErrInvalid)
+	}
+
+	db, release := d.db, d.release
+	d.db, d.release = nil, nil
+
+	if release != nil {
+		return release()
+	}
+
+	return hsFreeDatabase(db)
+}
 
 func (d *baseDatabase) Marshal() ([]byte, error) { return hsSerializeDatabase(d.db) }
 
-func (d *baseDatabase) Unmarshal(data []byte) error { return hsDeserializeDatabaseAt(data, d.db) }
+// CloneDatabase clones the database with an independent lifetime, it should be closed separately.
+func CloneDatabase(d Database) (Database, error) {
+	data, err := d.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := hsDeserializeDatabase(data)
+	if err != nil {
+		return nil, err
+	}
+
+	mode, err := databaseMode(db)
+	if err != nil {
+		_ = hsFreeDatabase(db)
+
+		return nil, err
+	}
+
+	cloned, err := newDatabase(db, mode)
+	if err != nil {
+		_ = hsFreeDatabase(db)
+
+		return nil, err
+	}
+
+	if b, ok := d.(baseDatabaser); ok {
+		base := cloned.(baseDatabaser).base()
+		base.patterns = b.base().patterns
+		base.inventory = b.base().inventory
+	}
+
+	return cloned, nil
+}
+
+// MarshalDatabaseTo serializes the database into the buffer,
+// it returns the required size and `io.ErrShortBuffer` if the buffer is too small.
+func MarshalDatabaseTo(db Database, buf []byte) (int, error) {
+	if d, ok := db.(database); ok {
+		return hsSerializeDatabaseInto(d.Db(), buf)
+	}
+
+	data, err := db.Marshal()
+	if err != nil {
+		return 0, err
+	}
+
+	if len(data) > len(buf) {
+		return len(data), fmt.Errorf("%d bytes required, %w", len(data), io.ErrShortBuffer)
+	}
+
+	return copy(buf, data), nil
+}
+
+// WriteTo serializes the database and writes it to the writer.
+func (d *baseDatabase) WriteTo(w io.Writer) (int64, error) { return hsSerializeDatabaseTo(d.db, w) }
+
+// ReadFrom replaces the database with a serialized database in the same mode read from the reader.
+func (d *baseDatabase) ReadFrom(r io.Reader) (int64, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return int64(len(data)), fmt.Errorf("read database, %w", err)
+	}
+
+	n := int64(len(data))
+
+	info, err := d.Info()
+	if err != nil {
+		return n, err
+	}
+
+	mode, err := info.Mode()
+	if err != nil {
+		return n, err
+	}
+
+	db, err := deserializeDatabase(data)
+	if err != nil {
+		return n, err
+	}
+
+	if err = checkMode(db, mode); err != nil {
+		_ = hsFreeDatabase(db)
+
+		return n, err
+	}
+
+	if err = d.Close(); err != nil {
+		_ = hsFreeDatabase(db)
+
+		return n, err
+	}
+
+	d.db = db
+	d.release = nil
+	d.patterns, d.inventory = 0, nil
+	d.gen++
+
+	return n, nil
+}
+
+// databaseMode returns the mode of database.
+func databaseMode(db hsDatabase) (ModeFlag, error) {
+	info, err := hsDatabaseInfo(db)
+	if err != nil {
+		return 0, err
+	}
+
+	return DbInfo(info).Mode()
+}
+
+// checkMode checks the database was built for the mode.
+func checkMode(db hsDatabase, expected ModeFlag) error {
+	mode, err := databaseMode(db)
+	if err != nil {
+		return err
+	}
+
+	if mode != expected {
+		return fmt.Errorf("%s database expected but got %s, %w", expected, mode, ErrDatabaseModeError)
+	}
+
+	return nil
+}
+
+func (d *baseDatabase) Unmarshal(data []byte) error {
+	if d.release != nil {
+		// The memory of database may be mapped in read-only mode or shared with the other processes.
+		return fmt.Errorf("unmarshal into the memory-mapped database, %w", ErrInvalid)
+	}
+
+	data, err := decompress(data)
+	if err != nil {
+		return err
+	}
+
+	if err = hsDeserializeDatabaseAt(data, d.db); err != nil {
+		return err
+	}
+
+	d.patterns, d.inventory = 0, nil
+	d.gen++
+
+	return nil
+}
 
 type blockDatabase struct {
 	*blockMatcher
+
+	mu      sync.Mutex
+	derived *derivedStream // the stream database compiled from the same patterns for `ScanReader` and `ScanFile`.
 }
 
 func newBlockDatabase(db hsDatabase) *blockDatabase {
-	return &blockDatabase{newBlockMatcher(newBlockScanner(newBaseDatabase(db)))}
+	return &blockDatabase{blockMatcher: newBlockMatcher(newBlockScanner(newBaseDatabase(db)))}
 }
 
 type streamDatabase struct {
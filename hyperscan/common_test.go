@@ -2,6 +2,9 @@
 package hyperscan_test
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"regexp"
 	"testing"
 
@@ -51,6 +54,14 @@ func TestBaseDatabase(t *testing.T) {
 			})
 		})
 
+		Convey("When get platform", func() {
+			platform, err := hyperscan.DatabasePlatform(bdb)
+
+			So(err, ShouldBeNil)
+			So(platform.Tune(), ShouldEqual, hyperscan.Generic)
+			So(platform.CpuFeatures()&^hyperscan.PopulatePlatform().CpuFeatures(), ShouldEqual, 0)
+		})
+
 		Convey("When serialize database", func() {
 			data, err := bdb.Marshal()
 
@@ -123,6 +134,36 @@ func TestBaseDatabase(t *testing.T) {
 	})
 }
 
+func TestCrossCompile(t *testing.T) {
+	Convey("Given a database compiled for the generic platform", t, func() {
+		data, err := hyperscan.CrossCompile(hyperscan.NewPlatform(hyperscan.Generic, 0), hyperscan.BlockMode,
+			hyperscan.NewPattern("test"))
+
+		So(err, ShouldBeNil)
+		So(data, ShouldNotBeEmpty)
+
+		Convey("When get platform of the serialized database", func() {
+			info, err := hyperscan.SerializedDatabaseInfo(data)
+
+			So(err, ShouldBeNil)
+
+			platform, err := info.Platform()
+
+			So(err, ShouldBeNil)
+			So(platform.CpuFeatures(), ShouldEqual, 0)
+		})
+
+		Convey("When load it on the current host", func() {
+			db, err := hyperscan.UnmarshalBlockDatabase(data)
+
+			So(err, ShouldBeNil)
+			So(db.MatchString("abctestdef"), ShouldBeTrue)
+
+			So(db.Close(), ShouldBeNil)
+		})
+	})
+}
+
 func TestBlockDatabase(t *testing.T) {
 	Convey("Give a block database", t, func() {
 		bdb, err := hyperscan.NewBlockDatabase(&hyperscan.Pattern{Expression: "test"})
@@ -210,3 +251,169 @@ func TestStreamDatabase(t *testing.T) {
 		So(sdb.Close(), ShouldBeNil)
 	})
 }
+
+func TestDatabaseWriteTo(t *testing.T) {
+	Convey("Given a block database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`), hyperscan.NewPattern(`bar\d+`))
+
+		So(err, ShouldBeNil)
+
+		Convey("When write it to a buffer", func() {
+			var buf bytes.Buffer
+
+			n, err := bdb.(io.WriterTo).WriteTo(&buf)
+
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, buf.Len())
+
+			data, err := bdb.Marshal()
+
+			So(err, ShouldBeNil)
+			So(buf.Bytes(), ShouldResemble, data)
+
+			Convey("Then read it to another block database", func() {
+				other, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`baz`))
+
+				So(err, ShouldBeNil)
+
+				n, err := other.(io.ReaderFrom).ReadFrom(&buf)
+
+				So(err, ShouldBeNil)
+				So(n, ShouldEqual, len(data))
+				So(other.MatchString("bar123"), ShouldBeTrue)
+				So(other.MatchString("baz"), ShouldBeFalse)
+				So(other.Close(), ShouldBeNil)
+			})
+
+			Convey("Then read it to a stream database", func() {
+				sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`baz`))
+
+				So(err, ShouldBeNil)
+
+				_, err = sdb.(io.ReaderFrom).ReadFrom(&buf)
+
+				So(errors.Is(err, hyperscan.ErrDatabaseModeError), ShouldBeTrue)
+				So(sdb.Close(), ShouldBeNil)
+			})
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
+
+func TestDatabaseMarshalTo(t *testing.T) {
+	Convey("Given a block database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`), hyperscan.NewPattern(`bar\d+`))
+
+		So(err, ShouldBeNil)
+
+		data, err := bdb.Marshal()
+
+		So(err, ShouldBeNil)
+
+		Convey("When marshal it to a large enough buffer", func() {
+			buf := make([]byte, len(data)+10)
+
+			n, err := hyperscan.MarshalDatabaseTo(bdb, buf)
+
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, len(data))
+			So(buf[:n], ShouldResemble, data)
+		})
+
+		Convey("When marshal it to a small buffer", func() {
+			n, err := hyperscan.MarshalDatabaseTo(bdb, make([]byte, 4))
+
+			So(errors.Is(err, io.ErrShortBuffer), ShouldBeTrue)
+			So(n, ShouldEqual, len(data))
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
+
+func TestDbInfoFeatures(t *testing.T) {
+	Convey("Given some database info", t, func() {
+		features, err := hyperscan.DbInfo("Version: 5.4.0 Features: AVX2 AVX512 Mode: BLOCK").Features()
+
+		So(err, ShouldBeNil)
+		So(features, ShouldEqual, hyperscan.AVX2|hyperscan.AVX512)
+		So(features.Has(hyperscan.AVX2), ShouldBeTrue)
+
+		features, err = hyperscan.DbInfo("Version: 5.4.0 Features: AVX2 UNKNOWN Mode: STREAM").Features()
+
+		So(err, ShouldBeNil)
+		So(features, ShouldEqual, hyperscan.AVX2)
+		So(features.Has(hyperscan.AVX2|hyperscan.AVX512), ShouldBeFalse)
+
+		features, err = hyperscan.DbInfo("Version: 5.4.0 Features:  Mode: BLOCK").Features()
+
+		So(err, ShouldBeNil)
+		So(features, ShouldEqual, 0)
+
+		_, err = hyperscan.DbInfo("invalid").Features()
+
+		So(errors.Is(err, hyperscan.ErrInvalid), ShouldBeTrue)
+
+		Convey("When check the info without CPU features is compatible", func() {
+			So(hyperscan.DbInfo("Version: 5.4.0 Features:  Mode: BLOCK").Compatible(), ShouldBeNil)
+		})
+	})
+}
+
+func TestDatabaseClone(t *testing.T) {
+	Convey("Given a block database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`), hyperscan.NewPattern(`bar\d+`))
+
+		So(err, ShouldBeNil)
+
+		Convey("When clone the database", func() {
+			db, err := hyperscan.CloneDatabase(bdb)
+
+			So(err, ShouldBeNil)
+
+			cloned, ok := db.(hyperscan.BlockDatabase)
+
+			So(ok, ShouldBeTrue)
+
+			Convey("Then it could be used after the original one closed", func() {
+				So(bdb.Close(), ShouldBeNil)
+
+				So(cloned.MatchString("bar123"), ShouldBeTrue)
+				So(cloned.Close(), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestUnmarshalDatabaseMode(t *testing.T) {
+	Convey("Given a serialized stream database", t, func() {
+		sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`test`))
+
+		So(err, ShouldBeNil)
+
+		data, err := sdb.Marshal()
+
+		So(err, ShouldBeNil)
+		So(sdb.Close(), ShouldBeNil)
+
+		Convey("When unmarshal it as a block database", func() {
+			_, err := hyperscan.UnmarshalBlockDatabase(data)
+
+			So(errors.Is(err, hyperscan.ErrDatabaseModeError), ShouldBeTrue)
+		})
+
+		Convey("When unmarshal it as a vectored database", func() {
+			_, err := hyperscan.UnmarshalVectoredDatabase(data)
+
+			So(errors.Is(err, hyperscan.ErrDatabaseModeError), ShouldBeTrue)
+		})
+
+		Convey("When unmarshal it as a stream database", func() {
+			db, err := hyperscan.UnmarshalStreamDatabase(data)
+
+			So(err, ShouldBeNil)
+			So(db.Close(), ShouldBeNil)
+		})
+	})
+}
@@ -2,12 +2,15 @@ package hyperscan
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
+	"unicode/utf8"
 )
 
 var (
@@ -15,8 +18,15 @@ var (
 	ErrNoFound = errors.New("no found")
 	// ErrUnexpected means item is unexpected.
 	ErrUnexpected = errors.New("unexpected")
+	// ErrConflict means patterns are conflicted.
+	ErrConflict = errors.New("conflict")
+	// ErrDatabaseTooLarge means the compiled database exceeds the size budget.
+	ErrDatabaseTooLarge = errors.New("database too large")
 )
 
+// logicalCombination is the flag of logical combination, which is only available in Hyperscan 5.0 or later.
+var logicalCombination CompileFlag
+
 // Expression of pattern.
 type Expression string
 
@@ -31,6 +41,9 @@ type Pattern struct {
 	Expression             // The expression to parse.
 	Flags      CompileFlag // Flags which modify the behaviour of the expression.
 	Id         int         // The ID number to be associated with the corresponding pattern
+	Tags       []string    // The tags (e.g. category or namespace) of pattern, which reported with the matches.
+	Priority   int         // The priority of pattern, the higher priority wins the overlapping matches.
+	Metadata   interface{} // The user metadata of pattern, which could be used by the pattern handler.
 	info       *ExprInfo
 	ext        *ExprExt
 }
@@ -52,9 +65,19 @@ func (p *Pattern) IsValid() bool {
 }
 
 // Info provides information about a regular expression.
+//
+// If the pattern has additional parameters, the information will take them into account.
 func (p *Pattern) Info() (*ExprInfo, error) {
 	if p.info == nil {
-		info, err := hsExpressionInfo(string(p.Expression), p.Flags)
+		var info *ExprInfo
+		var err error
+
+		if p.ext != nil {
+			info, err = hsExpressionExtInfo(string(p.Expression), p.Flags, p.ext)
+		} else {
+			info, err = hsExpressionInfo(string(p.Expression), p.Flags)
+		}
+
 		if err != nil {
 			return nil, err
 		}
@@ -65,6 +88,16 @@ func (p *Pattern) Info() (*ExprInfo, error) {
 	return p.info, nil
 }
 
+// ExpressionInfo provides information about a regular expression with the compile flags.
+func ExpressionInfo(expr string, flags CompileFlag) (*ExprInfo, error) {
+	return hsExpressionInfo(expr, flags)
+}
+
+// ExpressionExtInfo provides information about a regular expression with the compile flags and additional parameters.
+func ExpressionExtInfo(expr string, flags CompileFlag, ext *ExprExt) (*ExprInfo, error) {
+	return hsExpressionExtInfo(expr, flags, ext)
+}
+
 // WithExt is used to set the additional parameters related to an expression.
 func (p *Pattern) WithExt(exts ...Ext) *Pattern {
 	if p.ext == nil {
@@ -72,22 +105,27 @@ func (p *Pattern) WithExt(exts ...Ext) *Pattern {
 	}
 
 	p.ext.With(exts...)
+	p.info = nil
+
+	return p
+}
+
+// WithTags is used to add the tags of pattern.
+func (p *Pattern) WithTags(tags ...string) *Pattern {
+	p.Tags = append(p.Tags, tags...)
 
 	return p
 }
 
-// Ext provides additional parameters related to an expression.
+// Ext provides additional parameters related to an expression, which could be modified in place.
 func (p *Pattern) Ext() (*ExprExt, error) {
 	if p.ext == nil {
-		ext, info, err := hsExpressionExt(string(p.Expression), p.Flags)
-		if err != nil {
-			return nil, err
-		}
-
-		p.ext = ext
-		p.info = info
+		p.ext = new(ExprExt)
 	}
 
+	// The information may be changed with the parameters.
+	p.info = nil
+
 	return p.ext, nil
 }
 
@@ -100,7 +138,7 @@ func (p *Pattern) String() string {
 
 	fmt.Fprintf(&b, "/%s/%s", p.Expression, p.Flags)
 
-	if p.ext != nil {
+	if p.ext != nil && p.ext.Flags != 0 {
 		b.WriteString(p.ext.String())
 	}
 
@@ -110,12 +148,16 @@ func (p *Pattern) String() string {
 /*
 ParsePattern parse pattern from a formated string.
 
-	<integer id>:/<expression>/<flags>
+	<integer id>:/<expression>/<flags>{<extensions>}
 
 For example, the following pattern will match `test` in the caseless and multi-lines mode
 
 	/test/im
 
+The optional extensions are the additional parameters in `hsbench` style, such as
+
+	/test/im{min_offset=10,max_offset=100}
+
 */
 func ParsePattern(s string) (*Pattern, error) {
 	var p Pattern
@@ -137,7 +179,7 @@ func ParsePattern(s string) (*Pattern, error) {
 		p.Expression = Expression(s[1:n])
 		s = s[n+1:]
 
-		if n = strings.Index(s, "{"); n > 0 && strings.HasSuffix(s, "}") {
+		if n = strings.Index(s, "{"); n >= 0 && strings.HasSuffix(s, "}") {
 			ext, err := ParseExprExt(s[n:])
 			if err != nil {
 				return nil, fmt.Errorf("invalid expression extensions `%s`, %w", s[n:], err)
@@ -157,16 +199,26 @@ func ParsePattern(s string) (*Pattern, error) {
 		p.Expression = Expression(s)
 	}
 
-	info, err := hsExpressionInfo(string(p.Expression), p.Flags)
-	if err != nil {
-		return nil, fmt.Errorf("invalid pattern `%s`, %w", p.Expression, err)
+	if err := p.validate(); err != nil {
+		return nil, err
 	}
 
-	p.info = info
-
 	return &p, nil
 }
 
+func (p *Pattern) validate() error {
+	if logicalCombination != 0 && p.Flags&logicalCombination == logicalCombination {
+		// The logical combination can't be validated without the patterns it references.
+		return nil
+	}
+
+	if _, err := p.Info(); err != nil {
+		return fmt.Errorf("invalid pattern `%s`, %w", p.Expression, err)
+	}
+
+	return nil
+}
+
 // ParsePatterns parse lines as `Patterns`.
 func ParsePatterns(r io.Reader) (patterns Patterns, err error) {
 	s := bufio.NewScanner(r)
@@ -195,6 +247,138 @@ func ParsePatterns(r io.Reader) (patterns Patterns, err error) {
 	return
 }
 
+/*
+ParsePatternFile parse patterns from the file format used by the Hyperscan tools, such as `hsbench` and `hscheck`.
+
+Each line of the file is a pattern with an unique integer ID, the empty lines and comments will be skipped.
+
+	# comment
+	<integer id>:/<expression>/<flags>
+
+*/
+func ParsePatternFile(r io.Reader) (Patterns, error) {
+	var patterns Patterns
+
+	s := bufio.NewScanner(r)
+	lines := make(map[int]int)
+
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			// skip empty line and comment
+			continue
+		}
+
+		if strings.Index(line, ":/") <= 0 {
+			return nil, fmt.Errorf("line %d, missing pattern id, %w", n, ErrInvalid)
+		}
+
+		p, err := ParsePattern(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d, %w", n, err)
+		}
+
+		if prev, exists := lines[p.Id]; exists {
+			return nil, fmt.Errorf("line %d, duplicate pattern id %d defined at line %d, %w", n, p.Id, prev, ErrInvalid)
+		}
+
+		lines[p.Id] = n
+		patterns = append(patterns, p)
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("read patterns, %w", err)
+	}
+
+	return patterns, nil
+}
+
+// Normalize assigns unique IDs to the patterns without ID (zero), and returns the mapping of ID to pattern.
+//
+// It returns an error if some patterns have the same ID,
+// or the identical expressions have different flags.
+func (p Patterns) Normalize() (map[int]*Pattern, error) {
+	ids := make(map[int]*Pattern, len(p))
+	exprs := make(map[Expression]*Pattern, len(p))
+	next := 1
+
+	for _, pattern := range p {
+		if prev, exists := exprs[pattern.Expression]; exists && prev.Flags != pattern.Flags {
+			return nil, fmt.Errorf("pattern %s and %s have different flags, %w", prev, pattern, ErrConflict)
+		}
+
+		exprs[pattern.Expression] = pattern
+
+		if pattern.Id == 0 {
+			continue
+		}
+
+		if prev, exists := ids[pattern.Id]; exists {
+			return nil, fmt.Errorf("pattern %s and %s have the same id, %w", prev, pattern, ErrConflict)
+		}
+
+		ids[pattern.Id] = pattern
+
+		if pattern.Id >= next {
+			next = pattern.Id + 1
+		}
+	}
+
+	for _, pattern := range p {
+		if pattern.Id == 0 {
+			pattern.Id = next
+			ids[next] = pattern
+			next++
+		}
+	}
+
+	return ids, nil
+}
+
+// TaggedHandler returns a match handler which calls the handler with the tags of the matched pattern.
+func (p Patterns) TaggedHandler(handler TaggedMatchHandler) MatchHandler {
+	tags := make(map[uint][]string, len(p))
+
+	for _, pattern := range p {
+		if len(pattern.Tags) > 0 {
+			tags[uint(pattern.Id)] = pattern.Tags
+		}
+	}
+
+	return func(id uint, from, to uint64, flags uint, context interface{}) error {
+		return handler(id, from, to, flags, tags[id], context)
+	}
+}
+
+// PatternHandler returns a match handler which calls the handler with the matched pattern,
+// the pattern is nil if it isn't in the patterns.
+func (p Patterns) PatternHandler(handler PatternMatchHandler) MatchHandler {
+	patterns := make(map[uint]*Pattern, len(p))
+
+	for _, pattern := range p {
+		if _, exists := patterns[uint(pattern.Id)]; !exists {
+			patterns[uint(pattern.Id)] = pattern
+		}
+	}
+
+	return func(id uint, from, to uint64, flags uint, context interface{}) error {
+		return handler(patterns[id], from, to, flags, context)
+	}
+}
+
+// PatternHandler returns a match handler which calls the handler with the pattern compiled into the database.
+//
+// The patterns are only known for the database compiled in the current process, or it returns `ErrNoFound`.
+func PatternHandler(db Database, handler PatternMatchHandler) (MatchHandler, error) {
+	d, ok := db.(baseDatabaser)
+	if !ok || d.base().inventory == nil {
+		return nil, fmt.Errorf("patterns of database, %w", ErrNoFound)
+	}
+
+	return d.base().inventory.PatternHandler(handler), nil
+}
+
 // Platform is a type containing information on the target platform.
 type Platform interface {
 	// Information about the target platform which may be used to guide the optimisation process of the compile.
@@ -207,6 +391,18 @@ type Platform interface {
 // NewPlatform create a new platform information on the target platform.
 func NewPlatform(tune TuneFlag, cpu CpuFeature) Platform { return newPlatformInfo(tune, cpu) }
 
+// platformInfo converts the platform to the information used by the compiler, returns nil for the current host.
+func platformInfo(platform Platform) *hsPlatformInfo {
+	switch p := platform.(type) {
+	case nil:
+		return nil
+	case *hsPlatformInfo:
+		return p
+	default:
+		return newPlatformInfo(p.Tune(), p.CpuFeatures())
+	}
+}
+
 // PopulatePlatform populates the platform information based on the current host.
 func PopulatePlatform() Platform {
 	platform, _ := hsPopulatePlatform()
@@ -249,6 +445,9 @@ type DatabaseBuilder struct {
 	// If not nil, the platform structure is used to determine the target platform for the database.
 	// If nil, a database suitable for running on the current host platform is produced.
 	Platform Platform
+
+	// If positive, the compilation fails when the size of database exceeds it in bytes.
+	MaxSize int
 }
 
 // AddExpressions add more expressions to the database.
@@ -267,37 +466,76 @@ func (b *DatabaseBuilder) AddExpressionWithFlags(expr Expression, flags CompileF
 	return b
 }
 
+// WithPlatform set the target platform with the tuning flags and CPU features for the database.
+func (b *DatabaseBuilder) WithPlatform(tune TuneFlag, cpu CpuFeature) *DatabaseBuilder {
+	b.Platform = NewPlatform(tune, cpu)
+
+	return b
+}
+
+// WithMaxDatabaseSize set the size budget of the database in bytes.
+func (b *DatabaseBuilder) WithMaxDatabaseSize(size int) *DatabaseBuilder {
+	b.MaxSize = size
+
+	return b
+}
+
 // Build a database base on the expressions and platform.
 func (b *DatabaseBuilder) Build() (Database, error) {
 	if b.Patterns == nil {
 		return nil, ErrNoFound
 	}
 
-	mode := b.Mode
+	som := false
 
-	if mode == 0 {
-		mode = BlockMode
-	} else if mode == StreamMode {
-		som := false
-
-		for _, pattern := range b.Patterns {
-			if (pattern.Flags & SomLeftMost) == SomLeftMost {
-				som = true
-			}
+	for _, pattern := range b.Patterns {
+		if (pattern.Flags & SomLeftMost) == SomLeftMost {
+			som = true
 		}
+	}
 
-		if som && mode&(SomHorizonSmallMode|SomHorizonMediumMode|SomHorizonLargeMode) == 0 {
-			mode |= SomHorizonSmallMode
-		}
+	mode, err := somHorizon(b.Mode, som)
+	if err != nil {
+		return nil, err
 	}
 
-	platform, _ := b.Platform.(*hsPlatformInfo)
+	platform := platformInfo(b.Platform)
 
 	db, err := hsCompileMulti(b.Patterns, mode, platform)
 	if err != nil {
 		return nil, err
 	}
 
+	if b.MaxSize > 0 {
+		size, err := hsDatabaseSize(db)
+		if err != nil {
+			_ = hsFreeDatabase(db)
+
+			return nil, err
+		}
+
+		if size > b.MaxSize {
+			_ = hsFreeDatabase(db)
+
+			return nil, fmt.Errorf("database size %d exceeds %d bytes, %w", size, b.MaxSize, ErrDatabaseTooLarge)
+		}
+	}
+
+	d, err := newDatabase(db, mode)
+	if err != nil {
+		_ = hsFreeDatabase(db)
+
+		return nil, err
+	}
+
+	base := d.(baseDatabaser).base()
+	base.patterns = len(b.Patterns)
+	base.inventory = append(Patterns(nil), b.Patterns...)
+
+	return d, nil
+}
+
+func newDatabase(db hsDatabase, mode ModeFlag) (Database, error) {
 	switch mode & ModeMask {
 	case StreamMode:
 		return newStreamDatabase(db), nil
@@ -310,6 +548,202 @@ func (b *DatabaseBuilder) Build() (Database, error) {
 	}
 }
 
+// BuildWithContext is like Build but abandons the compilation when the context is done.
+//
+// The compilation can't be interrupted once it started in Hyperscan,
+// it keeps running in background and the database will be closed when it completed.
+func (b *DatabaseBuilder) BuildWithContext(ctx context.Context) (Database, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err // nolint: wrapcheck
+	}
+
+	type result struct {
+		db  Database
+		err error
+	}
+
+	done := make(chan result, 1)
+
+	go func() {
+		db, err := b.Build()
+
+		done <- result{db, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.db, r.err
+	case <-ctx.Done():
+		go func() {
+			if r := <-done; r.db != nil {
+				r.db.Close()
+			}
+		}()
+
+		return nil, ctx.Err() // nolint: wrapcheck
+	}
+}
+
+// CompileWithContext compiles the patterns to a database in the mode,
+// it returns the error of context if the context is done before the compilation completed.
+func CompileWithContext(ctx context.Context, mode ModeFlag, patterns ...*Pattern) (Database, error) {
+	b := DatabaseBuilder{Patterns: patterns, Mode: mode}
+
+	return b.BuildWithContext(ctx)
+}
+
+// somHorizonMask is the mask of the SOM horizon modes.
+const somHorizonMask = SomHorizonSmallMode | SomHorizonMediumMode | SomHorizonLargeMode
+
+// somHorizon validates the SOM horizon of mode, and uses the small horizon for the stream mode if it is required.
+func somHorizon(mode ModeFlag, som bool) (ModeFlag, error) {
+	if mode == 0 {
+		return BlockMode, nil
+	}
+
+	horizon := mode & somHorizonMask
+
+	switch {
+	case horizon == 0:
+		if som && mode&ModeMask == StreamMode {
+			mode |= SomHorizonSmallMode
+		}
+	case horizon != SomHorizonSmallMode && horizon != SomHorizonMediumMode && horizon != SomHorizonLargeMode:
+		return mode, fmt.Errorf("multiple SOM horizons, %w", ErrInvalid)
+	case mode&ModeMask != StreamMode:
+		return mode, fmt.Errorf("SOM horizon in %s mode, %w", mode, ErrInvalid)
+	}
+
+	return mode, nil
+}
+
+// PatternSetBuilder builds up a pattern set with chained methods, and validates the patterns as they are added.
+//
+// The first error stops the building, and it will be returned by `Err` or `Build`.
+type PatternSetBuilder struct {
+	patterns Patterns
+	ids      map[int]bool
+	err      error
+}
+
+// NewPatternSetBuilder returns an empty pattern set builder.
+func NewPatternSetBuilder() *PatternSetBuilder {
+	return &PatternSetBuilder{ids: make(map[int]bool)}
+}
+
+// Add a regular expression with flags, which associated with the next unused ID.
+func (b *PatternSetBuilder) Add(expr string, flags ...CompileFlag) *PatternSetBuilder {
+	if b.err != nil {
+		return b
+	}
+
+	p := NewPattern(expr, flags...)
+	p.Id = b.nextID()
+
+	if b.err = p.validate(); b.err == nil {
+		b.ids[p.Id] = true
+		b.patterns = append(b.patterns, p)
+	}
+
+	return b
+}
+
+// AddLiteral add a literal string which matched as is, with flags.
+func (b *PatternSetBuilder) AddLiteral(s string, flags ...CompileFlag) *PatternSetBuilder {
+	return b.Add(EscapeLiteral(s), flags...)
+}
+
+// WithFlags add the flags to the last added pattern.
+func (b *PatternSetBuilder) WithFlags(flags ...CompileFlag) *PatternSetBuilder {
+	p := b.last()
+	if p == nil {
+		return b
+	}
+
+	for _, f := range flags {
+		p.Flags |= f
+	}
+
+	p.info = nil
+	b.err = p.validate()
+
+	return b
+}
+
+// WithID set the ID of the last added pattern.
+func (b *PatternSetBuilder) WithID(id int) *PatternSetBuilder {
+	p := b.last()
+	if p == nil || p.Id == id {
+		return b
+	}
+
+	if b.ids[id] {
+		b.err = fmt.Errorf("pattern id %d, %w", id, ErrConflict)
+
+		return b
+	}
+
+	delete(b.ids, p.Id)
+	b.ids[id] = true
+	p.Id = id
+
+	return b
+}
+
+// Err returns the first error occurred while building the pattern set.
+func (b *PatternSetBuilder) Err() error { return b.err }
+
+// Patterns returns the patterns have been added.
+func (b *PatternSetBuilder) Patterns() Patterns { return b.patterns }
+
+// Build a database in the mode base on the patterns.
+func (b *PatternSetBuilder) Build(mode ModeFlag) (Database, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	return b.patterns.Build(mode)
+}
+
+func (b *PatternSetBuilder) last() *Pattern {
+	if b.err != nil {
+		return nil
+	}
+
+	if len(b.patterns) == 0 {
+		b.err = fmt.Errorf("no pattern to modify, %w", ErrNoFound)
+
+		return nil
+	}
+
+	return b.patterns[len(b.patterns)-1]
+}
+
+func (b *PatternSetBuilder) nextID() int {
+	id := 1
+
+	for b.ids[id] {
+		id++
+	}
+
+	return id
+}
+
+// CrossCompile builds the patterns to a serialized database for the target platform,
+// which could be deployed to the hosts of the target platform and loaded with the `Unmarshal` functions.
+//
+// The database will not be loaded on the current host if it requires CPU features the host doesn't have.
+func CrossCompile(target Platform, mode ModeFlag, patterns ...*Pattern) ([]byte, error) {
+	db, err := Patterns(patterns).ForPlatform(mode, target)
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	return db.Marshal() // nolint: wrapcheck
+}
+
 // NewBlockDatabase create a block database base on the patterns.
 func NewBlockDatabase(patterns ...*Pattern) (BlockDatabase, error) {
 	db, err := Patterns(patterns).Build(BlockMode)
@@ -363,18 +797,30 @@ func NewManagedStreamDatabase(patterns ...*Pattern) (StreamDatabase, error) {
 }
 
 // NewMediumStreamDatabase create a medium-sized stream database base on the patterns.
+//
+// The start of match offsets are tracked within 2^32 bytes for the patterns using `SomLeftMost`.
 func NewMediumStreamDatabase(patterns ...*Pattern) (StreamDatabase, error) {
-	db, err := Patterns(patterns).Build(StreamMode | SomHorizonMediumMode)
-	if err != nil {
-		return nil, err
-	}
-
-	return db.(*streamDatabase), err
+	return NewStreamDatabaseWithSomHorizon(SomHorizonMediumMode, patterns...)
 }
 
 // NewLargeStreamDatabase create a large-sized stream database base on the patterns.
+//
+// The start of match offsets are tracked in full precision for the patterns using `SomLeftMost`.
 func NewLargeStreamDatabase(patterns ...*Pattern) (StreamDatabase, error) {
-	db, err := Patterns(patterns).Build(StreamMode | SomHorizonLargeMode)
+	return NewStreamDatabaseWithSomHorizon(SomHorizonLargeMode, patterns...)
+}
+
+// NewStreamDatabaseWithSomHorizon create a stream database with the SOM horizon base on the patterns.
+//
+// The horizon is the precision to track the start of match offsets in the stream state,
+// a larger horizon reports the start of match of the longer matches, but increases the stream size
+// which could be found with `StreamDatabase.StreamSize`.
+func NewStreamDatabaseWithSomHorizon(horizon ModeFlag, patterns ...*Pattern) (StreamDatabase, error) {
+	if horizon&^somHorizonMask != 0 {
+		return nil, fmt.Errorf("SOM horizon %d, %w", horizon, ErrInvalid)
+	}
+
+	db, err := Patterns(patterns).Build(StreamMode | horizon)
 	if err != nil {
 		return nil, err
 	}
@@ -392,6 +838,123 @@ func NewVectoredDatabase(patterns ...*Pattern) (VectoredDatabase, error) {
 	return db.(*vectoredDatabase), err
 }
 
+// Databases contains the databases of all the modes compiled from the same patterns.
+type Databases struct {
+	Block    BlockDatabase
+	Stream   StreamDatabase
+	Vectored VectoredDatabase
+}
+
+// Close frees all the compiled pattern databases.
+func (dbs *Databases) Close() (err error) {
+	for _, db := range []Database{dbs.Block, dbs.Stream, dbs.Vectored} {
+		if db == nil {
+			continue
+		}
+
+		if e := db.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return
+}
+
+// CompileAllModes compiles the patterns to the block, stream and vectored databases at once.
+//
+// If any of the databases failed to compile, the others will be freed.
+func CompileAllModes(patterns ...*Pattern) (*Databases, error) {
+	dbs := new(Databases)
+
+	var err error
+
+	if dbs.Block, err = NewBlockDatabase(patterns...); err != nil {
+		return nil, fmt.Errorf("create block database, %w", err)
+	}
+
+	if dbs.Stream, err = NewStreamDatabase(patterns...); err != nil {
+		_ = dbs.Close()
+
+		return nil, fmt.Errorf("create stream database, %w", err)
+	}
+
+	if dbs.Vectored, err = NewVectoredDatabase(patterns...); err != nil {
+		_ = dbs.Close()
+
+		return nil, fmt.Errorf("create vectored database, %w", err)
+	}
+
+	return dbs, nil
+}
+
+// GroupError is the error of compiling a group of patterns.
+type GroupError struct {
+	Group int // The index of the failed group.
+	Err   error
+}
+
+func (e *GroupError) Error() string { return fmt.Sprintf("group %d, %v", e.Group, e.Err) }
+
+func (e *GroupError) Unwrap() error { return e.Err }
+
+// GroupErrors are the errors of the failed groups.
+type GroupErrors []*GroupError
+
+func (e GroupErrors) Error() string {
+	msgs := make([]string, len(e))
+
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// CompileGroups compiles the independent groups of patterns concurrently to the databases in the mode.
+//
+// The databases are returned in the order of groups, the failed group will be a nil database,
+// and all the failures are reported by a `GroupErrors`.
+func CompileGroups(mode ModeFlag, groups ...Patterns) ([]Database, error) {
+	dbs := make([]Database, len(groups))
+	errs := make([]error, len(groups))
+	tasks := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for n := 0; n < runtime.GOMAXPROCS(0) && n < len(groups); n++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range tasks {
+				dbs[i], errs[i] = groups[i].Build(mode)
+			}
+		}()
+	}
+
+	for i := range groups {
+		tasks <- i
+	}
+
+	close(tasks)
+	wg.Wait()
+
+	var failed GroupErrors
+
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, &GroupError{i, err})
+		}
+	}
+
+	if failed != nil {
+		return dbs, failed
+	}
+
+	return dbs, nil
+}
+
 // Compile a regular expression and returns, if successful,
 // a pattern database in the block mode that can be used to match against text.
 func Compile(expr string) (Database, error) {
@@ -415,6 +978,8 @@ func MustCompile(expr string) Database {
 }
 
 // Quote returns a quoted string literal representing s.
+//
+// Use `EscapeLiteral` to build an expression matching the literal string.
 func Quote(s string) string {
 	if strconv.CanBackquote(s) {
 		return "`" + s + "`"
@@ -422,3 +987,30 @@ func Quote(s string) string {
 
 	return strconv.Quote(s)
 }
+
+// EscapeLiteral returns an expression that matches the literal string s,
+// the ASCII characters except letters, digits and underscore are escaped with the `\x` sequences.
+//
+// It is analogous to `regexp.QuoteMeta`. If s is valid UTF-8, the non-ASCII characters are kept as is,
+// so the expression matches the same bytes with or without `Utf8Mode`,
+// otherwise all the non-ASCII bytes are escaped, and the expression can't be compiled with `Utf8Mode`.
+func EscapeLiteral(s string) string {
+	var b strings.Builder
+
+	valid := utf8.ValidString(s)
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_':
+			b.WriteByte(c)
+		case c >= utf8.RuneSelf && valid:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, `\x%02x`, c)
+		}
+	}
+
+	return b.String()
+}
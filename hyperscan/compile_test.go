@@ -2,7 +2,11 @@
 package hyperscan_test
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 
@@ -57,6 +61,51 @@ func TestPattern(t *testing.T) {
 			So(p.String(), ShouldEqual, "3:/foobar/8i{min_offset=4,min_length=8}")
 		})
 
+		Convey("When build pattern with approximate matching", func() {
+			p := hyperscan.NewPattern("foobar").WithExt(hyperscan.HammingDistance(2))
+
+			ext, err := p.Ext()
+			So(err, ShouldBeNil)
+			So(ext.Flags, ShouldEqual, hyperscan.ExtHammingDistance)
+			So(ext.HammingDistance, ShouldEqual, 2)
+
+			So(p.String(), ShouldEqual, "/foobar/{hamming_distance=2}")
+
+			db, err := hyperscan.NewBlockDatabase(p)
+			So(err, ShouldBeNil)
+
+			So(db.MatchString("fooxaz"), ShouldBeTrue)
+			So(db.MatchString("fxxxar"), ShouldBeFalse)
+
+			So(db.Close(), ShouldBeNil)
+		})
+
+		Convey("When parse pattern with extensions but without flags", func() {
+			p, err := hyperscan.ParsePattern("/foobar/{min_offset=10, max_offset=100}")
+			So(err, ShouldBeNil)
+			So(p.Expression, ShouldEqual, "foobar")
+			So(p.Flags, ShouldEqual, 0)
+
+			ext, err := p.Ext()
+			So(err, ShouldBeNil)
+			So(ext, ShouldResemble, new(hyperscan.ExprExt).With(hyperscan.MinOffset(10), hyperscan.MaxOffset(100)))
+
+			So(p.String(), ShouldEqual, "/foobar/{min_offset=10,max_offset=100}")
+
+			Convey("Then parse it again", func() {
+				p2, err := hyperscan.ParsePattern(p.String())
+				So(err, ShouldBeNil)
+				So(p2.String(), ShouldEqual, p.String())
+			})
+		})
+
+		Convey("When parse pattern with unknown extensions", func() {
+			p, err := hyperscan.ParsePattern("/foobar/si{min_offset=10,max_depth=100}")
+			So(err, ShouldNotBeNil)
+			So(errors.Is(err, hyperscan.ErrUnexpected), ShouldBeTrue)
+			So(p, ShouldBeNil)
+		})
+
 		Convey("When parse with a lot of flags", func() {
 			p, err := hyperscan.ParsePattern(`/test/ismoeupf`)
 
@@ -109,6 +158,62 @@ func TestPattern(t *testing.T) {
 			So(p.IsValid(), ShouldBeFalse)
 		})
 
+		Convey("When get information of a pattern with extended parameters", func() {
+			p := hyperscan.NewPattern("foobar").WithExt(hyperscan.EditDistance(2))
+
+			info, err := p.Info()
+
+			So(err, ShouldBeNil)
+			So(info.MinWidth, ShouldEqual, 4)
+			So(info.MaxWidth, ShouldEqual, 8)
+
+			ext, err := p.Ext()
+
+			So(err, ShouldBeNil)
+
+			info, err = hyperscan.ExpressionExtInfo("foobar", 0, ext)
+
+			So(err, ShouldBeNil)
+			So(info.MinWidth, ShouldEqual, 4)
+		})
+
+		Convey("When modify the extended parameters of a pattern without them", func() {
+			p := hyperscan.NewPattern("foobar")
+
+			So(p.String(), ShouldEqual, "/foobar/")
+
+			ext, err := p.Ext()
+
+			So(err, ShouldBeNil)
+
+			ext.With(hyperscan.MinOffset(8))
+
+			Convey("Then they are kept by the pattern", func() {
+				So(p.String(), ShouldEqual, "/foobar/{min_offset=8}")
+
+				db, err := hyperscan.NewBlockDatabase(p)
+				So(err, ShouldBeNil)
+
+				So(db.MatchString("foobar"), ShouldBeFalse)
+				So(db.MatchString("..foobar"), ShouldBeTrue)
+
+				So(db.Close(), ShouldBeNil)
+			})
+		})
+
+		Convey("When get information of an unbounded expression", func() {
+			info, err := hyperscan.ExpressionInfo(`foo.*bar$`, hyperscan.DotAll)
+
+			So(err, ShouldBeNil)
+			So(info, ShouldResemble, &hyperscan.ExprInfo{
+				MinWidth:        6,
+				MaxWidth:        hyperscan.UnboundedMaxWidth,
+				ReturnUnordered: true,
+				AtEndOfData:     true,
+				OnlyAtEndOfData: true,
+			})
+		})
+
 		Convey("When quote a string", func() {
 			So(hyperscan.Quote("test"), ShouldEqual, "`test`")
 			So(hyperscan.Quote("`can't backquote this`"), ShouldEqual, "\"`can't backquote this`\"")
@@ -116,6 +221,81 @@ func TestPattern(t *testing.T) {
 	})
 }
 
+func TestPatternFile(t *testing.T) {
+	Convey("Given a pattern file", t, func() {
+		Convey("When parse a valid file", func() {
+			patterns, err := hyperscan.ParsePatternFile(strings.NewReader(`
+# hsbench patterns
+1:/foobar/i
+2:/^hello\s+world$/sm
+
+3:/test/L{min_offset=10}
+`))
+
+			So(err, ShouldBeNil)
+			So(patterns, ShouldHaveLength, 3)
+			So(patterns[0].String(), ShouldEqual, "1:/foobar/i")
+			So(patterns[1].Id, ShouldEqual, 2)
+			So(patterns[2].String(), ShouldEqual, "3:/test/L{min_offset=10}")
+		})
+
+		Convey("When parse a pattern without id", func() {
+			patterns, err := hyperscan.ParsePatternFile(strings.NewReader("1:/foo/\n/bar/i\n"))
+
+			So(patterns, ShouldBeNil)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldStartWith, "line 2, missing pattern id")
+		})
+
+		Convey("When parse patterns with duplicate id", func() {
+			patterns, err := hyperscan.ParsePatternFile(strings.NewReader("1:/foo/\n1:/bar/i\n"))
+
+			So(patterns, ShouldBeNil)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldStartWith, "line 2, duplicate pattern id 1 defined at line 1")
+		})
+	})
+}
+
+func TestNormalizePatterns(t *testing.T) {
+	Convey("Given some patterns without id", t, func() {
+		patterns := hyperscan.Patterns{
+			hyperscan.NewPattern("foo"),
+			&hyperscan.Pattern{Expression: "bar", Id: 3},
+			hyperscan.NewPattern("baz", hyperscan.Caseless),
+		}
+
+		Convey("When normalize the patterns", func() {
+			ids, err := patterns.Normalize()
+
+			So(err, ShouldBeNil)
+			So(ids, ShouldHaveLength, 3)
+			So(patterns[0].Id, ShouldEqual, 4)
+			So(patterns[2].Id, ShouldEqual, 5)
+			So(ids[3], ShouldEqual, patterns[1])
+			So(ids[5], ShouldEqual, patterns[2])
+		})
+
+		Convey("When some patterns have the same id", func() {
+			patterns = append(patterns, &hyperscan.Pattern{Expression: "qux", Id: 3})
+
+			ids, err := patterns.Normalize()
+
+			So(ids, ShouldBeNil)
+			So(errors.Is(err, hyperscan.ErrConflict), ShouldBeTrue)
+		})
+
+		Convey("When the identical expressions have different flags", func() {
+			patterns = append(patterns, hyperscan.NewPattern("foo", hyperscan.DotAll))
+
+			ids, err := patterns.Normalize()
+
+			So(ids, ShouldBeNil)
+			So(errors.Is(err, hyperscan.ErrConflict), ShouldBeTrue)
+		})
+	})
+}
+
 func TestDatabaseBuilder(t *testing.T) {
 	Convey("Given a DatabaseBuilder", t, func() {
 		b := hyperscan.DatabaseBuilder{}
@@ -154,6 +334,43 @@ func TestDatabaseBuilder(t *testing.T) {
 			So(db.Close(), ShouldBeNil)
 		})
 
+		Convey("When build with an unsupported expression", func() {
+			db, err := b.AddExpressions("test", `\R`, "foobar").Build()
+
+			So(db, ShouldBeNil)
+			So(err, ShouldNotBeNil)
+			So(errors.Is(err, hyperscan.ErrCompileError), ShouldBeTrue)
+
+			var compileErr *hyperscan.CompileError
+
+			So(errors.As(err, &compileErr), ShouldBeTrue)
+			So(compileErr.Index, ShouldEqual, 1)
+			So(compileErr.Expression, ShouldEqual, `\R`)
+			So(compileErr.Message, ShouldEqual, `\R at index 0 not supported.`)
+		})
+
+		Convey("When build with extended parameters", func() {
+			b.Patterns = append(b.Patterns, hyperscan.NewPattern("test").WithExt(hyperscan.MaxOffset(10)))
+
+			db, err := b.Build()
+
+			So(err, ShouldBeNil)
+			So(db, ShouldNotBeNil)
+
+			var matches []uint64
+
+			matched := func(id uint, from, to uint64, flags uint, context interface{}) error {
+				matches = append(matches, to)
+
+				return nil
+			}
+
+			So(db.(hyperscan.BlockDatabase).Scan([]byte("abctest1234567890test"), nil, matched, nil), ShouldBeNil)
+			So(matches, ShouldResemble, []uint64{7})
+
+			So(db.Close(), ShouldBeNil)
+		})
+
 		Convey("When build stream database with a simple expression", func() {
 			b.Mode = hyperscan.StreamMode
 
@@ -196,6 +413,328 @@ func TestDatabaseBuilder(t *testing.T) {
 	})
 }
 
+func TestPatternSetBuilder(t *testing.T) {
+	Convey("Given a PatternSetBuilder", t, func() {
+		b := hyperscan.NewPatternSetBuilder()
+
+		Convey("When add some patterns", func() {
+			b.Add(`foo\d+`, hyperscan.SomLeftMost).
+				AddLiteral("a.b*c").WithID(10).
+				Add(`bar`).WithFlags(hyperscan.Caseless, hyperscan.SomLeftMost)
+
+			So(b.Err(), ShouldBeNil)
+
+			patterns := b.Patterns()
+
+			So(patterns, ShouldHaveLength, 3)
+			So(patterns[0].Id, ShouldEqual, 1)
+			So(patterns[1].Id, ShouldEqual, 10)
+			So(patterns[1].Expression, ShouldEqual, `a\x2eb\x2ac`)
+			So(patterns[2].Id, ShouldEqual, 2)
+			So(patterns[2].Flags, ShouldEqual, hyperscan.Caseless|hyperscan.SomLeftMost)
+
+			Convey("Then build a block database", func() {
+				db, err := b.Build(hyperscan.BlockMode)
+
+				So(err, ShouldBeNil)
+
+				bdb, ok := db.(hyperscan.BlockDatabase)
+
+				So(ok, ShouldBeTrue)
+				So(bdb.MatchString("xa.b*cx"), ShouldBeTrue)
+				So(bdb.MatchString("abc"), ShouldBeFalse)
+				So(bdb.FindStringIndex("BAR"), ShouldResemble, []int{0, 3})
+
+				So(db.Close(), ShouldBeNil)
+			})
+		})
+
+		Convey("When add an invalid pattern", func() {
+			db, err := b.Add(`foo`).Add(`\R`).Add(`bar`).Build(hyperscan.BlockMode)
+
+			So(db, ShouldBeNil)
+			So(err, ShouldNotBeNil)
+			So(b.Patterns(), ShouldHaveLength, 1)
+		})
+
+		Convey("When assign a duplicate ID", func() {
+			err := b.Add(`foo`).Add(`bar`).WithID(1).Err()
+
+			So(errors.Is(err, hyperscan.ErrConflict), ShouldBeTrue)
+		})
+
+		Convey("When modify without any pattern", func() {
+			err := b.WithFlags(hyperscan.Caseless).Err()
+
+			So(errors.Is(err, hyperscan.ErrNoFound), ShouldBeTrue)
+		})
+	})
+}
+
+func TestCompileWithContext(t *testing.T) {
+	Convey("Given some patterns", t, func() {
+		patterns := []*hyperscan.Pattern{
+			hyperscan.NewPattern(`foo`),
+			hyperscan.NewPattern(`bar\d+`),
+		}
+
+		Convey("When compile with a background context", func() {
+			db, err := hyperscan.CompileWithContext(context.Background(), hyperscan.BlockMode, patterns...)
+
+			So(err, ShouldBeNil)
+			So(db, ShouldNotBeNil)
+			So(db.Close(), ShouldBeNil)
+		})
+
+		Convey("When compile with a cancelled context", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			db, err := hyperscan.CompileWithContext(ctx, hyperscan.BlockMode, patterns...)
+
+			So(db, ShouldBeNil)
+			So(errors.Is(err, context.Canceled), ShouldBeTrue)
+		})
+
+		Convey("When compile with an expired context", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+			defer cancel()
+
+			time.Sleep(time.Millisecond)
+
+			db, err := hyperscan.CompileWithContext(ctx, hyperscan.StreamMode, patterns...)
+
+			So(db, ShouldBeNil)
+			So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+		})
+	})
+}
+
+func TestCompileGroups(t *testing.T) {
+	Convey("Given some groups of patterns", t, func() {
+		groups := []hyperscan.Patterns{
+			{hyperscan.NewPattern(`foo`)},
+			{hyperscan.NewPattern(`bar\d+`), hyperscan.NewPattern(`baz`)},
+			{hyperscan.NewPattern(`qux`, hyperscan.Caseless)},
+		}
+
+		Convey("When compile them concurrently", func() {
+			dbs, err := hyperscan.CompileGroups(hyperscan.BlockMode, groups...)
+
+			So(err, ShouldBeNil)
+			So(dbs, ShouldHaveLength, 3)
+
+			for _, db := range dbs {
+				So(db, ShouldNotBeNil)
+				So(db.Close(), ShouldBeNil)
+			}
+		})
+
+		Convey("When compile with an invalid group", func() {
+			groups[1] = append(groups[1], hyperscan.NewPattern(`\R`))
+
+			dbs, err := hyperscan.CompileGroups(hyperscan.BlockMode, groups...)
+
+			So(err, ShouldNotBeNil)
+			So(dbs, ShouldHaveLength, 3)
+			So(dbs[1], ShouldBeNil)
+
+			var errs hyperscan.GroupErrors
+
+			So(errors.As(err, &errs), ShouldBeTrue)
+			So(errs, ShouldHaveLength, 1)
+			So(errs[0].Group, ShouldEqual, 1)
+
+			So(dbs[0].Close(), ShouldBeNil)
+			So(dbs[2].Close(), ShouldBeNil)
+		})
+	})
+}
+
+func TestSomHorizon(t *testing.T) {
+	Convey("Given a SOM pattern", t, func() {
+		p := hyperscan.NewPattern(`foo\d+`, hyperscan.SomLeftMost)
+
+		Convey("When create stream databases with the SOM horizons", func() {
+			var sizes []int
+
+			for _, horizon := range []hyperscan.ModeFlag{
+				hyperscan.SomHorizonSmallMode,
+				hyperscan.SomHorizonMediumMode,
+				hyperscan.SomHorizonLargeMode,
+			} {
+				db, err := hyperscan.NewStreamDatabaseWithSomHorizon(horizon, p)
+
+				So(err, ShouldBeNil)
+
+				size, err := db.StreamSize()
+
+				So(err, ShouldBeNil)
+
+				sizes = append(sizes, size)
+
+				So(db.Close(), ShouldBeNil)
+			}
+
+			So(sizes[0], ShouldBeLessThanOrEqualTo, sizes[1])
+			So(sizes[1], ShouldBeLessThanOrEqualTo, sizes[2])
+		})
+
+		Convey("When create with an invalid horizon", func() {
+			_, err := hyperscan.NewStreamDatabaseWithSomHorizon(hyperscan.VectoredMode, p)
+
+			So(errors.Is(err, hyperscan.ErrInvalid), ShouldBeTrue)
+
+			_, err = hyperscan.NewStreamDatabaseWithSomHorizon(
+				hyperscan.SomHorizonSmallMode|hyperscan.SomHorizonLargeMode, p)
+
+			So(errors.Is(err, hyperscan.ErrInvalid), ShouldBeTrue)
+		})
+
+		Convey("When build a block database with SOM horizon", func() {
+			_, err := hyperscan.Patterns{p}.Build(hyperscan.BlockMode | hyperscan.SomHorizonLargeMode)
+
+			So(errors.Is(err, hyperscan.ErrInvalid), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given a pattern without SOM", t, func() {
+		p := hyperscan.NewPattern(`foo\d+`)
+
+		Convey("When create stream database with the SOM horizon", func() {
+			db, err := hyperscan.NewMediumStreamDatabase(p)
+
+			So(err, ShouldBeNil)
+			So(db.Close(), ShouldBeNil)
+		})
+	})
+}
+
+func TestPatternTags(t *testing.T) {
+	Convey("Given some tagged patterns", t, func() {
+		patterns := hyperscan.Patterns{
+			hyperscan.NewPattern(`foo`).WithTags("web", "xss"),
+			hyperscan.NewPattern(`bar`).WithTags("sql"),
+			hyperscan.NewPattern(`baz`),
+		}
+
+		for i, p := range patterns {
+			p.Id = i + 1
+		}
+
+		db, err := hyperscan.NewBlockDatabase(patterns...)
+
+		So(err, ShouldBeNil)
+
+		Convey("When scan with the tagged handler", func() {
+			matched := make(map[uint][]string)
+
+			handler := patterns.TaggedHandler(func(id uint, from, to uint64, flags uint,
+				tags []string, context interface{}) error {
+				matched[id] = tags
+
+				return nil
+			})
+
+			So(db.Scan([]byte("foo bar baz"), nil, handler, nil), ShouldBeNil)
+			So(matched, ShouldResemble, map[uint][]string{1: {"web", "xss"}, 2: {"sql"}, 3: nil})
+		})
+
+		So(db.Close(), ShouldBeNil)
+	})
+}
+
+func TestPatternHandler(t *testing.T) {
+	Convey("Given some patterns with metadata", t, func() {
+		foo := hyperscan.NewPattern(`foo`)
+		foo.Id = 1
+		foo.Metadata = "rule-foo"
+
+		bar := hyperscan.NewPattern(`bar`, hyperscan.Caseless)
+		bar.Id = 2
+		bar.Metadata = "rule-bar"
+
+		db, err := hyperscan.NewBlockDatabase(foo, bar)
+
+		So(err, ShouldBeNil)
+
+		Convey("When scan with the pattern handler of database", func() {
+			var matched []interface{}
+
+			handler, err := hyperscan.PatternHandler(db, func(p *hyperscan.Pattern, from, to uint64, flags uint,
+				context interface{}) error {
+				matched = append(matched, p.Metadata)
+
+				return nil
+			})
+
+			So(err, ShouldBeNil)
+			So(db.Scan([]byte("foo BAR"), nil, handler, nil), ShouldBeNil)
+			So(matched, ShouldResemble, []interface{}{"rule-foo", "rule-bar"})
+		})
+
+		Convey("When get the pattern handler of an unmarshaled database", func() {
+			data, err := db.Marshal()
+			So(err, ShouldBeNil)
+
+			other, err := hyperscan.UnmarshalBlockDatabase(data)
+			So(err, ShouldBeNil)
+
+			_, err = hyperscan.PatternHandler(other, func(p *hyperscan.Pattern, from, to uint64, flags uint,
+				context interface{}) error {
+				return nil
+			})
+
+			So(errors.Is(err, hyperscan.ErrNoFound), ShouldBeTrue)
+			So(other.Close(), ShouldBeNil)
+		})
+
+		So(db.Close(), ShouldBeNil)
+	})
+}
+
+func TestCompileAllModes(t *testing.T) {
+	Convey("Given some patterns", t, func() {
+		patterns := []*hyperscan.Pattern{
+			hyperscan.NewPattern(`\d+`, hyperscan.SomLeftMost),
+			hyperscan.NewPattern(`foo`),
+		}
+
+		Convey("When compile them in all modes", func() {
+			dbs, err := hyperscan.CompileAllModes(patterns...)
+
+			So(err, ShouldBeNil)
+			So(dbs, ShouldNotBeNil)
+
+			for db, mode := range map[hyperscan.Database]hyperscan.ModeFlag{
+				dbs.Block:    hyperscan.BlockMode,
+				dbs.Stream:   hyperscan.StreamMode,
+				dbs.Vectored: hyperscan.VectoredMode,
+			} {
+				info, err := db.Info()
+
+				So(err, ShouldBeNil)
+
+				m, err := info.Mode()
+
+				So(err, ShouldBeNil)
+				So(m, ShouldEqual, mode)
+			}
+
+			So(dbs.Block.FindStringIndex("abc123"), ShouldResemble, []int{3, 6})
+
+			So(dbs.Close(), ShouldBeNil)
+		})
+
+		Convey("When compile with an invalid pattern", func() {
+			dbs, err := hyperscan.CompileAllModes(append(patterns, hyperscan.NewPattern(`\R`))...)
+
+			So(dbs, ShouldBeNil)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
 func TestCompile(t *testing.T) {
 	Convey("Given compile some expressions", t, func() {
 		Convey("When compile a simple expression", func() {
@@ -218,6 +757,41 @@ func TestCompile(t *testing.T) {
 	})
 }
 
+func TestEscapeLiteral(t *testing.T) {
+	Convey("Given some literal strings", t, func() {
+		So(hyperscan.EscapeLiteral("foo_123"), ShouldEqual, "foo_123")
+		So(hyperscan.EscapeLiteral("a.b*c"), ShouldEqual, `a\x2eb\x2ac`)
+		So(hyperscan.EscapeLiteral("\x00\n/ "), ShouldEqual, `\x00\x0a\x2f\x20`)
+		So(hyperscan.EscapeLiteral("é"), ShouldEqual, "é")
+		So(hyperscan.EscapeLiteral("é\xff"), ShouldEqual, `\xc3\xa9\xff`)
+
+		Convey("When compile the escaped literal", func() {
+			s := "(?i)[a-z]+ $1.00\x00"
+			p := hyperscan.NewPattern(hyperscan.EscapeLiteral(s), hyperscan.SomLeftMost|hyperscan.DotAll)
+
+			db, err := hyperscan.NewBlockDatabase(p)
+
+			So(err, ShouldBeNil)
+			So(db.FindString("xx"+s+"xx"), ShouldEqual, s)
+			So(db.MatchString("(?i)[a-z]+ $1x00"), ShouldBeFalse)
+
+			So(db.Close(), ShouldBeNil)
+		})
+
+		Convey("When compile the escaped UTF-8 literal in the UTF-8 mode", func() {
+			p := hyperscan.NewPattern(hyperscan.EscapeLiteral("naïve?"), hyperscan.Utf8Mode)
+
+			db, err := hyperscan.NewBlockDatabase(p)
+
+			So(err, ShouldBeNil)
+			So(db.MatchString("so naïve?"), ShouldBeTrue)
+			So(db.MatchString("so naive?"), ShouldBeFalse)
+
+			So(db.Close(), ShouldBeNil)
+		})
+	})
+}
+
 func TestPlatform(t *testing.T) {
 	Convey("Given a native platform", t, func() {
 		p := hyperscan.PopulatePlatform()
@@ -227,5 +801,46 @@ func TestPlatform(t *testing.T) {
 		So(p.CpuFeatures(), ShouldBeGreaterThanOrEqualTo, 0)
 
 		So(p, ShouldResemble, hyperscan.NewPlatform(p.Tune(), p.CpuFeatures()))
+
+		Convey("When build database for a generic platform", func() {
+			b := hyperscan.DatabaseBuilder{}
+
+			db, err := b.AddExpressions("test").WithPlatform(hyperscan.Generic, 0).Build()
+
+			So(err, ShouldBeNil)
+			So(db, ShouldNotBeNil)
+
+			So(b.Platform.Tune(), ShouldEqual, hyperscan.Generic)
+			So(b.Platform.CpuFeatures(), ShouldEqual, 0)
+
+			So(db.Close(), ShouldBeNil)
+		})
+
+		Convey("When format the platform", func() {
+			So(hyperscan.Haswell.String(), ShouldEqual, "Haswell")
+			So((hyperscan.AVX2 | hyperscan.AVX512).String(), ShouldEqual, "AVX2 AVX512")
+		})
+	})
+}
+
+func TestMaxDatabaseSize(t *testing.T) {
+	Convey("Given a database builder", t, func() {
+		b := hyperscan.DatabaseBuilder{Patterns: hyperscan.Patterns{hyperscan.NewPattern(`foo\d+bar`)}}
+
+		Convey("When the database exceeds the size budget", func() {
+			_, err := b.WithMaxDatabaseSize(16).Build()
+
+			Convey("Then the compilation fails", func() {
+				So(errors.Is(err, hyperscan.ErrDatabaseTooLarge), ShouldBeTrue)
+				So(err.Error(), ShouldContainSubstring, "exceeds 16 bytes")
+			})
+		})
+
+		Convey("When the database fits the size budget", func() {
+			db, err := b.WithMaxDatabaseSize(1 << 20).Build()
+
+			So(err, ShouldBeNil)
+			So(db.Close(), ShouldBeNil)
+		})
 	})
 }
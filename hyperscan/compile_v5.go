@@ -116,17 +116,12 @@ func (lit *Literal) Build(mode ModeFlag) (Database, error) {
 }
 
 func (lit *Literal) ForPlatform(mode ModeFlag, platform Platform) (Database, error) {
-	if mode == 0 {
-		mode = BlockMode
-	} else if mode == StreamMode {
-		som := (lit.Flags & SomLeftMost) == SomLeftMost
-
-		if som && mode&(SomHorizonSmallMode|SomHorizonMediumMode|SomHorizonLargeMode) == 0 {
-			mode |= SomHorizonSmallMode
-		}
+	mode, err := somHorizon(mode, (lit.Flags&SomLeftMost) == SomLeftMost)
+	if err != nil {
+		return nil, err
 	}
 
-	p, _ := platform.(*hsPlatformInfo)
+	p := platformInfo(platform)
 
 	db, err := hsCompileLit(string(lit.Expression), lit.Flags, mode, p)
 	if err != nil {
@@ -150,23 +145,20 @@ func (literals Literals) Build(mode ModeFlag) (Database, error) {
 }
 
 func (literals Literals) ForPlatform(mode ModeFlag, platform Platform) (Database, error) {
-	if mode == 0 {
-		mode = BlockMode
-	} else if mode == StreamMode {
-		som := false
-
-		for _, lit := range literals {
-			if (lit.Flags & SomLeftMost) == SomLeftMost {
-				som = true
-			}
-		}
+	som := false
 
-		if som && mode&(SomHorizonSmallMode|SomHorizonMediumMode|SomHorizonLargeMode) == 0 {
-			mode |= SomHorizonSmallMode
+	for _, lit := range literals {
+		if (lit.Flags & SomLeftMost) == SomLeftMost {
+			som = true
 		}
 	}
 
-	p, _ := platform.(*hsPlatformInfo)
+	mode, err := somHorizon(mode, som)
+	if err != nil {
+		return nil, err
+	}
+
+	p := platformInfo(platform)
 
 	db, err := hsCompileLitMulti(literals, mode, p)
 	if err != nil {
@@ -184,3 +176,33 @@ func (literals Literals) ForPlatform(mode ModeFlag, platform Platform) (Database
 
 	return nil, fmt.Errorf("mode %d, %w", mode, ErrUnexpected)
 }
+
+// NewLiteralBlockDatabase create a block database base on the literals.
+func NewLiteralBlockDatabase(literals ...*Literal) (BlockDatabase, error) {
+	db, err := Literals(literals).Build(BlockMode)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.(*blockDatabase), err
+}
+
+// NewLiteralStreamDatabase create a stream database base on the literals.
+func NewLiteralStreamDatabase(literals ...*Literal) (StreamDatabase, error) {
+	db, err := Literals(literals).Build(StreamMode)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.(*streamDatabase), err
+}
+
+// NewLiteralVectoredDatabase create a vectored database base on the literals.
+func NewLiteralVectoredDatabase(literals ...*Literal) (VectoredDatabase, error) {
+	db, err := Literals(literals).Build(VectoredMode)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.(*vectoredDatabase), err
+}
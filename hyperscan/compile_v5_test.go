@@ -4,6 +4,7 @@
 package hyperscan_test
 
 import (
+	"strings"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -97,6 +98,44 @@ func TestLiteral(t *testing.T) {
 	})
 }
 
+func TestLiteralDatabase(t *testing.T) {
+	Convey("Given some literals with regular grammar and NUL", t, func() {
+		lit := hyperscan.NewLiteral("a(b\x00c", hyperscan.SomLeftMost)
+
+		Convey("When build a block database", func() {
+			db, err := hyperscan.NewLiteralBlockDatabase(lit)
+
+			So(err, ShouldBeNil)
+			So(db, ShouldNotBeNil)
+
+			So(db.MatchString("a(b"), ShouldBeFalse)
+			So(db.FindStringIndex("xxa(b\x00cyy"), ShouldResemble, []int{2, 7})
+
+			So(db.Close(), ShouldBeNil)
+		})
+
+		Convey("When build a stream database", func() {
+			db, err := hyperscan.NewLiteralStreamDatabase(lit)
+
+			So(err, ShouldBeNil)
+			So(db, ShouldNotBeNil)
+
+			So(db.Match(strings.NewReader("xxa(b\x00cyy")), ShouldBeTrue)
+
+			So(db.Close(), ShouldBeNil)
+		})
+
+		Convey("When build a vectored database", func() {
+			db, err := hyperscan.NewLiteralVectoredDatabase(lit)
+
+			So(err, ShouldBeNil)
+			So(db, ShouldNotBeNil)
+
+			So(db.Close(), ShouldBeNil)
+		})
+	})
+}
+
 func TestDatabaseBuilderV5(t *testing.T) {
 	Convey("Given a DatabaseBuilder (v5)", t, func() {
 		b := hyperscan.DatabaseBuilder{}
@@ -117,5 +156,64 @@ func TestDatabaseBuilderV5(t *testing.T) {
 
 			So(db.Close(), ShouldBeNil)
 		})
+
+		Convey("When scan with quiet patterns", func() {
+			db, err := hyperscan.NewBlockDatabase(
+				hyperscan.NewPattern("abc", hyperscan.Quiet),
+				&hyperscan.Pattern{Expression: "def", Id: 1},
+			)
+
+			So(err, ShouldBeNil)
+			So(db, ShouldNotBeNil)
+
+			var ids []uint
+
+			matched := func(id uint, from, to uint64, flags uint, context interface{}) error {
+				ids = append(ids, id)
+
+				return nil
+			}
+
+			So(db.Scan([]byte("abcdef"), nil, matched, nil), ShouldBeNil)
+			So(ids, ShouldResemble, []uint{1})
+
+			So(db.Close(), ShouldBeNil)
+		})
+
+		Convey("When scan with logical combination patterns", func() {
+			var patterns []*hyperscan.Pattern
+
+			for _, s := range []string{"101:/abc/Q", "102:/def/Q", "103:/(101&102)/C"} {
+				p, err := hyperscan.ParsePattern(s)
+
+				So(err, ShouldBeNil)
+				So(p, ShouldNotBeNil)
+
+				patterns = append(patterns, p)
+			}
+
+			So(patterns[2].Flags, ShouldEqual, hyperscan.Combination)
+
+			db, err := hyperscan.NewBlockDatabase(patterns...)
+
+			So(err, ShouldBeNil)
+			So(db, ShouldNotBeNil)
+
+			var ids []uint
+
+			matched := func(id uint, from, to uint64, flags uint, context interface{}) error {
+				ids = append(ids, id)
+
+				return nil
+			}
+
+			So(db.Scan([]byte("abc123"), nil, matched, nil), ShouldBeNil)
+			So(ids, ShouldBeEmpty)
+
+			So(db.Scan([]byte("abc123def"), nil, matched, nil), ShouldBeNil)
+			So(ids, ShouldResemble, []uint{103})
+
+			So(db.Close(), ShouldBeNil)
+		})
 	})
 }
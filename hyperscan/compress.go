@@ -0,0 +1,61 @@
+package hyperscan
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+)
+
+// gzipMagic is the leading bytes of gzip stream, which never starts a serialized database.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// MarshalCompressed serializes the database and compresses it with gzip in the level, e.g. `gzip.BestSpeed`.
+//
+// The compressed database is detected and decompressed by the `Unmarshal` functions.
+func MarshalCompressed(db Database, level int) ([]byte, error) {
+	data, err := db.Marshal()
+	if err != nil {
+		return nil, err // nolint: wrapcheck
+	}
+
+	var b bytes.Buffer
+
+	w, err := gzip.NewWriterLevel(&b, level)
+	if err != nil {
+		return nil, fmt.Errorf("compress database, %w", err)
+	}
+
+	if _, err = w.Write(data); err != nil {
+		return nil, fmt.Errorf("compress database, %w", err)
+	}
+
+	if err = w.Close(); err != nil {
+		return nil, fmt.Errorf("compress database, %w", err)
+	}
+
+	return b.Bytes(), nil
+}
+
+// IsCompressed reports whether the serialized database is compressed.
+func IsCompressed(data []byte) bool { return bytes.HasPrefix(data, gzipMagic) }
+
+func decompress(data []byte) ([]byte, error) {
+	if !IsCompressed(data) {
+		return data, nil
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decompress database, %w", err)
+	}
+
+	defer r.Close()
+
+	data, err = ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("decompress database, %w", err)
+	}
+
+	return data, nil
+}
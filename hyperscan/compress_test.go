@@ -0,0 +1,51 @@
+package hyperscan_test
+
+import (
+	"compress/gzip"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestCompressedDatabase(t *testing.T) {
+	Convey("Given a stream database", t, func() {
+		db, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`foo\d+`), hyperscan.NewPattern(`bar`))
+
+		So(err, ShouldBeNil)
+
+		Convey("When marshal it with compression", func() {
+			raw, err := db.Marshal()
+
+			So(err, ShouldBeNil)
+			So(hyperscan.IsCompressed(raw), ShouldBeFalse)
+
+			data, err := hyperscan.MarshalCompressed(db, gzip.BestCompression)
+
+			So(err, ShouldBeNil)
+			So(hyperscan.IsCompressed(data), ShouldBeTrue)
+			So(len(data), ShouldBeLessThan, len(raw))
+
+			Convey("Then inspect the compressed database", func() {
+				info, err := hyperscan.SerializedDatabaseInfo(data)
+
+				So(err, ShouldBeNil)
+
+				mode, err := info.Mode()
+
+				So(err, ShouldBeNil)
+				So(mode, ShouldEqual, hyperscan.StreamMode)
+			})
+
+			Convey("Then unmarshal it as a stream database", func() {
+				sdb, err := hyperscan.UnmarshalStreamDatabase(data)
+
+				So(err, ShouldBeNil)
+				So(sdb.Close(), ShouldBeNil)
+			})
+		})
+
+		So(db.Close(), ShouldBeNil)
+	})
+}
@@ -0,0 +1,128 @@
+package hyperscan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v2"
+)
+
+// PatternConfig is the configuration of a pattern with its metadata.
+//
+// The field tags allow to decode it from both JSON and YAML.
+type PatternConfig struct {
+	ID          int      `json:"id" yaml:"id"`                                       // The unique ID of pattern.
+	Expression  string   `json:"expression" yaml:"expression"`                       // The expression of pattern.
+	Flags       string   `json:"flags,omitempty" yaml:"flags,omitempty"`             // The compile flags, e.g. `is`.
+	Extensions  string   `json:"extensions,omitempty" yaml:"extensions,omitempty"`   // The extensions, e.g. `{edit_distance=1}`.
+	Severity    string   `json:"severity,omitempty" yaml:"severity,omitempty"`       // The severity of match.
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"` // The description of pattern.
+	Tags        []string `json:"tags,omitempty" yaml:"tags,omitempty"`               // The tags of pattern.
+}
+
+// Pattern returns the validated pattern of the configuration, with the configuration as its metadata.
+func (c *PatternConfig) Pattern() (*Pattern, error) {
+	flags, err := ParseCompileFlag(c.Flags)
+	if err != nil {
+		return nil, fmt.Errorf("pattern %d, invalid flags `%s`, %w", c.ID, c.Flags, err)
+	}
+
+	p := &Pattern{Expression: Expression(c.Expression), Flags: flags, Id: c.ID, Tags: c.Tags, Metadata: c}
+
+	if c.Extensions != "" {
+		if p.ext, err = ParseExprExt(c.Extensions); err != nil {
+			return nil, fmt.Errorf("pattern %d, invalid extensions `%s`, %w", c.ID, c.Extensions, err)
+		}
+	}
+
+	if err = p.validate(); err != nil {
+		return nil, fmt.Errorf("pattern %d, %w", c.ID, err)
+	}
+
+	return p, nil
+}
+
+// Config is the configuration of patterns, which preserves the metadata of patterns.
+type Config struct {
+	Patterns []*PatternConfig `json:"patterns" yaml:"patterns"`
+
+	ids map[int]*PatternConfig
+}
+
+// LoadConfig decodes the JSON configuration of patterns from the reader.
+func LoadConfig(r io.Reader) (*Config, error) {
+	var cfg Config
+
+	if err := json.NewDecoder(r).Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("decode config, %w", err)
+	}
+
+	if err := cfg.index(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// LoadYAMLConfig decodes the YAML configuration of patterns from the reader.
+func LoadYAMLConfig(r io.Reader) (*Config, error) {
+	var cfg Config
+
+	if err := yaml.NewDecoder(r).Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("decode config, %w", err)
+	}
+
+	if err := cfg.index(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func (cfg *Config) index() error {
+	cfg.ids = make(map[int]*PatternConfig, len(cfg.Patterns))
+
+	for _, p := range cfg.Patterns {
+		if _, exists := cfg.ids[p.ID]; exists {
+			return fmt.Errorf("duplicate pattern id %d, %w", p.ID, ErrConflict)
+		}
+
+		cfg.ids[p.ID] = p
+	}
+
+	return nil
+}
+
+// Lookup returns the configuration of pattern with the ID, e.g. the ID of match event.
+func (cfg *Config) Lookup(id uint) (*PatternConfig, bool) {
+	if cfg.ids != nil {
+		p, exists := cfg.ids[int(id)]
+
+		return p, exists
+	}
+
+	for _, p := range cfg.Patterns {
+		if p.ID == int(id) {
+			return p, true
+		}
+	}
+
+	return nil, false
+}
+
+// Build the patterns to a database in the mode.
+func (cfg *Config) Build(mode ModeFlag) (Database, error) {
+	patterns := make(Patterns, len(cfg.Patterns))
+
+	for i, c := range cfg.Patterns {
+		p, err := c.Pattern()
+		if err != nil {
+			return nil, err
+		}
+
+		patterns[i] = p
+	}
+
+	return patterns.Build(mode)
+}
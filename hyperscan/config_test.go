@@ -0,0 +1,118 @@
+package hyperscan_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestConfig(t *testing.T) {
+	Convey("Given a JSON config of patterns", t, func() {
+		cfg, err := hyperscan.LoadConfig(strings.NewReader(`{
+			"patterns": [
+				{"id": 1, "expression": "foo\\d+", "flags": "s", "severity": "high", "tags": ["test"]},
+				{"id": 2, "expression": "bar", "flags": "i", "description": "bar in any case"},
+				{"id": 3, "expression": "baz", "extensions": "{min_offset=4}"}
+			]
+		}`))
+
+		So(err, ShouldBeNil)
+		So(cfg.Patterns, ShouldHaveLength, 3)
+
+		Convey("When lookup the metadata of pattern", func() {
+			p, ok := cfg.Lookup(1)
+
+			So(ok, ShouldBeTrue)
+			So(p.Severity, ShouldEqual, "high")
+			So(p.Tags, ShouldResemble, []string{"test"})
+
+			_, ok = cfg.Lookup(4)
+
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("When build the patterns", func() {
+			db, err := cfg.Build(hyperscan.BlockMode)
+
+			So(err, ShouldBeNil)
+
+			bdb, ok := db.(hyperscan.BlockDatabase)
+
+			So(ok, ShouldBeTrue)
+			So(bdb.MatchString("BAR"), ShouldBeTrue)
+			So(bdb.MatchString("baz"), ShouldBeFalse)
+			So(bdb.MatchString("....baz"), ShouldBeTrue)
+
+			So(db.Close(), ShouldBeNil)
+		})
+	})
+
+	Convey("Given a YAML config of patterns", t, func() {
+		cfg, err := hyperscan.LoadYAMLConfig(strings.NewReader(`
+patterns:
+  - id: 1
+    expression: foo\d+
+    flags: s
+    severity: high
+    tags: [test]
+  - id: 2
+    expression: bar
+    description: bar in any case
+    flags: i
+`))
+
+		So(err, ShouldBeNil)
+		So(cfg.Patterns, ShouldHaveLength, 2)
+
+		Convey("When match the patterns built from it", func() {
+			p, err := cfg.Patterns[0].Pattern()
+			So(err, ShouldBeNil)
+			So(string(p.Expression), ShouldEqual, `foo\d+`)
+
+			db, err := cfg.Build(hyperscan.BlockMode)
+			So(err, ShouldBeNil)
+
+			var severities []string
+
+			err = db.(hyperscan.BlockDatabase).Scan([]byte("foo1 BAR"), nil,
+				func(id uint, from, to uint64, flags uint, context interface{}) error {
+					c, ok := cfg.Lookup(id)
+
+					So(ok, ShouldBeTrue)
+
+					severities = append(severities, c.Severity)
+
+					return nil
+				}, nil)
+
+			Convey("Then the metadata is looked up by the ID of match", func() {
+				So(err, ShouldBeNil)
+				So(severities, ShouldResemble, []string{"high", ""})
+				So(p.Metadata, ShouldEqual, cfg.Patterns[0])
+			})
+
+			So(db.Close(), ShouldBeNil)
+		})
+	})
+
+	Convey("Given a config with duplicate IDs", t, func() {
+		_, err := hyperscan.LoadConfig(strings.NewReader(`{"patterns": [
+			{"id": 1, "expression": "foo"},
+			{"id": 1, "expression": "bar"}
+		]}`))
+
+		So(errors.Is(err, hyperscan.ErrConflict), ShouldBeTrue)
+	})
+
+	Convey("Given a config with invalid flags", t, func() {
+		cfg := hyperscan.Config{Patterns: []*hyperscan.PatternConfig{{ID: 1, Expression: "foo", Flags: "z"}}}
+
+		_, err := cfg.Build(hyperscan.BlockMode)
+
+		So(errors.Is(err, hyperscan.ErrUnexpected), ShouldBeTrue)
+	})
+}
@@ -0,0 +1,135 @@
+package hyperscan
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// scanContext scans with the handler which terminates the scanning when the context is done,
+// and returns the error of context if the scanning was terminated by it.
+func scanContext(ctx context.Context, handler MatchHandler, scan func(MatchHandler) error) error {
+	if err := ctx.Err(); err != nil {
+		return err // nolint: wrapcheck
+	}
+
+	err := scan(func(id uint, from, to uint64, flags uint, context interface{}) error {
+		if err := ctx.Err(); err != nil {
+			return err // nolint: wrapcheck
+		}
+
+		return handler(id, from, to, flags, context)
+	})
+
+	if e := ctx.Err(); err != nil && e != nil {
+		return fmt.Errorf("scan terminated, %w", e)
+	}
+
+	return err
+}
+
+// ScanContext scans the data with the block scanner, and terminates the scanning when the context is done.
+//
+// Hyperscan can't interrupt a block scan, so the context is only checked before the scanning and on each match,
+// a scan without matches always runs to the end of data. To bound the latency of scanning a large data,
+// use `ScanStreamContext` or `ScanWithBudget` with a stream database, which scan the data in chunks.
+func ScanContext(ctx context.Context, scanner BlockScanner, data []byte, scratch *Scratch,
+	handler MatchHandler, context interface{}) error {
+	return scanContext(ctx, handler, func(h MatchHandler) error {
+		return scanner.Scan(data, scratch, h, context)
+	})
+}
+
+// ScanVectorContext is like `ScanContext` but scans the vectored data with the vectored scanner.
+func ScanVectorContext(ctx context.Context, scanner VectoredScanner, data [][]byte, scratch *Scratch,
+	handler MatchHandler, context interface{}) error {
+	return scanContext(ctx, handler, func(h MatchHandler) error {
+		return scanner.Scan(data, scratch, h, context)
+	})
+}
+
+// ScanStreamContext scans the data with the stream in chunks, and checks the context between them,
+// the context is also checked on each match for the streams opened by this package.
+//
+// The stream can't be used once the scanning was terminated.
+func ScanStreamContext(ctx context.Context, s Stream, data []byte) error {
+	if ss, ok := s.(*stream); ok {
+		return ss.scanContext(ctx, data)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err // nolint: wrapcheck
+	}
+
+	return scanChunks(ctx, data, s.Scan)
+}
+
+func (s *stream) scanContext(ctx context.Context, data []byte) error {
+	handler := s.handler
+	defer func() { s.handler = handler }()
+
+	return scanContext(ctx, MatchHandler(handler), func(h MatchHandler) error {
+		s.handler = hsMatchEventHandler(h)
+
+		return scanChunks(ctx, data, s.Scan)
+	})
+}
+
+// scanChunks scans the data in chunks, and checks the context before each of them.
+func scanChunks(ctx context.Context, data []byte, scan func([]byte) error) error {
+	for len(data) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err // nolint: wrapcheck
+		}
+
+		n := len(data)
+		if n > DefaultChunkSize {
+			n = DefaultChunkSize
+		}
+
+		if err := scan(data[:n]); err != nil {
+			return err
+		}
+
+		data = data[n:]
+	}
+
+	return nil
+}
+
+// ScanReaderContext scans the data read from the reader with a stream opened by the scanner,
+// and terminates the scanning when the context is done.
+func ScanReaderContext(ctx context.Context, scanner StreamScanner, reader io.Reader, scratch *Scratch,
+	handler MatchHandler, context interface{}) error {
+	return scanContext(ctx, handler, func(h MatchHandler) error {
+		stream, err := scanner.Open(0, scratch, h, context)
+		if err != nil {
+			return err // nolint: wrapcheck
+		}
+		defer stream.Close()
+
+		buf := make([]byte, bufSize)
+
+		for {
+			if err := ctx.Err(); err != nil {
+				return err // nolint: wrapcheck
+			}
+
+			n, err := reader.Read(buf)
+
+			if n > 0 {
+				if err := stream.Scan(buf[:n]); err != nil {
+					return err // nolint: wrapcheck
+				}
+			}
+
+			if err == io.EOF {
+				return nil
+			}
+
+			if err != nil {
+				return fmt.Errorf("read stream, %w", err)
+			}
+		}
+	})
+}
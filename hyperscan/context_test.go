@@ -0,0 +1,116 @@
+package hyperscan_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestScanContext(t *testing.T) {
+	Convey("Given a block database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`))
+		So(err, ShouldBeNil)
+
+		data := []byte(strings.Repeat("foo ", 100))
+
+		Convey("When scan with a canceled context", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			matches := 0
+			err := hyperscan.ScanContext(ctx, bdb, data, nil,
+				func(id uint, from, to uint64, flags uint, context interface{}) error {
+					matches++
+
+					return nil
+				}, nil)
+
+			So(errors.Is(err, context.Canceled), ShouldBeTrue)
+			So(matches, ShouldEqual, 0)
+		})
+
+		Convey("When the context is canceled during scanning", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			matches := 0
+			err := hyperscan.ScanContext(ctx, bdb, data, nil,
+				func(id uint, from, to uint64, flags uint, context interface{}) error {
+					matches++
+					cancel()
+
+					return nil
+				}, nil)
+
+			So(errors.Is(err, context.Canceled), ShouldBeTrue)
+			So(matches, ShouldEqual, 1)
+		})
+
+		Convey("When scan with a live context", func() {
+			matches := 0
+			err := hyperscan.ScanContext(context.Background(), bdb, data, nil,
+				func(id uint, from, to uint64, flags uint, context interface{}) error {
+					matches++
+
+					return nil
+				}, nil)
+
+			So(err, ShouldBeNil)
+			So(matches, ShouldEqual, 100)
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+
+	Convey("Given a stream database", t, func() {
+		sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`foo`))
+		So(err, ShouldBeNil)
+
+		data := []byte(strings.Repeat("foo ", 64*1024))
+
+		Convey("When scan a stream with context", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			matches := 0
+			s, err := sdb.Open(0, nil, func(id uint, from, to uint64, flags uint, context interface{}) error {
+				matches++
+
+				if matches == 10 {
+					cancel()
+				}
+
+				return nil
+			}, nil)
+			So(err, ShouldBeNil)
+
+			err = hyperscan.ScanStreamContext(ctx, s, data)
+
+			So(errors.Is(err, context.Canceled), ShouldBeTrue)
+			So(matches, ShouldEqual, 10)
+
+			_ = s.Close()
+		})
+
+		Convey("When scan a reader with context", func() {
+			matches := 0
+			err := hyperscan.ScanReaderContext(context.Background(), sdb, bytes.NewReader(data), nil,
+				func(id uint, from, to uint64, flags uint, context interface{}) error {
+					matches++
+
+					return nil
+				}, nil)
+
+			So(err, ShouldBeNil)
+			So(matches, ShouldEqual, 64*1024)
+		})
+
+		So(sdb.Close(), ShouldBeNil)
+	})
+}
@@ -0,0 +1,72 @@
+package hyperscan
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+)
+
+// MatchCount is the number of matches in the scanned data.
+type MatchCount struct {
+	// Total is the number of all matches.
+	Total uint64
+	// Patterns is the number of matches of each pattern, only known when the patterns of database are known.
+	Patterns map[uint]uint64
+}
+
+// CountMatches counts the matches in the data, in total and per pattern, without calling the handler for each match,
+// the matches are counted in C and crossing the cgo boundary once.
+func CountMatches(db BlockDatabase, data []byte, s *Scratch) (count MatchCount, err error) {
+	d, ok := db.(database)
+	if !ok {
+		return count, fmt.Errorf("database %v, %w", db, ErrUnexpected)
+	}
+
+	var inventory Patterns
+
+	if b, ok := db.(baseDatabaser); ok {
+		inventory = b.base().inventory
+	}
+
+	if s == nil {
+		s, err = NewScratch(db)
+		if err != nil {
+			return
+		}
+
+		defer func() {
+			_ = s.Free()
+		}()
+	}
+
+	ids := make([]uint32, 0, len(inventory))
+
+	for _, p := range inventory {
+		ids = append(ids, uint32(p.Id))
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	counts := make([]uint64, len(ids))
+
+	count.Total, err = hsScanCount(d.Db(), data, 0, s.s, ids, counts)
+
+	runtime.KeepAlive(db)
+	runtime.KeepAlive(s)
+
+	if err != nil {
+		return
+	}
+
+	if len(ids) > 0 {
+		count.Patterns = make(map[uint]uint64, len(ids))
+
+		for i, id := range ids {
+			if counts[i] > 0 {
+				count.Patterns[uint(id)] = counts[i]
+			}
+		}
+	}
+
+	return count, nil
+}
@@ -0,0 +1,36 @@
+package hyperscan_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestCount(t *testing.T) {
+	Convey("Given a block database", t, func() {
+		foo, bar, baz := hyperscan.NewPattern(`foo`, 0), hyperscan.NewPattern(`bar`, 0), hyperscan.NewPattern(`baz`, 0)
+		foo.Id, bar.Id, baz.Id = 1, 2, 3
+
+		bdb, err := hyperscan.NewBlockDatabase(foo, bar, baz)
+		So(err, ShouldBeNil)
+
+		Convey("When count the matches", func() {
+			count, err := hyperscan.CountMatches(bdb, []byte("foo bar foo foo"), nil)
+
+			So(err, ShouldBeNil)
+			So(count.Total, ShouldEqual, 4)
+			So(count.Patterns, ShouldResemble, map[uint]uint64{1: 3, 2: 1})
+		})
+
+		Convey("When count the matches of empty data", func() {
+			count, err := hyperscan.CountMatches(bdb, []byte{}, nil)
+
+			So(err, ShouldBeNil)
+			So(count.Total, ShouldEqual, 0)
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
@@ -0,0 +1,89 @@
+package hyperscan
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// DatabaseDescription describes a database for the inventory tools.
+type DatabaseDescription struct {
+	Version    string               `json:"version"`
+	Mode       string               `json:"mode"`
+	Features   []string             `json:"features"`
+	Size       int                  `json:"size"`
+	StreamSize int                  `json:"stream_size,omitempty"`
+	Patterns   []PatternDescription `json:"patterns,omitempty"`
+}
+
+// PatternDescription describes a pattern compiled into the database.
+type PatternDescription struct {
+	ID         int      `json:"id"`
+	Expression string   `json:"expression"`
+	Flags      string   `json:"flags,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+// DescribeDatabase describes the database,
+// the patterns are only available for the database compiled in the current process.
+func DescribeDatabase(db Database) (*DatabaseDescription, error) {
+	info, err := db.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	ver, err := info.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	mode, err := info.Mode()
+	if err != nil {
+		return nil, err
+	}
+
+	features, err := info.Features()
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := db.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	desc := &DatabaseDescription{
+		Version:  ver,
+		Mode:     mode.String(),
+		Features: strings.Fields(features.String()),
+		Size:     size,
+	}
+
+	if d, ok := db.(database); ok && mode == StreamMode {
+		if desc.StreamSize, err = hsStreamSize(d.Db()); err != nil {
+			return nil, err
+		}
+	}
+
+	if d, ok := db.(baseDatabaser); ok {
+		for _, p := range d.base().inventory {
+			desc.Patterns = append(desc.Patterns, PatternDescription{
+				ID:         p.Id,
+				Expression: string(p.Expression),
+				Flags:      p.Flags.String(),
+				Tags:       p.Tags,
+			})
+		}
+	}
+
+	return desc, nil
+}
+
+// DescribeDatabaseJSON describes the database in JSON.
+func DescribeDatabaseJSON(db Database) ([]byte, error) {
+	desc, err := DescribeDatabase(db)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(desc) // nolint: wrapcheck
+}
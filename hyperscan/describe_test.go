@@ -0,0 +1,63 @@
+package hyperscan_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestDescribeDatabaseJSON(t *testing.T) {
+	Convey("Given a stream database", t, func() {
+		sdb, err := hyperscan.NewStreamDatabase(
+			hyperscan.NewPattern(`foo`, hyperscan.Caseless).WithTags("web"),
+			hyperscan.NewPattern(`bar\d+`))
+
+		So(err, ShouldBeNil)
+
+		Convey("When describe it in JSON", func() {
+			data, err := hyperscan.DescribeDatabaseJSON(sdb)
+
+			So(err, ShouldBeNil)
+
+			var desc hyperscan.DatabaseDescription
+
+			So(json.Unmarshal(data, &desc), ShouldBeNil)
+
+			Convey("Then the database info is described", func() {
+				So(hyperscan.Version(), ShouldStartWith, desc.Version)
+				So(desc.Mode, ShouldEqual, "STREAM")
+				So(desc.Size, ShouldBeGreaterThan, 0)
+				So(desc.StreamSize, ShouldBeGreaterThan, 0)
+			})
+
+			Convey("Then the patterns are described", func() {
+				So(desc.Patterns, ShouldHaveLength, 2)
+				So(desc.Patterns[0].Expression, ShouldEqual, "foo")
+				So(desc.Patterns[0].Flags, ShouldEqual, "i")
+				So(desc.Patterns[0].Tags, ShouldResemble, []string{"web"})
+			})
+		})
+
+		Convey("When describe the unmarshaled database", func() {
+			data, err := sdb.Marshal()
+			So(err, ShouldBeNil)
+
+			db, err := hyperscan.UnmarshalStreamDatabase(data)
+			So(err, ShouldBeNil)
+
+			info, err := hyperscan.DescribeDatabaseJSON(db)
+			So(err, ShouldBeNil)
+
+			var desc hyperscan.DatabaseDescription
+
+			So(json.Unmarshal(info, &desc), ShouldBeNil)
+			So(desc.Patterns, ShouldBeEmpty)
+			So(db.Close(), ShouldBeNil)
+		})
+
+		So(sdb.Close(), ShouldBeNil)
+	})
+}
@@ -0,0 +1,142 @@
+//go:build go1.16
+// +build go1.16
+
+package hyperscan
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"sync"
+)
+
+// LazyDatabase is a serialized database in a file system, such as the `embed.FS`,
+// which is loaded and checked for the current platform on first use.
+//
+// The file could be saved by `SaveDatabaseToFile`, or contain the plain or compressed serialized database.
+type LazyDatabase struct {
+	fsys fs.FS
+	name string
+
+	once sync.Once
+	mu   sync.Mutex
+	db   Database
+	err  error
+}
+
+// NewLazyDatabase returns a lazy database loaded from the file in the file system.
+func NewLazyDatabase(fsys fs.FS, name string) *LazyDatabase {
+	return &LazyDatabase{fsys: fsys, name: name}
+}
+
+// LoadFS loads a database from the file in the file system immediately.
+func LoadFS(fsys fs.FS, name string) (Database, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("read database file, %w", err)
+	}
+
+	if bytes.HasPrefix(data, []byte(dbFileMagic)) {
+		return loadDatabaseFile(data, name)
+	}
+
+	db, err := deserializeDatabase(data)
+	if err != nil {
+		return nil, fmt.Errorf("database file %s, %w", name, err)
+	}
+
+	mode, err := databaseMode(db)
+	if err != nil {
+		_ = hsFreeDatabase(db)
+
+		return nil, err
+	}
+
+	d, err := newDatabase(db, mode)
+	if err != nil {
+		_ = hsFreeDatabase(db)
+
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Database loads the database on first call, and returns the same database or error later.
+func (l *LazyDatabase) Database() (Database, error) {
+	l.once.Do(func() {
+		db, err := LoadFS(l.fsys, l.name)
+
+		l.mu.Lock()
+		l.db, l.err = db, err
+		l.mu.Unlock()
+	})
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.db == nil && l.err == nil {
+		return nil, fmt.Errorf("database file %s closed, %w", l.name, ErrInvalid)
+	}
+
+	return l.db, l.err
+}
+
+// Block returns the loaded database as a block database.
+func (l *LazyDatabase) Block() (BlockDatabase, error) {
+	db, err := l.Database()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkMode(db.(database).Db(), BlockMode); err != nil {
+		return nil, err
+	}
+
+	return db.(BlockDatabase), nil
+}
+
+// Stream returns the loaded database as a stream database.
+func (l *LazyDatabase) Stream() (StreamDatabase, error) {
+	db, err := l.Database()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkMode(db.(database).Db(), StreamMode); err != nil {
+		return nil, err
+	}
+
+	return db.(StreamDatabase), nil
+}
+
+// Vectored returns the loaded database as a vectored database.
+func (l *LazyDatabase) Vectored() (VectoredDatabase, error) {
+	db, err := l.Database()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkMode(db.(database).Db(), VectoredMode); err != nil {
+		return nil, err
+	}
+
+	return db.(VectoredDatabase), nil
+}
+
+// Close frees the database if it was loaded, the database can't be used after closed.
+func (l *LazyDatabase) Close() error {
+	l.once.Do(func() {})
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	db := l.db
+	l.db = nil
+
+	if db == nil {
+		return nil
+	}
+
+	return db.Close() // nolint: wrapcheck
+}
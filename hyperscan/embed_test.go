@@ -0,0 +1,57 @@
+//go:build go1.16
+// +build go1.16
+
+package hyperscan_test
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestLazyDatabase(t *testing.T) {
+	Convey("Given a file system with a serialized block database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo\d+`))
+		So(err, ShouldBeNil)
+
+		data, err := bdb.Marshal()
+		So(err, ShouldBeNil)
+		So(bdb.Close(), ShouldBeNil)
+
+		fsys := fstest.MapFS{"rules/foo.db": &fstest.MapFile{Data: data}}
+
+		Convey("When load it lazily", func() {
+			lazy := hyperscan.NewLazyDatabase(fsys, "rules/foo.db")
+
+			db, err := lazy.Block()
+
+			So(err, ShouldBeNil)
+			So(db.MatchString("foo123"), ShouldBeTrue)
+
+			Convey("Then the same database is returned", func() {
+				other, err := lazy.Database()
+
+				So(err, ShouldBeNil)
+				So(other, ShouldEqual, db)
+			})
+
+			Convey("Then it can't be used as a stream database", func() {
+				_, err := lazy.Stream()
+
+				So(errors.Is(err, hyperscan.ErrDatabaseModeError), ShouldBeTrue)
+			})
+
+			So(lazy.Close(), ShouldBeNil)
+		})
+
+		Convey("When load a missing file", func() {
+			_, err := hyperscan.NewLazyDatabase(fsys, "rules/missing.db").Database()
+
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -24,6 +24,24 @@ func ExamplePattern() {
 	// true
 }
 
+// This example demonstrates approximate matching with extended parameters.
+func ExamplePattern_WithExt() {
+	p := hyperscan.NewPattern(`foobar`).WithExt(hyperscan.EditDistance(1))
+	fmt.Println(p)
+
+	db, err := hyperscan.NewBlockDatabase(p)
+	fmt.Println(err)
+
+	fmt.Println(db.MatchString("fooxar"))
+	fmt.Println(db.MatchString("fxxbar"))
+
+	// Output:
+	// /foobar/{edit_distance=1}
+	// <nil>
+	// true
+	// false
+}
+
 // This example demonstrates parsing pattern with id and flags.
 func ExampleParsePattern() {
 	p, err := hyperscan.ParsePattern("3:/foobar/i8")
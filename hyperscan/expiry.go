@@ -0,0 +1,191 @@
+package hyperscan
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RefreshFunc returns a new database with its time to live to replace the expired database with the name.
+type RefreshFunc func(name string) (Database, time.Duration, error)
+
+// ExpiryManager tracks the named databases with the expiration time,
+// the expired databases are evicted and optionally refreshed.
+//
+// The databases are shared with the in-flight scans, an evicted database is freed after all of them released it.
+type ExpiryManager struct {
+	// Now returns the current time to check the expiration, `time.Now` is used if nil.
+	// It should be set before the databases are set.
+	Now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*expiryEntry
+	refresh RefreshFunc
+}
+
+type expiryEntry struct {
+	db      *SharedDatabase
+	expires time.Time
+}
+
+// NewExpiryManager returns an empty manager, the refresh function is optional.
+func NewExpiryManager(refresh RefreshFunc) *ExpiryManager {
+	return &ExpiryManager{entries: make(map[string]*expiryEntry), refresh: refresh}
+}
+
+func (m *ExpiryManager) now() time.Time {
+	if m.Now != nil {
+		return m.Now()
+	}
+
+	return time.Now()
+}
+
+// Set the database with the name which expires after the time to live, it replaces the previous one.
+func (m *ExpiryManager) Set(name string, db Database, ttl time.Duration) {
+	entry := &expiryEntry{NewSharedDatabase(db), m.now().Add(ttl)}
+
+	m.mu.Lock()
+	prev := m.entries[name]
+	m.entries[name] = entry
+	m.mu.Unlock()
+
+	if prev != nil {
+		_ = prev.db.Close()
+	}
+}
+
+// Acquire retains the unexpired database with the name, it should be released with `Close` after use.
+func (m *ExpiryManager) Acquire(name string) (*SharedDatabase, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, exists := m.entries[name]
+	if !exists || !m.now().Before(entry.expires) {
+		return nil, false
+	}
+
+	db := entry.db.Retain()
+
+	return db, db != nil
+}
+
+// Expires returns the expiration time of the database with the name.
+func (m *ExpiryManager) Expires(name string) (time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, exists := m.entries[name]
+	if !exists {
+		return time.Time{}, false
+	}
+
+	return entry.expires, true
+}
+
+// Names returns the sorted names of the tracked databases.
+func (m *ExpiryManager) Names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.entries))
+
+	for name := range m.entries {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Sweep evicts the expired databases, and replaces them with the refreshed ones if the refresh function is set.
+//
+// The database failed to refresh is kept evicted, and the first error is returned after all refreshed.
+func (m *ExpiryManager) Sweep() error {
+	now := m.now()
+
+	var expired []string
+
+	m.mu.Lock()
+	for name, entry := range m.entries {
+		if !now.Before(entry.expires) {
+			delete(m.entries, name)
+			expired = append(expired, name)
+
+			_ = entry.db.Close()
+		}
+	}
+	m.mu.Unlock()
+
+	if m.refresh == nil {
+		return nil
+	}
+
+	sort.Strings(expired)
+
+	var err error
+
+	for _, name := range expired {
+		db, ttl, e := m.refresh(name)
+		if e != nil {
+			if err == nil {
+				err = fmt.Errorf("refresh database `%s`, %w", name, e)
+			}
+
+			continue
+		}
+
+		m.mu.Lock()
+		_, exists := m.entries[name]
+		if !exists {
+			m.entries[name] = &expiryEntry{NewSharedDatabase(db), m.now().Add(ttl)}
+		}
+		m.mu.Unlock()
+
+		// The database was set during refreshing.
+		if exists {
+			_ = db.Close()
+		}
+	}
+
+	return err
+}
+
+// Run sweeps the expired databases with the interval until the context is done,
+// the errors of refreshing are reported to the callback if it is set.
+func (m *ExpiryManager) Run(ctx context.Context, interval time.Duration, onError func(error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err() // nolint: wrapcheck
+		case <-ticker.C:
+			if err := m.Sweep(); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Close releases all the databases, they are freed after the in-flight scans released them.
+func (m *ExpiryManager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var err error
+
+	for name, entry := range m.entries {
+		if e := entry.db.Close(); e != nil && err == nil {
+			err = e
+		}
+
+		delete(m.entries, name)
+	}
+
+	return err
+}
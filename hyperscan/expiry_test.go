@@ -0,0 +1,87 @@
+package hyperscan_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestExpiryManager(t *testing.T) {
+	Convey("Given an expiry manager with a refresh function", t, func() {
+		var refreshed []string
+
+		now := time.Unix(0, 0)
+
+		m := hyperscan.NewExpiryManager(func(name string) (hyperscan.Database, time.Duration, error) {
+			refreshed = append(refreshed, name)
+
+			if name == "broken" {
+				return nil, 0, hyperscan.ErrInvalid
+			}
+
+			db, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`bar`))
+
+			return db, time.Hour, err
+		})
+
+		m.Now = func() time.Time { return now }
+
+		db, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`))
+		So(err, ShouldBeNil)
+
+		m.Set("feed", db, time.Millisecond)
+
+		Convey("When acquire a database in use after expired", func() {
+			shared, ok := m.Acquire("feed")
+
+			So(ok, ShouldBeTrue)
+
+			now = now.Add(time.Millisecond)
+
+			_, ok = m.Acquire("feed")
+
+			So(ok, ShouldBeFalse)
+
+			So(m.Sweep(), ShouldBeNil)
+
+			Convey("Then the in-flight database is still usable", func() {
+				So(shared.Refs(), ShouldEqual, 1)
+				So(shared.Database.(hyperscan.BlockDatabase).MatchString("foo"), ShouldBeTrue)
+				So(shared.Close(), ShouldBeNil)
+			})
+
+			Convey("Then the database is refreshed", func() {
+				So(refreshed, ShouldResemble, []string{"feed"})
+
+				fresh, ok := m.Acquire("feed")
+
+				So(ok, ShouldBeTrue)
+				So(fresh.Database.(hyperscan.BlockDatabase).MatchString("bar"), ShouldBeTrue)
+				So(fresh.Close(), ShouldBeNil)
+				So(shared.Close(), ShouldBeNil)
+			})
+		})
+
+		Convey("When the refreshing failed", func() {
+			broken, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`baz`))
+			So(err, ShouldBeNil)
+
+			m.Set("broken", broken, time.Millisecond)
+
+			now = now.Add(time.Millisecond)
+
+			err = m.Sweep()
+
+			Convey("Then the database is evicted", func() {
+				So(errors.Is(err, hyperscan.ErrInvalid), ShouldBeTrue)
+				So(m.Names(), ShouldResemble, []string{"feed"})
+			})
+		})
+
+		So(m.Close(), ShouldBeNil)
+	})
+}
@@ -0,0 +1,174 @@
+package hyperscan
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// ErrCorrupted means the database file is corrupted or truncated.
+var ErrCorrupted = errors.New("corrupted")
+
+const (
+	dbFileMagic   = "GOHS"
+	dbFileFormat  = 1
+	dbFileHdrSize = 4 + 2 + 4 + 4 + 8 + 4 + 2
+)
+
+// DatabaseFileHeader is the header of the database file saved by `SaveDatabaseToFile`.
+type DatabaseFileHeader struct {
+	Format   int      // The version of file format written by gohs, the unknown one is rejected.
+	Version  string   // The version of Hyperscan which compiled the database.
+	Mode     ModeFlag // The mode of database.
+	Patterns int      // The number of patterns, or zero if unknown.
+	Size     int      // The size of serialized database.
+	Checksum uint32   // The CRC-32 checksum of serialized database.
+}
+
+func (h *DatabaseFileHeader) marshal() []byte {
+	var b bytes.Buffer
+
+	b.WriteString(dbFileMagic)
+
+	_ = binary.Write(&b, binary.LittleEndian, struct {
+		Format   uint16
+		Mode     uint32
+		Patterns uint32
+		Size     uint64
+		Checksum uint32
+		Version  uint16
+	}{uint16(h.Format), uint32(h.Mode), uint32(h.Patterns), uint64(h.Size), h.Checksum, uint16(len(h.Version))})
+
+	b.WriteString(h.Version)
+
+	return b.Bytes()
+}
+
+// ReadDatabaseFileHeader reads the header of database file, it returns `ErrUnexpected` for an unknown file format.
+func ReadDatabaseFileHeader(r io.Reader) (*DatabaseFileHeader, error) {
+	buf := make([]byte, dbFileHdrSize)
+
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, fmt.Errorf("read header, %v, %w", err, ErrCorrupted)
+	}
+
+	if string(buf[:4]) != dbFileMagic {
+		return nil, fmt.Errorf("magic `%q`, %w", buf[:4], ErrCorrupted)
+	}
+
+	var hdr struct {
+		Format   uint16
+		Mode     uint32
+		Patterns uint32
+		Size     uint64
+		Checksum uint32
+		Version  uint16
+	}
+
+	_ = binary.Read(bytes.NewReader(buf[4:]), binary.LittleEndian, &hdr)
+
+	if hdr.Format != dbFileFormat {
+		return nil, fmt.Errorf("file format %d, %w", hdr.Format, ErrUnexpected)
+	}
+
+	ver := make([]byte, hdr.Version)
+
+	if _, err := io.ReadFull(r, ver); err != nil {
+		return nil, fmt.Errorf("read version, %v, %w", err, ErrCorrupted)
+	}
+
+	return &DatabaseFileHeader{
+		int(hdr.Format), string(ver), ModeFlag(hdr.Mode), int(hdr.Patterns), int(hdr.Size), hdr.Checksum,
+	}, nil
+}
+
+// SaveDatabaseToFile saves the serialized database to a file with header and checksum,
+// which could be loaded by `LoadDatabaseFromFile`.
+func SaveDatabaseToFile(db Database, path string) error {
+	info, err := db.Info()
+	if err != nil {
+		return err
+	}
+
+	ver, err := info.Version()
+	if err != nil {
+		return err
+	}
+
+	mode, err := info.Mode()
+	if err != nil {
+		return err
+	}
+
+	data, err := db.Marshal()
+	if err != nil {
+		return err
+	}
+
+	var patterns int
+
+	if d, ok := db.(baseDatabaser); ok {
+		patterns = d.base().patterns
+	}
+
+	hdr := DatabaseFileHeader{dbFileFormat, ver, mode, patterns, len(data), crc32.ChecksumIEEE(data)}
+
+	return writeFileAtomic(path, append(hdr.marshal(), data...))
+}
+
+// LoadDatabaseFromFile loads a database saved by `SaveDatabaseToFile`,
+// the header and checksum are validated before deserializing the database.
+func LoadDatabaseFromFile(path string) (Database, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read database file, %w", err)
+	}
+
+	return loadDatabaseFile(data, path)
+}
+
+// loadDatabaseFile loads a database from the content of file saved by `SaveDatabaseToFile`.
+func loadDatabaseFile(data []byte, path string) (Database, error) {
+	r := bytes.NewReader(data)
+
+	hdr, err := ReadDatabaseFileHeader(r)
+	if err != nil {
+		return nil, fmt.Errorf("database file %s, %w", path, err)
+	}
+
+	data = data[len(data)-r.Len():]
+
+	if len(data) != hdr.Size {
+		return nil, fmt.Errorf("database file %s, %d bytes expected but got %d bytes, %w",
+			path, hdr.Size, len(data), ErrCorrupted)
+	}
+
+	if sum := crc32.ChecksumIEEE(data); sum != hdr.Checksum {
+		return nil, fmt.Errorf("database file %s, checksum %08x mismatched %08x, %w", path, sum, hdr.Checksum, ErrCorrupted)
+	}
+
+	if ver := Version(); hdr.Version != "" && !strings.HasPrefix(ver, hdr.Version) {
+		return nil, fmt.Errorf("database file %s, compiled by Hyperscan %s, %w", path, hdr.Version, ErrDatabaseVersionError)
+	}
+
+	db, err := deserializeDatabase(data)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := newDatabase(db, hdr.Mode)
+	if err != nil {
+		_ = hsFreeDatabase(db)
+
+		return nil, err
+	}
+
+	d.(baseDatabaser).base().patterns = hdr.Patterns
+
+	return d, nil
+}
@@ -0,0 +1,115 @@
+package hyperscan_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestDatabaseFile(t *testing.T) {
+	Convey("Given a stream database", t, func() {
+		dir, err := ioutil.TempDir("", "gohs")
+
+		So(err, ShouldBeNil)
+
+		defer os.RemoveAll(dir)
+
+		db, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`foo`), hyperscan.NewPattern(`bar\d+`))
+
+		So(err, ShouldBeNil)
+
+		path := filepath.Join(dir, "test.db")
+
+		Convey("When save it to a file", func() {
+			So(hyperscan.SaveDatabaseToFile(db, path), ShouldBeNil)
+
+			f, err := os.Open(path)
+
+			So(err, ShouldBeNil)
+
+			hdr, err := hyperscan.ReadDatabaseFileHeader(f)
+
+			So(f.Close(), ShouldBeNil)
+			So(err, ShouldBeNil)
+			So(hdr.Format, ShouldEqual, 1)
+			So(hdr.Mode, ShouldEqual, hyperscan.StreamMode)
+			So(hdr.Patterns, ShouldEqual, 2)
+			So(hdr.Version, ShouldNotBeEmpty)
+			So(hdr.Size, ShouldBeGreaterThan, 0)
+
+			Convey("Then load it from the file", func() {
+				loaded, err := hyperscan.LoadDatabaseFromFile(path)
+
+				So(err, ShouldBeNil)
+
+				_, ok := loaded.(hyperscan.StreamDatabase)
+
+				So(ok, ShouldBeTrue)
+				So(loaded.Close(), ShouldBeNil)
+			})
+
+			Convey("Then load a truncated file", func() {
+				data, err := ioutil.ReadFile(path)
+
+				So(err, ShouldBeNil)
+				So(ioutil.WriteFile(path, data[:len(data)-1], 0o600), ShouldBeNil)
+
+				_, err = hyperscan.LoadDatabaseFromFile(path)
+
+				So(errors.Is(err, hyperscan.ErrCorrupted), ShouldBeTrue)
+			})
+
+			Convey("Then load a corrupted file", func() {
+				data, err := ioutil.ReadFile(path)
+
+				So(err, ShouldBeNil)
+
+				data[len(data)-1] ^= 0xff
+
+				So(ioutil.WriteFile(path, data, 0o600), ShouldBeNil)
+
+				_, err = hyperscan.LoadDatabaseFromFile(path)
+
+				So(errors.Is(err, hyperscan.ErrCorrupted), ShouldBeTrue)
+			})
+
+			Convey("Then load a file of unknown format", func() {
+				data, err := ioutil.ReadFile(path)
+
+				So(err, ShouldBeNil)
+
+				data[4]++
+
+				So(ioutil.WriteFile(path, data, 0o600), ShouldBeNil)
+
+				_, err = hyperscan.LoadDatabaseFromFile(path)
+
+				So(errors.Is(err, hyperscan.ErrUnexpected), ShouldBeTrue)
+			})
+		})
+
+		So(db.Close(), ShouldBeNil)
+	})
+
+	Convey("Given a file without header", t, func() {
+		dir, err := ioutil.TempDir("", "gohs")
+
+		So(err, ShouldBeNil)
+
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "test.db")
+
+		So(ioutil.WriteFile(path, []byte("raw database"), 0o600), ShouldBeNil)
+
+		_, err = hyperscan.LoadDatabaseFromFile(path)
+
+		So(errors.Is(err, hyperscan.ErrCorrupted), ShouldBeTrue)
+	})
+}
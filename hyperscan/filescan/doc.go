@@ -0,0 +1,5 @@
+// Package filescan scans the files in a file system or directory tree concurrently with a block database.
+//
+// The files are read and scanned by a pool of workers, each of which owns a scratch space,
+// and the matches are passed to the handler one at a time, or delivered on a channel.
+package filescan
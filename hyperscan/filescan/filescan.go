@@ -0,0 +1,208 @@
+//go:build go1.16
+// +build go1.16
+
+package filescan
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+// Result is a match in the scanned file.
+type Result struct {
+	Path     string
+	ID       uint
+	From, To uint64
+	Flags    uint
+}
+
+// Handler handles the matches, it is never called concurrently.
+type Handler func(result *Result) error
+
+// Scanner scans the files concurrently with a block database.
+type Scanner struct {
+	db hyperscan.BlockDatabase
+
+	// Workers is the number of files scanned concurrently, default to the number of CPUs.
+	Workers int
+
+	// OnError handles the errors of walking or reading files, the scanning is aborted if it returns an error.
+	// The scanning is aborted at the first error if it is nil.
+	OnError func(path string, err error) error
+}
+
+// New returns a scanner which scans the files with the block database.
+func New(db hyperscan.BlockDatabase) *Scanner {
+	return &Scanner{db: db}
+}
+
+func (s *Scanner) fileError(path string, err error) error {
+	if s.OnError != nil {
+		return s.OnError(path, err)
+	}
+
+	return fmt.Errorf("%s, %w", path, err)
+}
+
+// ScanFS walks the file tree rooted at root in the file system, and scans the regular files.
+//
+// It returns the first error of scanning, or the error of context if it was done.
+func (s *Scanner) ScanFS(ctx context.Context, fsys fs.FS, root string, handler Handler) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := s.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		once     sync.Once
+		firstErr error
+	)
+
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	paths := make(chan string)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := s.worker(ctx, fsys, paths, func(r *Result) error {
+				mu.Lock()
+				defer mu.Unlock()
+
+				return handler(r)
+			}); err != nil {
+				fail(err)
+			}
+		}()
+	}
+
+	err := fs.WalkDir(fsys, root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return s.fileError(path, err)
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		select {
+		case paths <- path:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err() // nolint: wrapcheck
+		}
+	})
+
+	close(paths)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return err // nolint: wrapcheck
+}
+
+func (s *Scanner) worker(ctx context.Context, fsys fs.FS, paths <-chan string, handler Handler) error {
+	scratch, err := hyperscan.NewScratch(s.db)
+	if err != nil {
+		return fmt.Errorf("create scratch, %w", err)
+	}
+
+	defer func() {
+		_ = scratch.Free()
+	}()
+
+	for path := range paths {
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			if err = s.fileError(path, err); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		path := path
+
+		err = hyperscan.ScanContext(ctx, s.db, data, scratch, func(id uint, from, to uint64, flags uint, _ interface{}) error {
+			return handler(&Result{Path: path, ID: id, From: from, To: to, Flags: flags})
+		}, nil)
+		if err != nil {
+			return fmt.Errorf("scan %s, %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// ScanDir walks the directory tree rooted at dir, and scans the regular files.
+//
+// The paths of results are joined with dir.
+func (s *Scanner) ScanDir(ctx context.Context, dir string, handler Handler) error {
+	return s.ScanFS(ctx, os.DirFS(dir), ".", func(r *Result) error {
+		r.Path = filepath.Join(dir, filepath.FromSlash(r.Path))
+
+		return handler(r)
+	})
+}
+
+// Results delivers the matches of a scanning running on dedicated goroutines.
+type Results struct {
+	// C is the buffered channel of results, which is closed when the scanning completed or failed.
+	C <-chan *Result
+
+	done chan struct{}
+	err  error
+}
+
+// Err waits for the scanning completed, and returns its error.
+func (r *Results) Err() error {
+	<-r.done
+
+	return r.err
+}
+
+// Chan scans the file system on dedicated goroutines, and delivers the matches on the channel.
+//
+// The consumer should cancel the context if it stops receiving before the channel closed.
+func (s *Scanner) Chan(ctx context.Context, fsys fs.FS, root string, size int) *Results {
+	c := make(chan *Result, size)
+	r := &Results{C: c, done: make(chan struct{})}
+
+	go func() {
+		defer close(r.done)
+		defer close(c)
+
+		r.err = s.ScanFS(ctx, fsys, root, func(result *Result) error {
+			select {
+			case c <- result:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err() // nolint: wrapcheck
+			}
+		})
+	}()
+
+	return r
+}
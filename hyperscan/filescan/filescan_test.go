@@ -0,0 +1,94 @@
+//go:build go1.16
+// +build go1.16
+
+package filescan_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"testing/fstest"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+	"github.com/flier/gohs/hyperscan/filescan"
+)
+
+func TestScanner(t *testing.T) {
+	Convey("Given a file system and a block database", t, func() {
+		fsys := fstest.MapFS{
+			"a.txt":       {Data: []byte("foo bar")},
+			"dir/b.txt":   {Data: []byte("bar foo foo")},
+			"dir/c.txt":   {Data: []byte("nothing")},
+			"dir/d/e.txt": {Data: []byte("foo")},
+		}
+
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		s := filescan.New(bdb)
+		s.Workers = 2
+
+		Convey("When scan the file system", func() {
+			var results []filescan.Result
+
+			err := s.ScanFS(context.Background(), fsys, ".", func(r *filescan.Result) error {
+				results = append(results, *r)
+
+				return nil
+			})
+
+			sort.Slice(results, func(i, j int) bool {
+				if results[i].Path != results[j].Path {
+					return results[i].Path < results[j].Path
+				}
+
+				return results[i].From < results[j].From
+			})
+
+			Convey("Then the matches of all files are reported", func() {
+				So(err, ShouldBeNil)
+				So(results, ShouldResemble, []filescan.Result{
+					{Path: "a.txt", From: 0, To: 3},
+					{Path: "dir/b.txt", From: 4, To: 7},
+					{Path: "dir/b.txt", From: 8, To: 11},
+					{Path: "dir/d/e.txt", From: 0, To: 3},
+				})
+			})
+		})
+
+		Convey("When the handler fails", func() {
+			errHandler := errors.New("handler failed")
+
+			err := s.ScanFS(context.Background(), fsys, ".", func(r *filescan.Result) error {
+				return errHandler
+			})
+
+			So(errors.Is(err, errHandler), ShouldBeTrue)
+		})
+
+		Convey("When the context was canceled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			So(errors.Is(s.ScanFS(ctx, fsys, ".", func(r *filescan.Result) error { return nil }),
+				context.Canceled), ShouldBeTrue)
+		})
+
+		Convey("When deliver the matches on a channel", func() {
+			r := s.Chan(context.Background(), fsys, "dir", 1)
+
+			n := 0
+			for range r.C {
+				n++
+			}
+
+			So(r.Err(), ShouldBeNil)
+			So(n, ShouldEqual, 3)
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
@@ -0,0 +1,86 @@
+package hyperscan
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// LeakHandler is called when a finalizer reclaims a leaked object,
+// with the kind of the object, one of "database", "scratch" or "stream".
+type LeakHandler func(kind string)
+
+var (
+	finalizers  int32
+	leakHandler atomic.Value
+)
+
+// SetFinalizers enables or disables the finalizers on the databases, scratch spaces and streams created afterward,
+// so the C resources of the objects which are leaked without Close or Free are reclaimed by the garbage collector.
+//
+// The databases and scratch spaces must be kept reachable while the streams opened with them are in use.
+func SetFinalizers(enabled bool) {
+	var v int32
+
+	if enabled {
+		v = 1
+	}
+
+	atomic.StoreInt32(&finalizers, v)
+}
+
+// SetLeakHandler sets the handler called when a finalizer reclaims a leaked object, nil to disable it.
+func SetLeakHandler(handler LeakHandler) {
+	leakHandler.Store(handler)
+}
+
+func finalizersEnabled() bool { return atomic.LoadInt32(&finalizers) != 0 }
+
+func reportLeak(kind string) {
+	if h, ok := leakHandler.Load().(LeakHandler); ok && h != nil {
+		h(kind)
+	}
+}
+
+func trackDatabase(d *baseDatabase) *baseDatabase {
+	if finalizersEnabled() {
+		runtime.SetFinalizer(d, func(d *baseDatabase) {
+			if d.db != nil {
+				reportLeak("database")
+
+				_ = d.Close()
+			}
+		})
+	}
+
+	return d
+}
+
+func trackScratch(s *Scratch) *Scratch {
+	if finalizersEnabled() {
+		runtime.SetFinalizer(s, func(s *Scratch) {
+			if s.s != nil {
+				reportLeak("scratch")
+
+				_ = s.Free()
+			}
+		})
+	}
+
+	return s
+}
+
+func trackStream(s *stream) *stream {
+	if finalizersEnabled() {
+		runtime.SetFinalizer(s, func(s *stream) {
+			if s.stream != nil {
+				reportLeak("stream")
+
+				_ = hsFreeStream(s.stream)
+
+				s.freeScratch()
+			}
+		})
+	}
+
+	return s
+}
@@ -0,0 +1,126 @@
+package hyperscan_test
+
+import (
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestFinalizers(t *testing.T) {
+	Convey("Given the finalizers enabled with a leak handler", t, func() {
+		var leaked, databases int32
+
+		hyperscan.SetFinalizers(true)
+		hyperscan.SetLeakHandler(func(kind string) {
+			switch kind {
+			case "scratch":
+				atomic.AddInt32(&leaked, 1)
+			case "database":
+				atomic.AddInt32(&databases, 1)
+			}
+		})
+
+		defer func() {
+			hyperscan.SetFinalizers(false)
+			hyperscan.SetLeakHandler(nil)
+		}()
+
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`test`))
+
+		So(err, ShouldBeNil)
+
+		Convey("When a scratch is leaked", func() {
+			func() {
+				s, err := hyperscan.NewScratch(bdb)
+
+				So(err, ShouldBeNil)
+				So(s, ShouldNotBeNil)
+			}()
+
+			reclaimed := waitForGC(func() bool { return atomic.LoadInt32(&leaked) > 0 })
+
+			Convey("Then it is reclaimed and reported", func() {
+				So(reclaimed, ShouldBeTrue)
+				So(atomic.LoadInt32(&leaked), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When a scratch is freed", func() {
+			s, err := hyperscan.NewScratch(bdb)
+
+			So(err, ShouldBeNil)
+			So(s.Free(), ShouldBeNil)
+
+			runtime.GC()
+			time.Sleep(10 * time.Millisecond)
+
+			Convey("Then it is not reported", func() {
+				So(atomic.LoadInt32(&leaked), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When a pooled scratch is put back to the pool", func() {
+			pool, err := hyperscan.NewScratchPool(bdb)
+			So(err, ShouldBeNil)
+
+			s, err := pool.Get()
+			So(err, ShouldBeNil)
+
+			pool.Put(s)
+
+			runtime.GC()
+
+			live := hyperscan.ScratchMetrics().Live
+
+			Convey("Then it is freed by the pool without being reported", func() {
+				So(atomic.LoadInt32(&leaked), ShouldEqual, 0)
+				So(pool.Close(), ShouldBeNil)
+				So(live-hyperscan.ScratchMetrics().Live, ShouldEqual, 2)
+			})
+		})
+
+		Convey("When a database is only referenced by the running scan", func() {
+			db, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`))
+
+			So(err, ShouldBeNil)
+
+			reclaimed := int32(-1)
+
+			err = db.Scan([]byte("foo"), nil, func(id uint, from, to uint64, flags uint, context interface{}) error {
+				runtime.GC()
+				time.Sleep(10 * time.Millisecond)
+
+				reclaimed = atomic.LoadInt32(&databases)
+
+				return nil
+			}, nil)
+
+			Convey("Then it is not reclaimed until the scan completed", func() {
+				So(err, ShouldBeNil)
+				So(reclaimed, ShouldEqual, 0)
+			})
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
+
+// waitForGC runs the garbage collector until the finalizers satisfied the condition, or the time is out.
+func waitForGC(cond func() bool) bool {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		runtime.GC()
+
+		if cond() {
+			return true
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	return cond()
+}
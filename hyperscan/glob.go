@@ -0,0 +1,237 @@
+package hyperscan
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+GlobExpression converts a shell-style glob to an anchored expression.
+
+	?		matches any single character except `/`
+	*		matches any sequence of characters except `/`
+	**		matches any sequence of characters including `/`, `**` + `/` also matches an empty directory
+	[abc]		matches any character in the set, `[!abc]` or `[^abc]` matches any character not in the set and `/`
+	{foo,bar}	matches any of the alternatives, which may contain the other globs
+	\c		matches the character c
+
+The non-ASCII characters in a character class are only matched as a whole in the `Utf8Mode`.
+
+*/
+func GlobExpression(glob string) (string, error) {
+	expr, err := globExpr(glob)
+	if err != nil {
+		return "", fmt.Errorf("glob `%s`, %w", glob, err)
+	}
+
+	return `\A` + expr + `\z`, nil
+}
+
+// NewGlobPattern returns a new pattern base on the glob and compile flags.
+func NewGlobPattern(glob string, flags ...CompileFlag) (*Pattern, error) {
+	expr, err := GlobExpression(glob)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPattern(expr, append(flags, DotAll)...), nil
+}
+
+// NewGlobDatabase create a block database base on the globs, the ID of pattern is the index of glob.
+func NewGlobDatabase(globs ...string) (BlockDatabase, error) {
+	patterns := make(Patterns, len(globs))
+
+	for i, glob := range globs {
+		p, err := NewGlobPattern(glob, SingleMatch)
+		if err != nil {
+			return nil, err
+		}
+
+		p.Id = i
+		patterns[i] = p
+	}
+
+	return NewBlockDatabase(patterns...)
+}
+
+// nolint: gocyclo,cyclop
+func globExpr(glob string) (string, error) {
+	var b strings.Builder
+
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if strings.HasPrefix(glob[i:], "**/") {
+				b.WriteString(`(?:.*/)?`)
+				i += 2
+			} else if strings.HasPrefix(glob[i:], "**") {
+				b.WriteString(`.*`)
+				i++
+			} else {
+				b.WriteString(`[^/]*`)
+			}
+
+		case '?':
+			b.WriteString(`[^/]`)
+
+		case '[':
+			n, err := globClass(&b, glob[i:])
+			if err != nil {
+				return "", err
+			}
+
+			i += n
+
+		case '{':
+			n := matchingBrace(glob[i:])
+			if n < 0 {
+				b.WriteString(EscapeLiteral("{"))
+
+				continue
+			}
+
+			b.WriteString(`(?:`)
+
+			for j, alt := range splitAlternatives(glob[i+1 : i+n]) {
+				expr, err := globExpr(alt)
+				if err != nil {
+					return "", err
+				}
+
+				if j > 0 {
+					b.WriteByte('|')
+				}
+
+				b.WriteString(expr)
+			}
+
+			b.WriteString(`)`)
+
+			i += n
+
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+			}
+
+			i += globLiteral(&b, glob[i:]) - 1
+
+		default:
+			i += globLiteral(&b, glob[i:]) - 1
+		}
+	}
+
+	return b.String(), nil
+}
+
+// globLiteral writes the escaped character at the beginning of s, and returns its length.
+func globLiteral(b *strings.Builder, s string) int {
+	_, n := utf8.DecodeRuneInString(s)
+
+	b.WriteString(EscapeLiteral(s[:n]))
+
+	return n
+}
+
+// globClass writes the character class at the beginning of s, and returns the offset of `]`.
+func globClass(b *strings.Builder, s string) (int, error) {
+	i := 1
+	negated := i < len(s) && (s[i] == '!' || s[i] == '^')
+
+	if negated {
+		i++
+	}
+
+	start := i
+
+	for ; i < len(s); i++ {
+		if s[i] == ']' && i > start {
+			break
+		}
+
+		if s[i] == '\\' {
+			i++
+		}
+	}
+
+	if i >= len(s) {
+		return 0, fmt.Errorf("unterminated character class, %w", ErrInvalid)
+	}
+
+	b.WriteByte('[')
+
+	if negated {
+		b.WriteString(`^/`)
+	}
+
+	for j := start; j < i; j++ {
+		c := s[j]
+
+		switch {
+		case c == '\\':
+			j++
+			c = s[j]
+		case c == '-' && j > start && j+1 < i:
+			b.WriteByte('-')
+
+			continue
+		case c >= utf8.RuneSelf:
+			if r, n := utf8.DecodeRuneInString(s[j:]); r != utf8.RuneError {
+				b.WriteString(s[j : j+n])
+				j += n - 1
+
+				continue
+			}
+		}
+
+		fmt.Fprintf(b, `\x%02x`, c)
+	}
+
+	b.WriteByte(']')
+
+	return i, nil
+}
+
+// matchingBrace returns the offset of `}` matches the `{` at the beginning of s, or -1 if not found.
+func matchingBrace(s string) int {
+	depth := 0
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// splitAlternatives splits s by the commas not in the nested braces.
+func splitAlternatives(s string) (alts []string) {
+	depth, start := 0, 0
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				alts = append(alts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(alts, s[start:])
+}
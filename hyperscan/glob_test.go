@@ -0,0 +1,63 @@
+package hyperscan_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestGlobExpression(t *testing.T) {
+	Convey("Given some globs", t, func() {
+		cases := map[string]string{
+			`*.exe`:         `\A[^/]*\x2eexe\z`,
+			`prefix-??-*`:   `\Aprefix\x2d[^/][^/]\x2d[^/]*\z`,
+			`**/*.go`:       `\A(?:.*/)?[^/]*\x2ego\z`,
+			`src/**`:        `\Asrc\x2f.*\z`,
+			`[a-c]`:         `\A[\x61-\x63]\z`,
+			`[!a-]`:         `\A[^/\x61\x2d]\z`,
+			`[]]`:           `\A[\x5d]\z`,
+			`*.{jpg,png}`:   `\A[^/]*\x2e(?:jpg|png)\z`,
+			`{a,b{c,d}}`:    `\A(?:a|b(?:c|d))\z`,
+			`\*\[`:          `\A\x2a\x5b\z`,
+			`{unterminated`: `\A\x7bunterminated\z`,
+			`café.[éè]`:     `\Acafé\x2e[éè]\z`,
+		}
+
+		for glob, expr := range cases {
+			Convey("When convert glob "+glob, func() {
+				s, err := hyperscan.GlobExpression(glob)
+
+				So(err, ShouldBeNil)
+				So(s, ShouldEqual, expr)
+			})
+		}
+
+		Convey("When convert an unterminated class", func() {
+			_, err := hyperscan.GlobExpression(`[abc`)
+
+			So(errors.Is(err, hyperscan.ErrInvalid), ShouldBeTrue)
+		})
+	})
+}
+
+func TestGlobDatabase(t *testing.T) {
+	Convey("Given a glob database", t, func() {
+		db, err := hyperscan.NewGlobDatabase(`*.exe`, `**/*.go`, `prefix-??-*`)
+
+		So(err, ShouldBeNil)
+
+		Convey("When match the paths", func() {
+			So(db.MatchString("setup.exe"), ShouldBeTrue)
+			So(db.MatchString("bin/setup.exe"), ShouldBeFalse)
+			So(db.MatchString("main.go"), ShouldBeTrue)
+			So(db.MatchString("a/b/main.go"), ShouldBeTrue)
+			So(db.MatchString("prefix-ab-test"), ShouldBeTrue)
+			So(db.MatchString("prefix-abc-test"), ShouldBeFalse)
+		})
+
+		So(db.Close(), ShouldBeNil)
+	})
+}
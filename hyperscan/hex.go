@@ -0,0 +1,151 @@
+package hyperscan
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+HexExpression converts a binary signature in hex to an expression, the whitespaces are ignored.
+
+	DE AD		matches the bytes 0xDE 0xAD
+	??		matches any byte
+	D? ?D		matches any byte with the high or low nibble
+	[2-4]		matches 2 to 4 arbitrary bytes, `[4]` matches 4 bytes and `[2-]` matches at least 2 bytes
+	(DE | AD)	matches any of the alternatives
+
+The expression should be compiled with `DotAll`, `NewHexPattern` does it.
+*/
+func HexExpression(sig string) (string, error) {
+	var b strings.Builder
+
+	s := strings.Join(strings.Fields(sig), "")
+	depth := 0
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '(':
+			depth++
+
+			b.WriteString(`(?:`)
+
+		case ')':
+			if depth--; depth < 0 {
+				return "", fmt.Errorf("signature `%s`, unbalanced parentheses, %w", sig, ErrInvalid)
+			}
+
+			b.WriteByte(')')
+
+		case '|':
+			b.WriteByte('|')
+
+		case '[':
+			n := strings.IndexByte(s[i:], ']')
+			if n < 0 {
+				return "", fmt.Errorf("signature `%s`, unterminated jump, %w", sig, ErrInvalid)
+			}
+
+			jump, err := hexJump(s[i+1 : i+n])
+			if err != nil {
+				return "", fmt.Errorf("signature `%s`, invalid jump `%s`, %w", sig, s[i:i+n+1], err)
+			}
+
+			b.WriteString(jump)
+
+			i += n
+
+		default:
+			if i+1 >= len(s) {
+				return "", fmt.Errorf("signature `%s`, incomplete byte `%c`, %w", sig, c, ErrInvalid)
+			}
+
+			expr, err := hexByte(s[i], s[i+1])
+			if err != nil {
+				return "", fmt.Errorf("signature `%s`, invalid byte `%s`, %w", sig, s[i:i+2], err)
+			}
+
+			b.WriteString(expr)
+
+			i++
+		}
+	}
+
+	if depth != 0 {
+		return "", fmt.Errorf("signature `%s`, unbalanced parentheses, %w", sig, ErrInvalid)
+	}
+
+	return b.String(), nil
+}
+
+// NewHexPattern returns a new pattern base on the binary signature in hex and compile flags.
+func NewHexPattern(sig string, flags ...CompileFlag) (*Pattern, error) {
+	expr, err := HexExpression(sig)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPattern(expr, append(flags, DotAll)...), nil
+}
+
+func hexByte(hi, lo byte) (string, error) {
+	switch {
+	case hi == '?' && lo == '?':
+		return ".", nil
+	case hi == '?':
+		n, err := strconv.ParseUint(string(lo), 16, 8)
+		if err != nil {
+			return "", ErrInvalid
+		}
+
+		var b strings.Builder
+
+		b.WriteByte('[')
+
+		for h := uint64(0); h < 16; h++ {
+			fmt.Fprintf(&b, `\x%02x`, h<<4|n)
+		}
+
+		b.WriteByte(']')
+
+		return b.String(), nil
+	case lo == '?':
+		n, err := strconv.ParseUint(string(hi), 16, 8)
+		if err != nil {
+			return "", ErrInvalid
+		}
+
+		return fmt.Sprintf(`[\x%02x-\x%02x]`, n<<4, n<<4|0xf), nil
+	default:
+		n, err := strconv.ParseUint(string([]byte{hi, lo}), 16, 8)
+		if err != nil {
+			return "", ErrInvalid
+		}
+
+		return fmt.Sprintf(`\x%02x`, n), nil
+	}
+}
+
+func hexJump(s string) (string, error) {
+	parts := strings.SplitN(s, "-", 2) // nolint: gomnd
+
+	least, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return "", ErrInvalid
+	}
+
+	if len(parts) == 1 {
+		return fmt.Sprintf(`.{%d}`, least), nil
+	}
+
+	if parts[1] == "" {
+		return fmt.Sprintf(`.{%d,}`, least), nil
+	}
+
+	most, err := strconv.Atoi(parts[1])
+	if err != nil || most < least {
+		return "", ErrInvalid
+	}
+
+	return fmt.Sprintf(`.{%d,%d}`, least, most), nil
+}
@@ -0,0 +1,60 @@
+package hyperscan_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestHexExpression(t *testing.T) {
+	Convey("Given some binary signatures", t, func() {
+		cases := map[string]string{
+			`DE AD ?? EF`:  `\xde\xad.\xef`,
+			`dead beef`:    `\xde\xad\xbe\xef`,
+			`4? ?1`:        `[\x40-\x4f][\x01\x11\x21\x31\x41\x51\x61\x71\x81\x91\xa1\xb1\xc1\xd1\xe1\xf1]`,
+			`00 [2-4] FF`:  `\x00.{2,4}\xff`,
+			`00 [4] [2-]`:  `\x00.{4}.{2,}`,
+			`(AA | BB) CC`: `(?:\xaa|\xbb)\xcc`,
+		}
+
+		for sig, expr := range cases {
+			Convey("When convert signature "+sig, func() {
+				s, err := hyperscan.HexExpression(sig)
+
+				So(err, ShouldBeNil)
+				So(s, ShouldEqual, expr)
+			})
+		}
+
+		Convey("When convert some invalid signatures", func() {
+			for _, sig := range []string{`DE A`, `XY`, `(AA`, `AA)`, `[4-2]`, `[a]`, `[2`} {
+				_, err := hyperscan.HexExpression(sig)
+
+				So(errors.Is(err, hyperscan.ErrInvalid), ShouldBeTrue)
+			}
+		})
+	})
+}
+
+func TestHexPattern(t *testing.T) {
+	Convey("Given a binary signature", t, func() {
+		p, err := hyperscan.NewHexPattern(`DE AD ?? EF`, hyperscan.SomLeftMost)
+
+		So(err, ShouldBeNil)
+		So(p.Flags, ShouldEqual, hyperscan.SomLeftMost|hyperscan.DotAll)
+
+		db, err := hyperscan.NewBlockDatabase(p)
+
+		So(err, ShouldBeNil)
+
+		Convey("When match the binary data", func() {
+			So(db.FindIndex([]byte{0x00, 0xde, 0xad, '\n', 0xef}), ShouldResemble, []int{1, 5})
+			So(db.Match([]byte{0xde, 0xad, 0xef}), ShouldBeFalse)
+		})
+
+		So(db.Close(), ShouldBeNil)
+	})
+}
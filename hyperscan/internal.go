@@ -3,11 +3,14 @@ package hyperscan
 import (
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
 	"runtime"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"unsafe"
 
 	"github.com/flier/gohs/hyperscan/handle"
@@ -20,6 +23,7 @@ import (
 #include <stdlib.h>
 #include <limits.h>
 #include <stdint.h>
+#include <string.h>
 
 #include <hs.h>
 
@@ -59,6 +63,106 @@ DEFINE_ALLOCTOR(Scratch, scratch);
 DEFINE_ALLOCTOR(Stream, stream);
 
 extern int hsMatchEventCallback(unsigned int id, unsigned long long from, unsigned long long to, unsigned int flags, void *context);
+
+typedef struct {
+	void *context;
+	unsigned int index;
+} hs_batch_context_t;
+
+extern int hsBatchMatchEventCallback(unsigned int id, unsigned long long from, unsigned long long to, unsigned int flags, void *context);
+
+static inline hs_error_t hs_scan_batch_cgo(const hs_database_t *db, const char **data, const unsigned int *length,
+	unsigned int count, unsigned int flags, hs_scratch_t *scratch, void *context, unsigned int *failed) {
+	hs_batch_context_t ctx = { context, 0 };
+
+	for (unsigned int i = 0; i < count; i++) {
+		if (length[i] == 0) {
+			continue;
+		}
+
+		ctx.index = i;
+
+		hs_error_t ret = hs_scan(db, data[i], length[i], flags, scratch, hsBatchMatchEventCallback, &ctx);
+		if (ret != HS_SUCCESS) {
+			*failed = i;
+			return ret;
+		}
+	}
+
+	return HS_SUCCESS;
+}
+
+typedef struct {
+	unsigned long long total;
+	const unsigned int *ids;
+	unsigned long long *counts;
+	unsigned int size;
+} hs_count_context_t;
+
+static int hs_count_callback(unsigned int id, unsigned long long from, unsigned long long to,
+	unsigned int flags, void *context) {
+	hs_count_context_t *ctx = (hs_count_context_t *)context;
+
+	ctx->total++;
+
+	unsigned int lo = 0, hi = ctx->size;
+
+	while (lo < hi) {
+		unsigned int mid = lo + (hi - lo) / 2;
+
+		if (ctx->ids[mid] < id) {
+			lo = mid + 1;
+		} else {
+			hi = mid;
+		}
+	}
+
+	if (lo < ctx->size && ctx->ids[lo] == id) {
+		ctx->counts[lo]++;
+	}
+
+	return 0;
+}
+
+static inline hs_error_t hs_scan_count_cgo(const hs_database_t *db, const char *data, unsigned int length,
+	unsigned int flags, hs_scratch_t *scratch, const unsigned int *ids, unsigned long long *counts,
+	unsigned int size, unsigned long long *total) {
+	hs_count_context_t ctx = { 0, ids, counts, size };
+
+	hs_error_t ret = hs_scan(db, data, length, flags, scratch, hs_count_callback, &ctx);
+
+	*total = ctx.total;
+
+	return ret;
+}
+
+typedef struct {
+	unsigned int id;
+	unsigned long long from;
+	unsigned long long to;
+	unsigned int flags;
+	int found;
+} hs_first_match_t;
+
+static int hs_first_callback(unsigned int id, unsigned long long from, unsigned long long to,
+	unsigned int flags, void *context) {
+	hs_first_match_t *m = (hs_first_match_t *)context;
+
+	m->id = id;
+	m->from = from;
+	m->to = to;
+	m->flags = flags;
+	m->found = 1;
+
+	return 1;
+}
+
+static inline hs_error_t hs_scan_first_cgo(const hs_database_t *db, const char *data, unsigned int length,
+	unsigned int flags, hs_scratch_t *scratch, hs_first_match_t *match) {
+	hs_error_t ret = hs_scan(db, data, length, flags, scratch, hs_first_callback, match);
+
+	return match->found && ret == HS_SCAN_TERMINATED ? HS_SUCCESS : ret;
+}
 */
 import "C"
 
@@ -162,6 +266,28 @@ const (
 	AVX512 CpuFeature = C.HS_CPU_FEATURES_AVX512
 )
 
+var cpuFeatures = map[CpuFeature]string{
+	AVX2:   "AVX2",
+	AVX512: "AVX512",
+}
+
+// Has reports whether all the features are included.
+func (f CpuFeature) Has(features CpuFeature) bool { return f&features == features }
+
+func (f CpuFeature) String() string {
+	var values []string
+
+	for feature, name := range cpuFeatures {
+		if (f & feature) == feature {
+			values = append(values, name)
+		}
+	}
+
+	sort.Strings(values)
+
+	return strings.Join(values, " ")
+}
+
 // TuneFlag is the tuning flags
 type TuneFlag int
 
@@ -186,6 +312,26 @@ const (
 	Goldmont TuneFlag = C.HS_TUNE_FAMILY_GLM
 )
 
+var tuneFlags = map[TuneFlag]string{
+	Generic:       "Generic",
+	SandyBridge:   "SandyBridge",
+	IvyBridge:     "IvyBridge",
+	Haswell:       "Haswell",
+	Silvermont:    "Silvermont",
+	Broadwell:     "Broadwell",
+	Skylake:       "Skylake",
+	SkylakeServer: "SkylakeServer",
+	Goldmont:      "Goldmont",
+}
+
+func (t TuneFlag) String() string {
+	if name, exists := tuneFlags[t]; exists {
+		return name
+	}
+
+	return fmt.Sprintf("TuneFlag(%d)", int(t))
+}
+
 // ModeFlag represents the compile mode flags
 type ModeFlag uint
 
@@ -293,17 +439,31 @@ func (e HsError) Error() string {
 	return fmt.Sprintf("unexpected error, %d", int(e))
 }
 
-type compileError struct {
-	msg  string
-	expr int
+// CompileError is the error returned if the pattern compiler failed, it contains the details of the failure.
+type CompileError struct {
+	// A human-readable error message describing the error.
+	Message string
+	// The zero-based index of the expression that caused the error (if this can be determined).
+	// If the error is not specific to an expression, then this value will be less than zero.
+	Index int
+	// The expression that caused the error (if this can be determined).
+	Expression Expression
+}
+
+func newCompileError(err *C.hs_compile_error_t, exprs ...Expression) *CompileError {
+	e := &CompileError{Message: C.GoString(err.message), Index: int(err.expression)}
+
+	if 0 <= e.Index && e.Index < len(exprs) {
+		e.Expression = exprs[e.Index]
+	}
+
+	return e
 }
 
-// A human-readable error message describing the error.
-func (e *compileError) Error() string { return e.msg }
+func (e *CompileError) Error() string { return e.Message }
 
-// The zero-based number of the expression that caused the error (if this can be determined).
-// If the error is not specific to an expression, then this value will be less than zero.
-func (e *compileError) Expression() int { return e.expr }
+// Unwrap returns `ErrCompileError`, the error could be checked with `errors.Is`.
+func (e *CompileError) Unwrap() error { return ErrCompileError }
 
 type hsPlatformInfo struct {
 	platform C.struct_hs_platform_info
@@ -431,6 +591,24 @@ type ExprExt struct {
 	HammingDistance uint32  // Allow patterns to approximately match within this Hamming distance.
 }
 
+// newExprExt copies the additional parameters to the C heap, the caller should free it after use.
+func newExprExt(ext *ExprExt) *C.hs_expr_ext_t {
+	if ext == nil {
+		return nil
+	}
+
+	cext := (*C.hs_expr_ext_t)(C.calloc(1, C.size_t(unsafe.Sizeof(C.hs_expr_ext_t{}))))
+
+	cext.flags = C.ulonglong(ext.Flags)
+	cext.min_offset = C.ulonglong(ext.MinOffset)
+	cext.max_offset = C.ulonglong(ext.MaxOffset)
+	cext.min_length = C.ulonglong(ext.MinLength)
+	cext.edit_distance = C.uint(ext.EditDistance)
+	cext.hamming_distance = C.uint(ext.HammingDistance)
+
+	return cext
+}
+
 // With specifies the additional parameters related to an expression.
 func (ext *ExprExt) With(exts ...Ext) *ExprExt {
 	for _, f := range exts {
@@ -477,33 +655,37 @@ func ParseExprExt(s string) (ext *ExprExt, err error) {
 	}
 
 	for _, s := range strings.Split(s, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+
 		parts := strings.SplitN(s, "=", keyValuePair)
 
 		if len(parts) != keyValuePair {
-			continue
+			return nil, fmt.Errorf("extension `%s`, %w", s, ErrInvalid)
 		}
 
-		key := strings.ToLower(parts[0])
-		value := parts[1]
+		key := strings.ToLower(strings.TrimSpace(parts[0]))
+		value := strings.TrimSpace(parts[1])
 
-		var n int
+		var n uint64
 
-		if n, err = strconv.Atoi(value); err != nil {
-			return
+		if n, err = strconv.ParseUint(value, 10, 64); err != nil {
+			return nil, fmt.Errorf("extension `%s`, %w", s, err)
 		}
 
 		switch key {
 		case "min_offset":
 			ext.Flags |= ExtMinOffset
-			ext.MinOffset = uint64(n)
+			ext.MinOffset = n
 
 		case "max_offset":
 			ext.Flags |= ExtMaxOffset
-			ext.MaxOffset = uint64(n)
+			ext.MaxOffset = n
 
 		case "min_length":
 			ext.Flags |= ExtMinLength
-			ext.MinLength = uint64(n)
+			ext.MinLength = n
 
 		case "edit_distance":
 			ext.Flags |= ExtEditDistance
@@ -512,6 +694,9 @@ func ParseExprExt(s string) (ext *ExprExt, err error) {
 		case "hamming_distance":
 			ext.Flags |= ExtHammingDistance
 			ext.HammingDistance = uint32(n)
+
+		default:
+			return nil, fmt.Errorf("extension `%s`, %w", key, ErrUnexpected)
 		}
 	}
 
@@ -545,20 +730,12 @@ func hsDefaultFree(ptr unsafe.Pointer) {
 
 //export hsDbAlloc
 func hsDbAlloc(size C.size_t) unsafe.Pointer {
-	if dbAllocator.Alloc != nil {
-		return dbAllocator.Alloc(uint(size))
-	}
-
-	return hsDefaultAlloc(uint(size))
+	return accountAlloc(memDatabase, dbAllocator.Alloc, uint(size))
 }
 
 //export hsDbFree
 func hsDbFree(ptr unsafe.Pointer) {
-	if dbAllocator.Free != nil {
-		dbAllocator.Free(ptr)
-	} else {
-		hsDefaultFree(ptr)
-	}
+	accountFree(dbAllocator.Free, ptr)
 }
 
 func hsSetDatabaseAllocator(allocFunc hsAllocFunc, freeFunc hsFreeFunc) error {
@@ -576,25 +753,19 @@ func hsClearDatabaseAllocator() error {
 		return HsError(ret)
 	}
 
+	dbAllocator = hsAllocator{}
+
 	return nil
 }
 
 //export hsMiscAlloc
 func hsMiscAlloc(size C.size_t) unsafe.Pointer {
-	if miscAllocator.Alloc != nil {
-		return miscAllocator.Alloc(uint(size))
-	}
-
-	return hsDefaultAlloc(uint(size))
+	return accountAlloc(memMisc, miscAllocator.Alloc, uint(size))
 }
 
 //export hsMiscFree
 func hsMiscFree(ptr unsafe.Pointer) {
-	if miscAllocator.Free != nil {
-		miscAllocator.Free(ptr)
-	} else {
-		hsDefaultFree(ptr)
-	}
+	accountFree(miscAllocator.Free, ptr)
 }
 
 func hsSetMiscAllocator(allocFunc hsAllocFunc, freeFunc hsFreeFunc) error {
@@ -612,25 +783,73 @@ func hsClearMiscAllocator() error {
 		return HsError(ret)
 	}
 
+	miscAllocator = hsAllocator{}
+
 	return nil
 }
 
+var (
+	// scratchAllocLock serializes the scratch allocation with a dedicated allocator against the others.
+	scratchAllocLock sync.RWMutex
+	// scratchOverride is the dedicated allocator of the scratch space being allocated.
+	scratchOverride hsAllocator
+	// scratchFrees is the free functions of the scratch memory allocated with the dedicated allocators.
+	scratchFrees sync.Map
+	// scratchDedicated is the number of the scratch memory allocated with the dedicated allocators.
+	scratchDedicated int64
+)
+
 //export hsScratchAlloc
 func hsScratchAlloc(size C.size_t) unsafe.Pointer {
-	if scratchAllocator.Alloc != nil {
-		return scratchAllocator.Alloc(uint(size))
+	if a := scratchOverride; a.Alloc != nil {
+		ptr := accountAlloc(memScratch, a.Alloc, uint(size))
+		if ptr != nil && a.Free != nil {
+			scratchFrees.Store(ptr, a.Free)
+			atomic.AddInt64(&scratchDedicated, 1)
+		}
+
+		return ptr
 	}
 
-	return hsDefaultAlloc(uint(size))
+	return accountAlloc(memScratch, scratchAllocator.Alloc, uint(size))
 }
 
 //export hsScratchFree
 func hsScratchFree(ptr unsafe.Pointer) {
-	if scratchAllocator.Free != nil {
-		scratchAllocator.Free(ptr)
-	} else {
-		hsDefaultFree(ptr)
+	if free, ok := scratchFrees.Load(ptr); ok {
+		scratchFrees.Delete(ptr)
+		atomic.AddInt64(&scratchDedicated, -1)
+		accountFree(free.(hsFreeFunc), ptr)
+
+		return
+	}
+
+	accountFree(scratchAllocator.Free, ptr)
+}
+
+// hsAllocScratchWith allocates a scratch space with the dedicated allocator,
+// the memory is freed with the paired free function regardless of the scratch allocator.
+func hsAllocScratchWith(db hsDatabase, allocFunc hsAllocFunc, freeFunc hsFreeFunc) (hsScratch, error) {
+	scratchAllocLock.Lock()
+	defer scratchAllocLock.Unlock()
+
+	// Route the scratch allocation through Go, the default allocator is used if none was set.
+	if ret := C.hs_set_scratch_allocator_cgo(); ret != C.HS_SUCCESS {
+		return nil, HsError(ret)
+	}
+
+	scratchOverride = hsAllocator{allocFunc, freeFunc}
+	defer func() { scratchOverride = hsAllocator{} }()
+
+	var scratch *C.hs_scratch_t
+
+	if ret := C.hs_alloc_scratch(db, &scratch); ret != C.HS_SUCCESS {
+		return nil, HsError(ret)
 	}
+
+	scratchAllocated()
+
+	return scratch, nil
 }
 
 func hsSetScratchAllocator(allocFunc hsAllocFunc, freeFunc hsFreeFunc) error {
@@ -644,29 +863,31 @@ func hsSetScratchAllocator(allocFunc hsAllocFunc, freeFunc hsFreeFunc) error {
 }
 
 func hsClearScratchAllocator() error {
+	scratchAllocLock.Lock()
+	defer scratchAllocLock.Unlock()
+
+	// Hyperscan would free the scratch memory with its default allocator once cleared.
+	if n := atomic.LoadInt64(&scratchDedicated); n > 0 {
+		return fmt.Errorf("%d scratch allocations with the dedicated allocators, %w", n, ErrInvalid)
+	}
+
 	if ret := C.hs_clear_scratch_allocator_cgo(); ret != C.HS_SUCCESS {
 		return HsError(ret)
 	}
 
+	scratchAllocator = hsAllocator{}
+
 	return nil
 }
 
 //export hsStreamAlloc
 func hsStreamAlloc(size C.size_t) unsafe.Pointer {
-	if streamAllocator.Alloc != nil {
-		return streamAllocator.Alloc(uint(size))
-	}
-
-	return hsDefaultAlloc(uint(size))
+	return accountAlloc(memStream, streamAllocator.Alloc, uint(size))
 }
 
 //export hsStreamFree
 func hsStreamFree(ptr unsafe.Pointer) {
-	if streamAllocator.Free != nil {
-		streamAllocator.Free(ptr)
-	} else {
-		hsDefaultFree(ptr)
-	}
+	accountFree(streamAllocator.Free, ptr)
 }
 
 func hsSetStreamAllocator(allocFunc hsAllocFunc, freeFunc hsFreeFunc) error {
@@ -684,6 +905,8 @@ func hsClearStreamAllocator() error {
 		return HsError(ret)
 	}
 
+	streamAllocator = hsAllocator{}
+
 	return nil
 }
 
@@ -723,6 +946,62 @@ func hsSerializeDatabase(db hsDatabase) (b []byte, err error) {
 	return
 }
 
+const serializeChunkSize = 1 << 20
+
+func hsSerializeDatabaseTo(db hsDatabase, w io.Writer) (int64, error) {
+	var data *C.char
+	var length C.size_t
+
+	if ret := C.hs_serialize_database(db, &data, &length); ret != C.HS_SUCCESS {
+		return 0, HsError(ret)
+	}
+
+	defer C.free(unsafe.Pointer(data))
+
+	var written int64
+
+	for off := C.size_t(0); off < length; {
+		n := length - off
+		if n > serializeChunkSize {
+			n = serializeChunkSize
+		}
+
+		chunk := C.GoBytes(unsafe.Pointer(uintptr(unsafe.Pointer(data))+uintptr(off)), C.int(n))
+
+		m, err := w.Write(chunk)
+		written += int64(m)
+
+		if err != nil {
+			return written, err // nolint: wrapcheck
+		}
+
+		off += n
+	}
+
+	return written, nil
+}
+
+func hsSerializeDatabaseInto(db hsDatabase, buf []byte) (int, error) {
+	var data *C.char
+	var length C.size_t
+
+	if ret := C.hs_serialize_database(db, &data, &length); ret != C.HS_SUCCESS {
+		return 0, HsError(ret)
+	}
+
+	defer C.free(unsafe.Pointer(data))
+
+	if int(length) > len(buf) {
+		return int(length), fmt.Errorf("%d bytes required, %w", length, io.ErrShortBuffer)
+	}
+
+	if length > 0 {
+		C.memcpy(unsafe.Pointer(&buf[0]), unsafe.Pointer(data), length)
+	}
+
+	return int(length), nil
+}
+
 func hsDeserializeDatabase(data []byte) (hsDatabase, error) {
 	var db *C.hs_database_t
 
@@ -737,6 +1016,9 @@ func hsDeserializeDatabase(data []byte) (hsDatabase, error) {
 	return db, nil
 }
 
+// hsDatabaseAt returns the database at the memory location.
+func hsDatabaseAt(mem []byte) hsDatabase { return (*C.hs_database_t)(unsafe.Pointer(&mem[0])) }
+
 func hsDeserializeDatabaseAt(data []byte, db hsDatabase) error {
 	ret := C.hs_deserialize_database_at((*C.char)(unsafe.Pointer(&data[0])), C.size_t(len(data)), db)
 
@@ -835,7 +1117,7 @@ func hsCompile(expression string, flags CompileFlag, mode ModeFlag, info *hsPlat
 	}
 
 	if ret == C.HS_COMPILER_ERROR && err != nil {
-		return nil, &compileError{C.GoString(err.message), int(err.expression)}
+		return nil, newCompileError(err, Expression(expression))
 	}
 
 	return nil, fmt.Errorf("compile error %d, %w", int(ret), ErrCompileError)
@@ -866,7 +1148,7 @@ func hsCompileMulti(patterns []*Pattern, mode ModeFlag, info *hsPlatformInfo) (h
 		exprs[i] = C.CString(string(pattern.Expression))
 		flags[i] = C.uint(pattern.Flags)
 		ids[i] = C.uint(pattern.Id)
-		exts[i] = (*C.hs_expr_ext_t)(unsafe.Pointer(pattern.ext))
+		exts[i] = newExprExt(pattern.ext)
 	}
 
 	ret := C.hs_compile_ext_multi(cexprs, cflags, cids, cexts, C.uint(len(patterns)), C.uint(mode), platform, &db, &err)
@@ -875,13 +1157,15 @@ func hsCompileMulti(patterns []*Pattern, mode ModeFlag, info *hsPlatformInfo) (h
 		C.free(unsafe.Pointer(expr))
 	}
 
+	for _, ext := range exts {
+		C.free(unsafe.Pointer(ext))
+	}
+
 	C.free(unsafe.Pointer(cexprs))
 	C.free(unsafe.Pointer(cflags))
 	C.free(unsafe.Pointer(cexts))
 	C.free(unsafe.Pointer(cids))
 
-	runtime.KeepAlive(patterns)
-
 	if err != nil {
 		defer C.hs_free_compile_error(err)
 	}
@@ -891,7 +1175,13 @@ func hsCompileMulti(patterns []*Pattern, mode ModeFlag, info *hsPlatformInfo) (h
 	}
 
 	if ret == C.HS_COMPILER_ERROR && err != nil {
-		return nil, &compileError{C.GoString(err.message), int(err.expression)}
+		exprs := make([]Expression, len(patterns))
+
+		for i, pattern := range patterns {
+			exprs[i] = pattern.Expression
+		}
+
+		return nil, newCompileError(err, exprs...)
 	}
 
 	return nil, fmt.Errorf("compile error %d, %w", int(ret), ErrCompileError)
@@ -918,67 +1208,87 @@ func hsExpressionInfo(expression string, flags CompileFlag) (*ExprInfo, error) {
 	}
 
 	if ret == C.HS_COMPILER_ERROR && err != nil {
-		return nil, &compileError{C.GoString(err.message), int(err.expression)}
+		return nil, newCompileError(err, Expression(expression))
 	}
 
 	return nil, fmt.Errorf("compile error %d, %w", int(ret), ErrCompileError)
 }
 
-func hsExpressionExt(expression string, flags CompileFlag) (ext *ExprExt, info *ExprInfo, err error) {
-	var exprInfo *C.hs_expr_info_t
-	var compileErr *C.hs_compile_error_t
+func hsExpressionExtInfo(expression string, flags CompileFlag, ext *ExprExt) (*ExprInfo, error) {
+	var info *C.hs_expr_info_t
+	var err *C.hs_compile_error_t
 
-	ext = new(ExprExt)
 	expr := C.CString(expression)
 
 	defer C.free(unsafe.Pointer(expr))
 
-	ret := C.hs_expression_ext_info(expr, C.uint(flags), (*C.hs_expr_ext_t)(unsafe.Pointer(ext)), &exprInfo, &compileErr)
+	cext := newExprExt(ext)
+
+	defer C.free(unsafe.Pointer(cext))
+
+	ret := C.hs_expression_ext_info(expr, C.uint(flags), cext, &info, &err)
 
-	if exprInfo != nil {
-		defer hsMiscFree(unsafe.Pointer(exprInfo))
+	if ret == C.HS_SUCCESS && info != nil {
+		defer hsMiscFree(unsafe.Pointer(info))
 
-		info = newExprInfo(exprInfo)
+		return newExprInfo(info), nil
 	}
 
-	if compileErr != nil {
-		defer C.hs_free_compile_error(compileErr)
+	if err != nil {
+		defer C.hs_free_compile_error(err)
 	}
 
-	if ret == C.HS_COMPILER_ERROR && compileErr != nil {
-		err = &compileError{C.GoString(compileErr.message), int(compileErr.expression)}
-	} else {
-		err = fmt.Errorf("compile error %d, %w", int(ret), ErrCompileError)
+	if ret == C.HS_COMPILER_ERROR && err != nil {
+		return nil, newCompileError(err, Expression(expression))
 	}
 
-	return
+	return nil, fmt.Errorf("compile error %d, %w", int(ret), ErrCompileError)
 }
 
 func hsAllocScratch(db hsDatabase) (hsScratch, error) {
+	scratchAllocLock.RLock()
+	defer scratchAllocLock.RUnlock()
+
 	var scratch *C.hs_scratch_t
 
 	if ret := C.hs_alloc_scratch(db, &scratch); ret != C.HS_SUCCESS {
 		return nil, HsError(ret)
 	}
 
+	scratchAllocated()
+
 	return scratch, nil
 }
 
 func hsReallocScratch(db hsDatabase, scratch *hsScratch) error {
+	scratchAllocLock.RLock()
+	defer scratchAllocLock.RUnlock()
+
+	allocated := *scratch == nil
+
 	if ret := C.hs_alloc_scratch(db, (**C.struct_hs_scratch)(scratch)); ret != C.HS_SUCCESS {
 		return HsError(ret)
 	}
 
+	if allocated {
+		scratchAllocated()
+	}
+
 	return nil
 }
 
 func hsCloneScratch(scratch hsScratch) (hsScratch, error) {
+	scratchAllocLock.RLock()
+	defer scratchAllocLock.RUnlock()
+
 	var clone *C.hs_scratch_t
 
 	if ret := C.hs_clone_scratch(scratch, &clone); ret != C.HS_SUCCESS {
 		return nil, HsError(ret)
 	}
 
+	scratchAllocated()
+
 	return clone, nil
 }
 
@@ -997,6 +1307,10 @@ func hsFreeScratch(scratch hsScratch) error {
 		return HsError(ret)
 	}
 
+	if scratch != nil {
+		scratchFreed()
+	}
+
 	return nil
 }
 
@@ -1005,34 +1319,131 @@ type hsMatchEventHandler func(id uint, from, to uint64, flags uint, context inte
 type hsMatchEventContext struct {
 	handler hsMatchEventHandler
 	context interface{}
+	err     error
+}
+
+// hsMatchEventResult records the error of handler, and translates it to the code returned to the engine.
+func hsMatchEventResult(err error, handlerErr *error) C.int {
+	if err == nil {
+		return C.HS_SUCCESS
+	}
+
+	var hsErr HsError
+	if errors.As(err, &hsErr) {
+		return C.int(hsErr)
+	}
+
+	*handlerErr = err
+
+	return C.HS_SCAN_TERMINATED
+}
+
+// hsScanResult returns the error of the handler if it terminated the scanning, or the error of the engine.
+func hsScanResult(ret C.hs_error_t, handlerErr error) error {
+	switch {
+	case ret == C.HS_SUCCESS:
+		return nil
+	case ret == C.HS_SCAN_TERMINATED && handlerErr != nil:
+		return &HandlerError{Err: handlerErr}
+	default:
+		return HsError(ret)
+	}
 }
 
 //export hsMatchEventCallback
 func hsMatchEventCallback(id C.uint, from, to C.ulonglong, flags C.uint, data unsafe.Pointer) C.int {
-	ctx, ok := handle.Handle(data).Value().(hsMatchEventContext)
+	ctx, ok := handle.Handle(data).Value().(*hsMatchEventContext)
 	if !ok {
 		return C.HS_INVALID
 	}
 
-	err := ctx.handler(uint(id), uint64(from), uint64(to), uint(flags), ctx.context)
+	return hsMatchEventResult(ctx.handler(uint(id), uint64(from), uint64(to), uint(flags), ctx.context), &ctx.err)
+}
+
+type hsBatchMatchEventHandler func(index int, id uint, from, to uint64, flags uint, context interface{}) error
+
+type hsBatchMatchEventContext struct {
+	handler hsBatchMatchEventHandler
+	context interface{}
+	err     error
+}
+
+//export hsBatchMatchEventCallback
+func hsBatchMatchEventCallback(id C.uint, from, to C.ulonglong, flags C.uint, data unsafe.Pointer) C.int {
+	batch := (*C.hs_batch_context_t)(data)
+
+	ctx, ok := handle.Handle(batch.context).Value().(*hsBatchMatchEventContext)
+	if !ok {
+		return C.HS_INVALID
+	}
+
+	err := ctx.handler(int(batch.index), uint(id), uint64(from), uint64(to), uint(flags), ctx.context)
+
+	return hsMatchEventResult(err, &ctx.err)
+}
+
+// hsScanBatch scans the independent blocks in one cgo call, it returns the index of the failed block.
+func hsScanBatch(db hsDatabase, blocks [][]byte, flags ScanFlag, scratch hsScratch,
+	onEvent hsBatchMatchEventHandler, context interface{}) (int, error) {
+	release, err := acquireScratch(scratch)
 	if err != nil {
-		var hsErr HsError
-		if errors.As(err, &hsErr) {
-			return C.int(hsErr)
+		return 0, err
+	}
+	defer release()
+
+	if len(blocks) == 0 {
+		return 0, nil
+	}
+
+	cdata := make([]uintptr, len(blocks))
+	clength := make([]C.uint, len(blocks))
+
+	for i, b := range blocks {
+		if len(b) > 0 {
+			cdata[i] = uintptr(unsafe.Pointer(&b[0]))
+			clength[i] = C.uint(len(b))
 		}
+	}
+
+	ctx := &hsBatchMatchEventContext{handler: onEvent, context: context}
+	h := handle.New(ctx)
+	defer h.Delete()
+
+	var failed C.uint
+
+	ret := C.hs_scan_batch_cgo(db,
+		(**C.char)(unsafe.Pointer(&cdata[0])),
+		&clength[0],
+		C.uint(len(blocks)),
+		C.uint(flags),
+		scratch,
+		unsafe.Pointer(h),
+		&failed)
+
+	// Ensure go data is alive before the C function returns
+	runtime.KeepAlive(blocks)
+	runtime.KeepAlive(cdata)
 
-		return C.HS_SCAN_TERMINATED
+	if ret != C.HS_SUCCESS {
+		return int(failed), hsScanResult(ret, ctx.err)
 	}
 
-	return C.HS_SUCCESS
+	return 0, nil
 }
 
 func hsScan(db hsDatabase, data []byte, flags ScanFlag, scratch hsScratch, onEvent hsMatchEventHandler, context interface{}) error {
+	release, err := acquireScratch(scratch)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	if data == nil {
 		return HsError(C.HS_INVALID)
 	}
 
-	h := handle.New(hsMatchEventContext{onEvent, context})
+	ctx := &hsMatchEventContext{handler: onEvent, context: context}
+	h := handle.New(ctx)
 	defer h.Delete()
 
 	hdr := (*reflect.SliceHeader)(unsafe.Pointer(&data)) // FIXME: Zero-copy access to go data
@@ -1048,33 +1459,118 @@ func hsScan(db hsDatabase, data []byte, flags ScanFlag, scratch hsScratch, onEve
 	// Ensure go data is alive before the C function returns
 	runtime.KeepAlive(data)
 
+	return hsScanResult(ret, ctx.err)
+}
+
+// hsScanCount counts the matches in C without calling back into Go,
+// the counts of patterns are indexed by the sorted ids.
+func hsScanCount(db hsDatabase, data []byte, flags ScanFlag, scratch hsScratch,
+	ids []uint32, counts []uint64) (uint64, error) {
+	release, err := acquireScratch(scratch)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	if data == nil {
+		return 0, HsError(C.HS_INVALID)
+	}
+
+	var (
+		cdata   *C.char
+		cids    *C.uint
+		ccounts *C.ulonglong
+		total   C.ulonglong
+	)
+
+	if len(data) > 0 {
+		cdata = (*C.char)(unsafe.Pointer(&data[0]))
+	}
+
+	if len(ids) > 0 {
+		cids = (*C.uint)(unsafe.Pointer(&ids[0]))
+		ccounts = (*C.ulonglong)(unsafe.Pointer(&counts[0]))
+	}
+
+	ret := C.hs_scan_count_cgo(db, cdata, C.uint(len(data)), C.uint(flags), scratch,
+		cids, ccounts, C.uint(len(ids)), &total)
+
+	// Ensure go data is alive before the C function returns
+	runtime.KeepAlive(data)
+	runtime.KeepAlive(ids)
+	runtime.KeepAlive(counts)
+
 	if ret != C.HS_SUCCESS {
-		return HsError(ret)
+		return 0, HsError(ret)
 	}
 
-	return nil
+	return uint64(total), nil
+}
+
+// hsScanFirst terminates the scanning at the first match in C, and returns it.
+func hsScanFirst(db hsDatabase, data []byte, flags ScanFlag, scratch hsScratch) (*RecordedMatch, error) {
+	release, err := acquireScratch(scratch)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if data == nil {
+		return nil, HsError(C.HS_INVALID)
+	}
+
+	var (
+		cdata *C.char
+		match C.hs_first_match_t
+	)
+
+	if len(data) > 0 {
+		cdata = (*C.char)(unsafe.Pointer(&data[0]))
+	}
+
+	ret := C.hs_scan_first_cgo(db, cdata, C.uint(len(data)), C.uint(flags), scratch, &match)
+
+	// Ensure go data is alive before the C function returns
+	runtime.KeepAlive(data)
+
+	if ret != C.HS_SUCCESS {
+		return nil, HsError(ret)
+	}
+
+	if match.found == 0 {
+		return nil, nil
+	}
+
+	return &RecordedMatch{uint(match.id), uint64(match.from), uint64(match.to), uint(match.flags)}, nil
 }
 
 func hsScanVector(db hsDatabase, data [][]byte, flags ScanFlag, scratch hsScratch, onEvent hsMatchEventHandler, context interface{}) error {
+	release, err := acquireScratch(scratch)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	if data == nil {
 		return HsError(C.HS_INVALID)
 	}
 
-	cdata := make([]uintptr, len(data))
-	clength := make([]C.uint, len(data))
+	// Build the arrays of blocks in one pass without copying the data,
+	// the empty blocks are skipped since they don't affect the matching.
+	cdata := make([]uintptr, 0, len(data))
+	clength := make([]C.uint, 0, len(data))
 
-	for i, d := range data {
-		if d == nil {
-			return HsError(C.HS_INVALID)
+	for _, d := range data {
+		if len(d) == 0 {
+			continue
 		}
 
-		// FIXME: Zero-copy access to go data
-		hdr := (*reflect.SliceHeader)(unsafe.Pointer(&d)) // nolint: scopelint
-		cdata[i] = uintptr(unsafe.Pointer(hdr.Data))
-		clength[i] = C.uint(hdr.Len)
+		cdata = append(cdata, uintptr(unsafe.Pointer(&d[0])))
+		clength = append(clength, C.uint(len(d)))
 	}
 
-	h := handle.New(hsMatchEventContext{onEvent, context})
+	ctx := &hsMatchEventContext{handler: onEvent, context: context}
+	h := handle.New(ctx)
 	defer h.Delete()
 
 	cdataHdr := (*reflect.SliceHeader)(unsafe.Pointer(&cdata))     // FIXME: Zero-copy access to go data
@@ -1094,11 +1590,7 @@ func hsScanVector(db hsDatabase, data [][]byte, flags ScanFlag, scratch hsScratc
 	runtime.KeepAlive(cdata)
 	runtime.KeepAlive(clength)
 
-	if ret != C.HS_SUCCESS {
-		return HsError(ret)
-	}
-
-	return nil
+	return hsScanResult(ret, ctx.err)
 }
 
 func hsOpenStream(db hsDatabase, flags ScanFlag) (hsStream, error) {
@@ -1112,11 +1604,18 @@ func hsOpenStream(db hsDatabase, flags ScanFlag) (hsStream, error) {
 }
 
 func hsScanStream(stream hsStream, data []byte, flags ScanFlag, scratch hsScratch, onEvent hsMatchEventHandler, context interface{}) error {
+	release, err := acquireScratch(scratch)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	if data == nil {
 		return HsError(C.HS_INVALID)
 	}
 
-	h := handle.New(hsMatchEventContext{onEvent, context})
+	ctx := &hsMatchEventContext{handler: onEvent, context: context}
+	h := handle.New(ctx)
 	defer h.Delete()
 
 	hdr := (*reflect.SliceHeader)(unsafe.Pointer(&data)) // FIXME: Zero-copy access to go data
@@ -1132,15 +1631,18 @@ func hsScanStream(stream hsStream, data []byte, flags ScanFlag, scratch hsScratc
 	// Ensure go data is alive before the C function returns
 	runtime.KeepAlive(data)
 
-	if ret != C.HS_SUCCESS {
-		return HsError(ret)
-	}
-
-	return nil
+	return hsScanResult(ret, ctx.err)
 }
 
 func hsCloseStream(stream hsStream, scratch hsScratch, onEvent hsMatchEventHandler, context interface{}) error {
-	h := handle.New(hsMatchEventContext{onEvent, context})
+	release, err := acquireScratch(scratch)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	ctx := &hsMatchEventContext{handler: onEvent, context: context}
+	h := handle.New(ctx)
 	defer h.Delete()
 
 	ret := C.hs_close_stream(stream,
@@ -1148,7 +1650,11 @@ func hsCloseStream(stream hsStream, scratch hsScratch, onEvent hsMatchEventHandl
 		C.match_event_handler(C.hsMatchEventCallback),
 		unsafe.Pointer(h))
 
-	if ret != C.HS_SUCCESS {
+	return hsScanResult(ret, ctx.err)
+}
+
+func hsFreeStream(stream hsStream) error {
+	if ret := C.hs_close_stream(stream, nil, nil, nil); ret != C.HS_SUCCESS {
 		return HsError(ret)
 	}
 
@@ -1156,7 +1662,14 @@ func hsCloseStream(stream hsStream, scratch hsScratch, onEvent hsMatchEventHandl
 }
 
 func hsResetStream(stream hsStream, flags ScanFlag, scratch hsScratch, onEvent hsMatchEventHandler, context interface{}) error {
-	h := handle.New(hsMatchEventContext{onEvent, context})
+	release, err := acquireScratch(scratch)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	ctx := &hsMatchEventContext{handler: onEvent, context: context}
+	h := handle.New(ctx)
 	defer h.Delete()
 
 	ret := C.hs_reset_stream(stream,
@@ -1165,11 +1678,7 @@ func hsResetStream(stream hsStream, flags ScanFlag, scratch hsScratch, onEvent h
 		C.match_event_handler(C.hsMatchEventCallback),
 		unsafe.Pointer(h))
 
-	if ret != C.HS_SUCCESS {
-		return HsError(ret)
-	}
-
-	return nil
+	return hsScanResult(ret, ctx.err)
 }
 
 func hsCopyStream(stream hsStream) (hsStream, error) {
@@ -1183,7 +1692,14 @@ func hsCopyStream(stream hsStream) (hsStream, error) {
 }
 
 func hsResetAndCopyStream(to, from hsStream, scratch hsScratch, onEvent hsMatchEventHandler, context interface{}) error {
-	h := handle.New(hsMatchEventContext{onEvent, context})
+	release, err := acquireScratch(scratch)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	ctx := &hsMatchEventContext{handler: onEvent, context: context}
+	h := handle.New(ctx)
 	defer h.Delete()
 
 	ret := C.hs_reset_and_copy_stream(to,
@@ -1192,11 +1708,7 @@ func hsResetAndCopyStream(to, from hsStream, scratch hsScratch, onEvent hsMatchE
 		C.match_event_handler(C.hsMatchEventCallback),
 		unsafe.Pointer(h))
 
-	if ret != C.HS_SUCCESS {
-		return HsError(ret)
-	}
-
-	return nil
+	return hsScanResult(ret, ctx.err)
 }
 
 func hsCompressStream(stream hsStream, buf []byte) ([]byte, error) {
@@ -1218,6 +1730,10 @@ func hsCompressStream(stream hsStream, buf []byte) ([]byte, error) {
 }
 
 func hsExpandStream(db hsDatabase, stream *hsStream, buf []byte) error {
+	if len(buf) == 0 {
+		return HsError(C.HS_INVALID)
+	}
+
 	ret := C.hs_expand_stream(db, (**C.hs_stream_t)(stream), (*C.char)(unsafe.Pointer(&buf[0])), C.size_t(len(buf)))
 
 	runtime.KeepAlive(buf)
@@ -1230,7 +1746,18 @@ func hsExpandStream(db hsDatabase, stream *hsStream, buf []byte) error {
 }
 
 func hsResetAndExpandStream(stream hsStream, buf []byte, scratch hsScratch, onEvent hsMatchEventHandler, context interface{}) error {
-	h := handle.New(hsMatchEventContext{onEvent, context})
+	if len(buf) == 0 {
+		return HsError(C.HS_INVALID)
+	}
+
+	release, err := acquireScratch(scratch)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	ctx := &hsMatchEventContext{handler: onEvent, context: context}
+	h := handle.New(ctx)
 	defer h.Delete()
 
 	ret := C.hs_reset_and_expand_stream(stream,
@@ -1242,9 +1769,5 @@ func hsResetAndExpandStream(stream hsStream, buf []byte, scratch hsScratch, onEv
 
 	runtime.KeepAlive(buf)
 
-	if ret != C.HS_SUCCESS {
-		return HsError(ret)
-	}
-
-	return nil
+	return hsScanResult(ret, ctx.err)
 }
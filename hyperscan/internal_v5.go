@@ -15,14 +15,22 @@ import (
 
 const (
 	// Combination represents logical combination.
+	//
+	// The expression is a logical combination of other patterns, referenced by their IDs,
+	// using the operators `&` (and), `|` (or), `!` (not) and parentheses, such as `(101 & 102) | !103`.
 	Combination CompileFlag = C.HS_FLAG_COMBINATION
 	// Quiet represents don't do any match reporting.
+	//
+	// The matches of the pattern will not be delivered to the match handler,
+	// but it still could be referenced by the logical combination patterns.
 	Quiet CompileFlag = C.HS_FLAG_QUIET
 )
 
 func init() {
 	compileFlags['C'] = Combination
 	compileFlags['Q'] = Quiet
+
+	logicalCombination = Combination
 }
 
 func hsCompileLit(expression string, flags CompileFlag, mode ModeFlag, info *hsPlatformInfo) (hsDatabase, error) {
@@ -49,7 +57,7 @@ func hsCompileLit(expression string, flags CompileFlag, mode ModeFlag, info *hsP
 	}
 
 	if ret == C.HS_COMPILER_ERROR && err != nil {
-		return nil, &compileError{C.GoString(err.message), int(err.expression)}
+		return nil, newCompileError(err, Expression(expression))
 	}
 
 	return nil, fmt.Errorf("compile error %d, %w", int(ret), ErrCompileError)
@@ -105,7 +113,13 @@ func hsCompileLitMulti(literals []*Literal, mode ModeFlag, info *hsPlatformInfo)
 	}
 
 	if ret == C.HS_COMPILER_ERROR && err != nil {
-		return nil, &compileError{C.GoString(err.message), int(err.expression)}
+		exprs := make([]Expression, len(literals))
+
+		for i, lit := range literals {
+			exprs[i] = lit.Expression
+		}
+
+		return nil, newCompileError(err, exprs...)
 	}
 
 	return nil, fmt.Errorf("compile error %d, %w", int(ret), ErrCompileError)
@@ -16,3 +16,10 @@ const (
 	Icelake       TuneFlag = C.HS_TUNE_FAMILY_ICL // Icelake indicates that the compiled database should be tuned for the Icelake microarchitecture.
 	IcelakeServer TuneFlag = C.HS_TUNE_FAMILY_ICX // IcelakeServer indicates that the compiled database should be tuned for the Icelake Server microarchitecture.
 )
+
+func init() {
+	cpuFeatures[AVX512VBMI] = "AVX512VBMI"
+
+	tuneFlags[Icelake] = "Icelake"
+	tuneFlags[IcelakeServer] = "IcelakeServer"
+}
@@ -0,0 +1,60 @@
+//go:build go1.23
+// +build go1.23
+
+package hyperscan
+
+import (
+	"errors"
+	"io"
+	"iter"
+)
+
+var errStopIteration = errors.New("stop iteration")
+
+// matchSeq returns an iterator over the matches reported by the scanning,
+// the scanning is terminated when breaking out of the loop.
+func matchSeq(scan func(MatchHandler) error) iter.Seq2[MatchEvent, error] {
+	return func(yield func(MatchEvent, error) bool) {
+		stopped := false
+
+		err := scan(func(id uint, from, to uint64, flags uint, context interface{}) error {
+			if !yield(&matchEvent{id, from, to, ScanFlag(flags)}, nil) {
+				stopped = true
+
+				return errStopIteration
+			}
+
+			return nil
+		})
+
+		if err != nil && !stopped {
+			yield(nil, err)
+		}
+	}
+}
+
+// Matches returns an iterator over the matches of the data scanned by the block scanner,
+// and the scanning error if it failed.
+//
+//	for m, err := range hyperscan.Matches(db, data, nil) {
+//		...
+//	}
+func Matches(scanner BlockScanner, data []byte, scratch *Scratch) iter.Seq2[MatchEvent, error] {
+	return matchSeq(func(handler MatchHandler) error {
+		return scanner.Scan(data, scratch, handler, nil)
+	})
+}
+
+// VectoredMatches returns an iterator over the matches of the data scanned by the vectored scanner.
+func VectoredMatches(scanner VectoredScanner, data [][]byte, scratch *Scratch) iter.Seq2[MatchEvent, error] {
+	return matchSeq(func(handler MatchHandler) error {
+		return scanner.Scan(data, scratch, handler, nil)
+	})
+}
+
+// StreamMatches returns an iterator over the matches of the data read from the reader and scanned by the stream scanner.
+func StreamMatches(scanner StreamScanner, reader io.Reader, scratch *Scratch) iter.Seq2[MatchEvent, error] {
+	return matchSeq(func(handler MatchHandler) error {
+		return scanner.Scan(reader, scratch, handler, nil)
+	})
+}
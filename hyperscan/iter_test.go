@@ -0,0 +1,68 @@
+//go:build go1.23
+// +build go1.23
+
+package hyperscan_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestMatches(t *testing.T) {
+	Convey("Given a block database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		data := []byte("foo bar foo")
+
+		Convey("When range over the matches", func() {
+			var locs [][]uint64
+
+			for m, err := range hyperscan.Matches(bdb, data, nil) {
+				So(err, ShouldBeNil)
+
+				locs = append(locs, []uint64{m.From(), m.To()})
+			}
+
+			So(locs, ShouldResemble, [][]uint64{{0, 3}, {8, 11}})
+		})
+
+		Convey("When break out of the loop", func() {
+			n := 0
+
+			for _, err := range hyperscan.Matches(bdb, []byte(strings.Repeat("foo", 100)), nil) {
+				So(err, ShouldBeNil)
+
+				n++
+
+				if n == 3 {
+					break
+				}
+			}
+
+			So(n, ShouldEqual, 3)
+		})
+
+		Convey("When scan the strings reader", func() {
+			sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`foo`))
+			So(err, ShouldBeNil)
+
+			n := 0
+
+			for _, err := range hyperscan.StreamMatches(sdb, strings.NewReader("foo bar foo"), nil) {
+				So(err, ShouldBeNil)
+
+				n++
+			}
+
+			So(n, ShouldEqual, 2)
+			So(sdb.Close(), ShouldBeNil)
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
@@ -0,0 +1,60 @@
+package hyperscan
+
+import "errors"
+
+// MatchLimiter stops the scanning after the handler handled the maximum number of matches.
+type MatchLimiter struct {
+	handler MatchHandler
+	max     int
+	count   int
+}
+
+// WithMaxMatches returns a limiter which passes at most n matches to the handler, zero or negative means unlimited.
+//
+//	l := hyperscan.WithMaxMatches(10, handler)
+//	limited, err := l.Done(db.Scan(data, nil, l.Handle, nil))
+func WithMaxMatches(n int, handler MatchHandler) *MatchLimiter {
+	return &MatchLimiter{handler: handler, max: n}
+}
+
+// Handle the match event, it terminates the scanning once the limit was hit.
+func (l *MatchLimiter) Handle(id uint, from, to uint64, flags uint, context interface{}) error {
+	if err := l.handler(id, from, to, flags, context); err != nil {
+		return err
+	}
+
+	l.count++
+
+	if l.max > 0 && l.count >= l.max {
+		return ErrTooManyMatches
+	}
+
+	return nil
+}
+
+// Count returns the number of matches handled.
+func (l *MatchLimiter) Count() int { return l.count }
+
+// Limited reports whether the limit was hit.
+func (l *MatchLimiter) Limited() bool { return l.max > 0 && l.count >= l.max }
+
+// Done translates the error of scanning with the limiter, the termination caused by the limit is not an error.
+func (l *MatchLimiter) Done(err error) (limited bool, _ error) {
+	if l.Limited() && errors.Is(err, ErrScanTerminated) {
+		return true, nil
+	}
+
+	return l.Limited(), err
+}
+
+// Reset the number of matches handled, so the limiter could be reused for the next scanning.
+func (l *MatchLimiter) Reset() { l.count = 0 }
+
+// ScanWithMaxMatches scans the data with the block scanner, and stops after n matches.
+// It reports whether the limit was hit.
+func ScanWithMaxMatches(scanner BlockScanner, data []byte, scratch *Scratch, n int,
+	handler MatchHandler, context interface{}) (bool, error) {
+	l := WithMaxMatches(n, handler)
+
+	return l.Done(scanner.Scan(data, scratch, l.Handle, context))
+}
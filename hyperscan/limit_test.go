@@ -0,0 +1,44 @@
+package hyperscan_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestMaxMatches(t *testing.T) {
+	Convey("Given a block database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`))
+		So(err, ShouldBeNil)
+
+		n := 0
+		handler := func(id uint, from, to uint64, flags uint, context interface{}) error {
+			n++
+
+			return nil
+		}
+
+		Convey("When the matches exceed the limit", func() {
+			limited, err := hyperscan.ScanWithMaxMatches(bdb, []byte(strings.Repeat("foo", 100)), nil, 3, handler, nil)
+
+			So(err, ShouldBeNil)
+			So(limited, ShouldBeTrue)
+			So(n, ShouldEqual, 3)
+		})
+
+		Convey("When the matches are under the limit", func() {
+			l := hyperscan.WithMaxMatches(3, handler)
+
+			limited, err := l.Done(bdb.Scan([]byte("foo foo"), nil, l.Handle, nil))
+
+			So(err, ShouldBeNil)
+			So(limited, ShouldBeFalse)
+			So(l.Count(), ShouldEqual, 2)
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
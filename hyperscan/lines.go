@@ -0,0 +1,133 @@
+package hyperscan
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// LineMatchHandler handles match events with the line number and column of the match start, both are 1-based.
+type LineMatchHandler func(id uint, from, to uint64, line, column int, flags uint, context interface{}) error
+
+// lineTracker tracks the offsets of newlines in the scanned data.
+type lineTracker struct {
+	newlines []uint64 // the offsets of newlines in the window
+	dropped  int      // the number of newlines dropped before the window
+	last     uint64   // the offset after the last dropped newline
+	start    uint64   // the offset of the window start, the lines are tracked from it
+	end      uint64   // the offset of the scanned data end
+}
+
+func (t *lineTracker) feed(data []byte) {
+	for off := 0; ; {
+		i := bytes.IndexByte(data[off:], '\n')
+		if i < 0 {
+			break
+		}
+
+		t.newlines = append(t.newlines, t.end+uint64(off+i))
+		off += i + 1
+	}
+
+	t.end += uint64(len(data))
+}
+
+// position returns the line number and column of the offset.
+func (t *lineTracker) position(off uint64) (line, column int) {
+	i := sort.Search(len(t.newlines), func(i int) bool { return t.newlines[i] >= off })
+
+	start := t.last
+	if i > 0 {
+		start = t.newlines[i-1] + 1
+	}
+
+	return t.dropped + i + 1, int(off-start) + 1
+}
+
+// trim drops the newlines before the window, which are not needed for the following matches.
+func (t *lineTracker) trim(window uint64) {
+	if t.end <= window {
+		return
+	}
+
+	t.start = t.end - window
+
+	i := sort.Search(len(t.newlines), func(i int) bool { return t.newlines[i] >= t.start })
+	if i == 0 {
+		return
+	}
+
+	t.dropped += i
+	t.last = t.newlines[i-1] + 1
+	t.newlines = t.newlines[:copy(t.newlines, t.newlines[i:])]
+}
+
+// WithLines returns a handler which passes the matches in the data with the line number and column to the handler.
+//
+// The patterns should be compiled with SomLeftMost to locate the start of matches.
+func WithLines(data []byte, handler LineMatchHandler) MatchHandler {
+	t := &lineTracker{}
+	t.feed(data)
+
+	return func(id uint, from, to uint64, flags uint, context interface{}) error {
+		line, column := t.position(from)
+
+		return handler(id, from, to, line, column, flags, context)
+	}
+}
+
+// LineStream is a stream which passes the matches with the line number and column to the handler,
+// the lines are counted across the chunk boundaries.
+//
+// Only the newlines in the last window bytes are kept, the start of a longer match is located at the window start.
+type LineStream struct {
+	stream  Stream
+	handler LineMatchHandler
+	window  uint64
+	lines   lineTracker
+}
+
+// NewLineStream opens a stream which passes the matches with the line number and column to the handler.
+func NewLineStream(db StreamDatabase, flags ScanFlag, scratch *Scratch, window int,
+	handler LineMatchHandler, context interface{}) (*LineStream, error) {
+	s := &LineStream{handler: handler, window: uint64(window)}
+
+	stream, err := db.Open(flags, scratch, s.handle, context)
+	if err != nil {
+		return nil, fmt.Errorf("open stream, %w", err)
+	}
+
+	s.stream = stream
+
+	return s, nil
+}
+
+func (s *LineStream) handle(id uint, from, to uint64, flags uint, context interface{}) error {
+	start := from
+	if start < s.lines.start {
+		start = s.lines.start
+	}
+
+	line, column := s.lines.position(start)
+
+	return s.handler(id, from, to, line, column, flags, context)
+}
+
+// Scan the chunk of data.
+func (s *LineStream) Scan(data []byte) error {
+	s.lines.feed(data)
+
+	if err := s.stream.Scan(data); err != nil {
+		return err // nolint: wrapcheck
+	}
+
+	s.lines.trim(s.window)
+
+	return nil
+}
+
+// Lines returns the number of newlines scanned.
+func (s *LineStream) Lines() int { return s.lines.dropped + len(s.lines.newlines) }
+
+// Close the stream.
+func (s *LineStream) Close() error { return s.stream.Close() } // nolint: wrapcheck
@@ -0,0 +1,74 @@
+package hyperscan_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestLines(t *testing.T) {
+	var matches [][]int
+
+	handler := func(id uint, from, to uint64, line, column int, flags uint, context interface{}) error {
+		matches = append(matches, []int{int(from), line, column})
+
+		return nil
+	}
+
+	Convey("Given a block database", t, func() {
+		matches = nil
+
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		Convey("When scan the data with line numbers", func() {
+			data := []byte("foo\nbar\n  foo\n\nxfoo")
+
+			So(bdb.Scan(data, nil, hyperscan.WithLines(data, handler), nil), ShouldBeNil)
+
+			So(matches, ShouldResemble, [][]int{{0, 1, 1}, {10, 3, 3}, {16, 5, 2}})
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+
+	Convey("Given a stream database", t, func() {
+		matches = nil
+
+		sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`foo`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		Convey("When scan the chunks with line numbers", func() {
+			s, err := hyperscan.NewLineStream(sdb, 0, nil, 8, handler, nil)
+			So(err, ShouldBeNil)
+
+			for _, chunk := range []string{"foo\nba", "r\n  f", "oo\n", "\n", "xfoo"} {
+				So(s.Scan([]byte(chunk)), ShouldBeNil)
+			}
+
+			So(s.Lines(), ShouldEqual, 4)
+			So(s.Close(), ShouldBeNil)
+
+			Convey("Then the lines are counted across the chunk boundaries", func() {
+				So(matches, ShouldResemble, [][]int{{0, 1, 1}, {10, 3, 3}, {16, 5, 2}})
+			})
+		})
+
+		Convey("When scan a chunk larger than the window", func() {
+			s, err := hyperscan.NewLineStream(sdb, 0, nil, 4, handler, nil)
+			So(err, ShouldBeNil)
+
+			So(s.Scan([]byte("foo\nbar\n  foo\n\nxfoo")), ShouldBeNil)
+			So(s.Scan([]byte("\nfoo")), ShouldBeNil)
+			So(s.Close(), ShouldBeNil)
+
+			Convey("Then the lines in the chunk are located", func() {
+				So(matches, ShouldResemble, [][]int{{0, 1, 1}, {10, 3, 3}, {16, 5, 2}, {20, 6, 1}})
+			})
+		})
+
+		So(sdb.Close(), ShouldBeNil)
+	})
+}
@@ -0,0 +1,198 @@
+package hyperscan
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Diagnostic describes a construct in the expression which isn't supported by Hyperscan.
+type Diagnostic struct {
+	Pos       int    // The byte offset of the construct in the expression.
+	Construct string // The text of the construct.
+	Message   string // A human-readable message describing the construct.
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d: `%s` %s", d.Pos, d.Construct, d.Message)
+}
+
+var (
+	boundedRepeat = regexp.MustCompile(`^\{\d+(,\d*)?\}`)
+
+	unsupportedGroups = []struct {
+		prefix  string
+		message string
+	}{
+		{"(?<=", "lookbehind assertion is not supported"},
+		{"(?<!", "lookbehind assertion is not supported"},
+		{"(?=", "lookahead assertion is not supported"},
+		{"(?!", "lookahead assertion is not supported"},
+		{"(?>", "atomic group is not supported"},
+		{"(?(", "conditional subpattern is not supported"},
+		{"(?C", "callout is not supported"},
+		{"(?R", "recursion is not supported"},
+		{"(?&", "subroutine reference is not supported"},
+		{"(?P>", "subroutine reference is not supported"},
+		{"(?P=", "back reference is not supported"},
+		{"(*", "backtracking control verb is not supported"},
+	}
+
+	supportedVerbs = []string{"(*UTF8)", "(*UTF)", "(*UCP)"}
+
+	unsupportedEscapes = map[byte]string{
+		'C': "single code unit matching is not supported",
+		'R': "newline sequence is not supported",
+		'X': "extended grapheme cluster is not supported",
+		'K': "match start reset is not supported",
+		'G': "first matching position assertion is not supported",
+		'k': "back reference is not supported",
+		'g': "back reference is not supported",
+	}
+)
+
+// Lint checks the expression against the constructs which aren't supported by Hyperscan,
+// such as back references, lookaround assertions, atomic groups and possessive quantifiers,
+// without compiling the expression.
+//
+// nolint: gocyclo,cyclop,funlen,gocognit
+func Lint(expr string) (diags []Diagnostic) {
+	report := func(pos, n int, msg string) {
+		diags = append(diags, Diagnostic{pos, expr[pos : pos+n], msg})
+	}
+
+	possessive := func(i int) {
+		if i < len(expr) && expr[i] == '+' {
+			report(i-1, 2, "possessive quantifier is not supported")
+		}
+	}
+
+	for i := 0; i < len(expr); i++ {
+		switch c := expr[i]; c {
+		case '\\':
+			if i+1 >= len(expr) {
+				return
+			}
+
+			e := expr[i+1]
+
+			switch {
+			case e >= '1' && e <= '9':
+				n := 2
+				for i+n < len(expr) && expr[i+n] >= '0' && expr[i+n] <= '9' {
+					n++
+				}
+
+				report(i, n, "back reference is not supported")
+			case unsupportedEscapes[e] != "":
+				report(i, 2, unsupportedEscapes[e])
+			case e == 'Q':
+				if n := strings.Index(expr[i+2:], `\E`); n >= 0 {
+					i += n + 3
+
+					continue
+				}
+
+				return
+			case strings.IndexByte("xpPoN", e) >= 0 && i+2 < len(expr) && expr[i+2] == '{':
+				if n := strings.IndexByte(expr[i+2:], '}'); n >= 0 {
+					i += n + 2
+
+					continue
+				}
+			}
+
+			i++
+
+		case '[':
+			i = skipClass(expr, i)
+
+		case '(':
+			if n := supportedVerb(expr[i:]); n > 0 {
+				i += n - 1
+
+				continue
+			}
+
+			for _, g := range unsupportedGroups {
+				if strings.HasPrefix(expr[i:], g.prefix) {
+					report(i, len(g.prefix), g.message)
+
+					break
+				}
+			}
+
+			if strings.HasPrefix(expr[i:], "(?") && i+2 < len(expr) {
+				if d := expr[i+2]; d >= '0' && d <= '9' || (d == '+' || d == '-') && i+3 < len(expr) &&
+					expr[i+3] >= '0' && expr[i+3] <= '9' {
+					report(i, 3, "subroutine reference is not supported")
+				}
+			}
+
+			if strings.HasPrefix(expr[i:], "(?") {
+				i++
+			}
+
+		case '*', '+', '?':
+			possessive(i + 1)
+
+			if i+1 < len(expr) && (expr[i+1] == '+' || expr[i+1] == '?') {
+				i++
+			}
+
+		case '{':
+			if loc := boundedRepeat.FindStringIndex(expr[i:]); loc != nil {
+				i += loc[1] - 1
+
+				possessive(i + 1)
+
+				if i+1 < len(expr) && (expr[i+1] == '+' || expr[i+1] == '?') {
+					i++
+				}
+			}
+		}
+	}
+
+	return diags
+}
+
+// supportedVerb returns the length of the control verb supported by Hyperscan at the start of the expression.
+func supportedVerb(expr string) int {
+	for _, v := range supportedVerbs {
+		if strings.HasPrefix(expr, v) {
+			return len(v)
+		}
+	}
+
+	return 0
+}
+
+// skipClass returns the offset of `]` which closes the character class starts at the offset.
+func skipClass(expr string, start int) int {
+	i := start + 1
+
+	if i < len(expr) && expr[i] == '^' {
+		i++
+	}
+
+	if i < len(expr) && expr[i] == ']' {
+		i++
+	}
+
+	for ; i < len(expr); i++ {
+		switch expr[i] {
+		case '\\':
+			i++
+		case '[':
+			if i+1 < len(expr) && expr[i+1] == ':' {
+				if n := strings.Index(expr[i:], ":]"); n >= 0 {
+					i += n + 1
+				}
+			}
+		case ']':
+			return i
+		}
+	}
+
+	return i
+}
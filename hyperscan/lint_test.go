@@ -0,0 +1,59 @@
+package hyperscan_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestLint(t *testing.T) {
+	Convey("Given some supported expressions", t, func() {
+		for _, expr := range []string{
+			`foo\d+bar`,
+			`(?i)foo(?:bar|baz)*?`,
+			`[\]\\(?=][[:alpha:]]+`,
+			`\x{41}+a{2,3}?`,
+			`\Q(?=)\1\E`,
+			`(?<name>foo)`,
+			`(*UTF8)(*UCP)\w+`,
+			`(*UTF)a`,
+		} {
+			Convey("When lint "+expr, func() {
+				So(hyperscan.Lint(expr), ShouldBeEmpty)
+			})
+		}
+	})
+
+	Convey("Given some unsupported expressions", t, func() {
+		cases := []struct {
+			expr      string
+			pos       int
+			construct string
+		}{
+			{`(\w)\1`, 4, `\1`},
+			{`(?<n>a)\k<n>`, 7, `\k`},
+			{`foo(?=bar)`, 3, `(?=`},
+			{`(?<!foo)bar`, 0, `(?<!`},
+			{`(?>a+)b`, 0, `(?>`},
+			{`a++b`, 1, `++`},
+			{`a{2,3}+`, 5, `}+`},
+			{`(a|(?1))`, 3, `(?1`},
+			{`\R`, 0, `\R`},
+			{`(*PRUNE)a`, 0, `(*`},
+			{`(*UTF8)(*SKIP)a`, 7, `(*`},
+		}
+
+		for _, c := range cases {
+			Convey("When lint "+c.expr, func() {
+				diags := hyperscan.Lint(c.expr)
+
+				So(diags, ShouldHaveLength, 1)
+				So(diags[0].Pos, ShouldEqual, c.pos)
+				So(diags[0].Construct, ShouldEqual, c.construct)
+				So(diags[0].Message, ShouldContainSubstring, "not supported")
+			})
+		}
+	})
+}
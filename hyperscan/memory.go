@@ -0,0 +1,200 @@
+package hyperscan
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+// MemStats is the memory allocated by Hyperscan in bytes, since the memory accounting enabled.
+type MemStats struct {
+	Database int64 // The memory of compiled pattern databases.
+	Scratch  int64 // The memory of scratch spaces.
+	Stream   int64 // The memory of stream states.
+	Misc     int64 // The memory of miscellaneous data, such as the compile errors.
+}
+
+// Total returns the total memory allocated by Hyperscan.
+func (s MemStats) Total() int64 { return s.Database + s.Scratch + s.Stream + s.Misc }
+
+type memKind int
+
+const (
+	memDatabase memKind = iota
+	memScratch
+	memStream
+	memMisc
+	memKinds
+)
+
+var memAccount struct {
+	sync.Mutex
+	enabled bool
+	limit   int64
+	total   int64
+	usage   [memKinds]int64
+	allocs  map[unsafe.Pointer]memAlloc
+	restore [memKinds]func() error // clears the allocators installed by enabling the memory accounting.
+}
+
+type memAlloc struct {
+	kind memKind
+	size int64
+}
+
+// EnableMemoryAccounting tracks the memory allocated by Hyperscan with the current allocators.
+//
+// It must be enabled before creating any database, scratch space or stream,
+// and it stops tracking once an allocator cleared.
+func EnableMemoryAccounting() error {
+	memAccount.Lock()
+	defer memAccount.Unlock()
+
+	if memAccount.enabled {
+		return nil
+	}
+
+	if memAccount.allocs == nil {
+		memAccount.allocs = make(map[unsafe.Pointer]memAlloc)
+	}
+
+	for _, a := range []struct {
+		kind      memKind
+		set       func(hsAllocFunc, hsFreeFunc) error
+		clear     func() error
+		allocator *hsAllocator
+	}{
+		{memDatabase, hsSetDatabaseAllocator, hsClearDatabaseAllocator, &dbAllocator},
+		{memMisc, hsSetMiscAllocator, hsClearMiscAllocator, &miscAllocator},
+		{memScratch, hsSetScratchAllocator, hsClearScratchAllocator, &scratchAllocator},
+		{memStream, hsSetStreamAllocator, hsClearStreamAllocator, &streamAllocator},
+	} {
+		// Only the default allocators are restored, the allocators set before are kept.
+		if a.allocator.Alloc == nil {
+			memAccount.restore[a.kind] = a.clear
+		}
+
+		if err := a.set(a.allocator.Alloc, a.allocator.Free); err != nil {
+			return fmt.Errorf("enable memory accounting, %w", err)
+		}
+	}
+
+	memAccount.enabled = true
+
+	return nil
+}
+
+// DisableMemoryAccounting stops tracking the memory allocated by Hyperscan,
+// and restores the default allocators replaced by enabling it.
+//
+// It returns `ErrInvalid` while the memory allocated with the default allocators since enabled is not freed,
+// and it is not safe to disable it while other goroutines use Hyperscan.
+func DisableMemoryAccounting() error {
+	memAccount.Lock()
+
+	if !memAccount.enabled {
+		memAccount.Unlock()
+
+		return nil
+	}
+
+	restore := memAccount.restore
+
+	for kind, clear := range restore {
+		if clear != nil && memAccount.usage[kind] > 0 {
+			n := memAccount.usage[kind]
+			memAccount.Unlock()
+
+			return fmt.Errorf("disable memory accounting, %d bytes allocated, %w", n, ErrInvalid)
+		}
+	}
+
+	memAccount.enabled = false
+	memAccount.restore = [memKinds]func() error{}
+	memAccount.Unlock()
+
+	// The scratch allocator is cleared with its own lock held, which is taken before the memory accounting.
+	for _, clear := range restore {
+		if clear == nil {
+			continue
+		}
+
+		if err := clear(); err != nil {
+			return fmt.Errorf("disable memory accounting, %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SetMemoryLimit sets the hard cap of the total memory allocated by Hyperscan when the memory accounting enabled,
+// the allocation exceeding it fails with `ErrNoMemory`, or a compile error when compiling, zero means unlimited.
+func SetMemoryLimit(limit int64) {
+	memAccount.Lock()
+	memAccount.limit = limit
+	memAccount.Unlock()
+}
+
+// MemoryStats returns the memory currently allocated by Hyperscan.
+func MemoryStats() MemStats {
+	memAccount.Lock()
+	defer memAccount.Unlock()
+
+	return MemStats{
+		Database: memAccount.usage[memDatabase],
+		Scratch:  memAccount.usage[memScratch],
+		Stream:   memAccount.usage[memStream],
+		Misc:     memAccount.usage[memMisc],
+	}
+}
+
+func accountAlloc(kind memKind, alloc hsAllocFunc, size uint) unsafe.Pointer {
+	if alloc == nil {
+		alloc = hsDefaultAlloc
+	}
+
+	memAccount.Lock()
+	enabled := memAccount.enabled
+	if enabled {
+		if memAccount.limit > 0 && memAccount.total+int64(size) > memAccount.limit {
+			memAccount.Unlock()
+
+			return nil
+		}
+
+		// Reserve the memory before allocating it.
+		memAccount.total += int64(size)
+	}
+	memAccount.Unlock()
+
+	ptr := alloc(size)
+
+	if enabled {
+		memAccount.Lock()
+		if ptr == nil {
+			memAccount.total -= int64(size)
+		} else {
+			memAccount.allocs[ptr] = memAlloc{kind, int64(size)}
+			memAccount.usage[kind] += int64(size)
+		}
+		memAccount.Unlock()
+	}
+
+	return ptr
+}
+
+func accountFree(free hsFreeFunc, ptr unsafe.Pointer) {
+	if free == nil {
+		free = hsDefaultFree
+	}
+
+	memAccount.Lock()
+	if a, exists := memAccount.allocs[ptr]; exists {
+		delete(memAccount.allocs, ptr)
+		memAccount.usage[a.kind] -= a.size
+		memAccount.total -= a.size
+	}
+	memAccount.Unlock()
+
+	free(ptr)
+}
@@ -0,0 +1,84 @@
+package hyperscan_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestMemoryAccounting(t *testing.T) {
+	Convey("Given the memory accounting enabled", t, func() {
+		So(hyperscan.EnableMemoryAccounting(), ShouldBeNil)
+
+		defer func() {
+			So(hyperscan.DisableMemoryAccounting(), ShouldBeNil)
+		}()
+
+		before := hyperscan.MemoryStats()
+
+		Convey("When compile a database and allocate a scratch", func() {
+			bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo\d+`))
+			So(err, ShouldBeNil)
+
+			s, err := hyperscan.NewScratch(bdb)
+			So(err, ShouldBeNil)
+
+			stats := hyperscan.MemoryStats()
+
+			Convey("Then the memory is accounted", func() {
+				size, err := bdb.Size()
+				So(err, ShouldBeNil)
+				So(stats.Database-before.Database, ShouldBeGreaterThanOrEqualTo, size)
+
+				size, err = s.Size()
+				So(err, ShouldBeNil)
+				So(stats.Scratch-before.Scratch, ShouldBeGreaterThanOrEqualTo, size)
+				So(stats.Total(), ShouldBeGreaterThan, before.Total())
+
+				So(s.Free(), ShouldBeNil)
+				So(bdb.Close(), ShouldBeNil)
+			})
+
+			Convey("Then the memory is released after freed", func() {
+				So(s.Free(), ShouldBeNil)
+				So(bdb.Close(), ShouldBeNil)
+
+				So(hyperscan.MemoryStats().Scratch, ShouldEqual, before.Scratch)
+				So(hyperscan.MemoryStats().Database, ShouldEqual, before.Database)
+			})
+		})
+
+		Convey("When the memory limit is exceeded", func() {
+			bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo\d+`))
+			So(err, ShouldBeNil)
+
+			hyperscan.SetMemoryLimit(hyperscan.MemoryStats().Total() + 16)
+
+			_, err = hyperscan.NewScratch(bdb)
+
+			hyperscan.SetMemoryLimit(0)
+
+			Convey("Then the allocation fails", func() {
+				So(errors.Is(err, hyperscan.ErrNoMemory), ShouldBeTrue)
+			})
+
+			So(bdb.Close(), ShouldBeNil)
+		})
+
+		Convey("When disable it with the memory allocated", func() {
+			bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo\d+`))
+			So(err, ShouldBeNil)
+
+			err = hyperscan.DisableMemoryAccounting()
+
+			Convey("Then it is rejected until the memory freed", func() {
+				So(errors.Is(err, hyperscan.ErrInvalid), ShouldBeTrue)
+
+				So(bdb.Close(), ShouldBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,132 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package hyperscan
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// MapDatabase deserializes the database into an anonymous memory-mapped region,
+// which will be unmapped when the database is closed.
+//
+// The mapped database can't be unmarshaled in place, use `ReadFrom` to replace it instead.
+func MapDatabase(data []byte) (Database, error) {
+	return mapDatabase(data, -1, syscall.MAP_PRIVATE|syscall.MAP_ANON)
+}
+
+// MapDatabaseFile deserializes the database into a shared memory-mapped file,
+// which could be opened by the other processes with `OpenMappedDatabase` to share the page cache.
+//
+// The file is only valid for the same version of Hyperscan on the hosts with the same platform.
+func MapDatabaseFile(data []byte, path string) (Database, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open database file, %w", err)
+	}
+
+	defer f.Close()
+
+	db, err := mapDatabase(data, int(f.Fd()), syscall.MAP_SHARED)
+	if err != nil {
+		os.Remove(path)
+
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// OpenMappedDatabase maps a database file created by `MapDatabaseFile` in read-only mode,
+// the memory pages are shared with the other processes which opened the same file,
+// so the database can't be unmarshaled in place.
+func OpenMappedDatabase(path string) (Database, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open database file, %w", err)
+	}
+
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat database file, %w", err)
+	}
+
+	if fi.Size() == 0 {
+		return nil, fmt.Errorf("empty database file, %w", ErrInvalid)
+	}
+
+	mem, err := syscall.Mmap(int(f.Fd()), 0, int(fi.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("map database file, %w", err)
+	}
+
+	return newMappedDatabase(mem)
+}
+
+func mapDatabase(data []byte, fd, flags int) (Database, error) {
+	data, err := decompress(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = compatiblePlatform(data); err != nil {
+		return nil, err
+	}
+
+	size, err := hsSerializedDatabaseSize(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if fd >= 0 {
+		if err = syscall.Ftruncate(fd, int64(size)); err != nil {
+			return nil, fmt.Errorf("truncate database file, %w", err)
+		}
+	}
+
+	// The mapped region is aligned to the page, which satisfies the 8 bytes alignment required by Hyperscan.
+	mem, err := syscall.Mmap(fd, 0, size, syscall.PROT_READ|syscall.PROT_WRITE, flags)
+	if err != nil {
+		return nil, fmt.Errorf("map memory, %w", err)
+	}
+
+	if err = hsDeserializeDatabaseAt(data, hsDatabaseAt(mem)); err != nil {
+		_ = syscall.Munmap(mem)
+
+		return nil, err
+	}
+
+	return newMappedDatabase(mem)
+}
+
+func newMappedDatabase(mem []byte) (Database, error) {
+	db := hsDatabaseAt(mem)
+
+	mode, err := databaseMode(db)
+	if err != nil {
+		_ = syscall.Munmap(mem)
+
+		return nil, err
+	}
+
+	d, err := newDatabase(db, mode)
+	if err != nil {
+		_ = syscall.Munmap(mem)
+
+		return nil, err
+	}
+
+	d.(baseDatabaser).base().release = func() error {
+		if err := syscall.Munmap(mem); err != nil {
+			return fmt.Errorf("unmap database, %w", err)
+		}
+
+		return nil
+	}
+
+	return d, nil
+}
@@ -0,0 +1,73 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package hyperscan_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestMappedDatabase(t *testing.T) {
+	Convey("Given a serialized block database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo\d+`), hyperscan.NewPattern(`bar`))
+
+		So(err, ShouldBeNil)
+
+		data, err := bdb.Marshal()
+
+		So(err, ShouldBeNil)
+		So(bdb.Close(), ShouldBeNil)
+
+		Convey("When map it to the anonymous memory", func() {
+			db, err := hyperscan.MapDatabase(data)
+
+			So(err, ShouldBeNil)
+
+			mapped, ok := db.(hyperscan.BlockDatabase)
+
+			So(ok, ShouldBeTrue)
+			So(mapped.MatchString("foo123"), ShouldBeTrue)
+			So(mapped.MatchString("baz"), ShouldBeFalse)
+			So(errors.Is(db.Unmarshal(data), hyperscan.ErrInvalid), ShouldBeTrue)
+			So(db.Close(), ShouldBeNil)
+			So(errors.Is(db.Close(), hyperscan.ErrInvalid), ShouldBeTrue)
+		})
+
+		Convey("When map it to a shared file", func() {
+			dir, err := ioutil.TempDir("", "gohs")
+
+			So(err, ShouldBeNil)
+
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "test.hsdb")
+
+			db, err := hyperscan.MapDatabaseFile(data, path)
+
+			So(err, ShouldBeNil)
+			So(db.Close(), ShouldBeNil)
+
+			Convey("Then open the mapped file", func() {
+				db, err := hyperscan.OpenMappedDatabase(path)
+
+				So(err, ShouldBeNil)
+
+				mapped, ok := db.(hyperscan.BlockDatabase)
+
+				So(ok, ShouldBeTrue)
+				So(mapped.MatchString("bar"), ShouldBeTrue)
+				So(errors.Is(db.Unmarshal(data), hyperscan.ErrInvalid), ShouldBeTrue)
+				So(mapped.MatchString("bar"), ShouldBeTrue)
+				So(db.Close(), ShouldBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,169 @@
+package hyperscan
+
+import (
+	"fmt"
+	"sync"
+)
+
+// LabeledMatchHandler handles the match event with the label of the database which reported it.
+type LabeledMatchHandler func(label string, id uint, from, to uint64, flags uint, context interface{}) error
+
+// MultiScanner scans the data across several block databases with the scratch spaces allocated for each of them,
+// and reports the matches with the labels of databases.
+//
+// The databases are owned by the caller, and must not be closed before removed from the scanner.
+type MultiScanner struct {
+	mu      sync.RWMutex
+	targets []*multiTarget
+}
+
+type multiTarget struct {
+	label string
+	db    BlockDatabase
+	scratchList
+}
+
+// NewMultiScanner returns a scanner without any database.
+func NewMultiScanner() *MultiScanner {
+	return &MultiScanner{}
+}
+
+// Add the database with the label, the label must be unique.
+func (m *MultiScanner) Add(label string, db BlockDatabase) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, t := range m.targets {
+		if t.label == label {
+			return fmt.Errorf("database `%s`, %w", label, ErrConflict)
+		}
+	}
+
+	s, err := NewScratch(db)
+	if err != nil {
+		return fmt.Errorf("create scratch, %w", err)
+	}
+
+	m.targets = append(m.targets, &multiTarget{label: label, db: db, scratchList: scratchList{proto: s}})
+
+	return nil
+}
+
+// Remove the database with the label and free its scratch spaces, it returns the removed database.
+func (m *MultiScanner) Remove(label string) (BlockDatabase, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, t := range m.targets {
+		if t.label == label {
+			m.targets = append(m.targets[:i], m.targets[i+1:]...)
+			t.free()
+
+			return t.db, true
+		}
+	}
+
+	return nil, false
+}
+
+// Labels returns the labels of databases in the order they were added.
+func (m *MultiScanner) Labels() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	labels := make([]string, len(m.targets))
+
+	for i, t := range m.targets {
+		labels[i] = t.label
+	}
+
+	return labels
+}
+
+func (t *multiTarget) scan(data []byte, handler LabeledMatchHandler, context interface{}) error {
+	s, err := t.get()
+	if err != nil {
+		return fmt.Errorf("database `%s`, %w", t.label, err)
+	}
+
+	defer t.put(s)
+
+	err = t.db.Scan(data, s, func(id uint, from, to uint64, flags uint, context interface{}) error {
+		return handler(t.label, id, from, to, flags, context)
+	}, context)
+	if err != nil {
+		return fmt.Errorf("database `%s`, %w", t.label, err)
+	}
+
+	return nil
+}
+
+// Scan the data with the databases one by one in the order they were added,
+// it stops at the first database which failed.
+func (m *MultiScanner) Scan(data []byte, handler LabeledMatchHandler, context interface{}) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, t := range m.targets {
+		if err := t.scan(data, handler, context); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ScanParallel scan the data with all the databases concurrently, it returns the first error after all scans done.
+//
+// The calls of handler are serialized, so it needn't be safe for concurrent use.
+func (m *MultiScanner) ScanParallel(data []byte, handler LabeledMatchHandler, context interface{}) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	serialized := func(label string, id uint, from, to uint64, flags uint, context interface{}) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return handler(label, id, from, to, flags, context)
+	}
+
+	for _, t := range m.targets {
+		wg.Add(1)
+
+		go func(t *multiTarget) {
+			defer wg.Done()
+
+			if err := t.scan(data, serialized, context); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(t)
+	}
+
+	wg.Wait()
+
+	return firstErr
+}
+
+// Close frees the scratch spaces and removes all the databases, the databases are not closed.
+func (m *MultiScanner) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, t := range m.targets {
+		t.free()
+	}
+
+	m.targets = nil
+
+	return nil
+}
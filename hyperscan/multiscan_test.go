@@ -0,0 +1,69 @@
+package hyperscan_test
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestMultiScanner(t *testing.T) {
+	Convey("Given a multi scanner with two databases", t, func() {
+		http, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`GET`))
+		So(err, ShouldBeNil)
+
+		dns, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`example\.com`))
+		So(err, ShouldBeNil)
+
+		m := hyperscan.NewMultiScanner()
+
+		So(m.Add("http", http), ShouldBeNil)
+		So(m.Add("dns", dns), ShouldBeNil)
+		So(m.Labels(), ShouldResemble, []string{"http", "dns"})
+
+		Convey("When add a database with a duplicated label", func() {
+			So(errors.Is(m.Add("http", dns), hyperscan.ErrConflict), ShouldBeTrue)
+		})
+
+		var matches []string
+
+		handler := func(label string, id uint, from, to uint64, flags uint, context interface{}) error {
+			matches = append(matches, label)
+
+			return nil
+		}
+
+		Convey("When scan the data", func() {
+			So(m.Scan([]byte("GET http://example.com/"), handler, nil), ShouldBeNil)
+
+			Convey("Then the matches are labeled with databases", func() {
+				So(matches, ShouldResemble, []string{"http", "dns"})
+			})
+		})
+
+		Convey("When scan the data in parallel", func() {
+			So(m.ScanParallel([]byte("GET http://example.com/"), handler, nil), ShouldBeNil)
+
+			sort.Strings(matches)
+
+			Convey("Then the matches are labeled with databases", func() {
+				So(matches, ShouldResemble, []string{"dns", "http"})
+			})
+		})
+
+		Convey("When remove a database", func() {
+			db, ok := m.Remove("http")
+
+			So(ok, ShouldBeTrue)
+			So(db, ShouldEqual, http)
+			So(m.Labels(), ShouldResemble, []string{"dns"})
+		})
+
+		So(m.Close(), ShouldBeNil)
+		So(http.Close(), ShouldBeNil)
+		So(dns.Close(), ShouldBeNil)
+	})
+}
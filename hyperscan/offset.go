@@ -0,0 +1,39 @@
+package hyperscan
+
+// WithOffset returns a handler which reports the matches at the absolute offsets from the base,
+// e.g. the offset of the scanned chunk in the file.
+func WithOffset(base uint64, handler MatchHandler) MatchHandler {
+	return func(id uint, from, to uint64, flags uint, context interface{}) error {
+		return handler(id, base+from, base+to, flags, context)
+	}
+}
+
+// OffsetHandler reports the matches at the absolute offsets from a movable base.
+//
+// It could be used to scan the chunks of a larger object in block mode,
+//
+//	h := hyperscan.NewOffsetHandler(0, handler)
+//	for _, chunk := range chunks {
+//		err := db.Scan(chunk, scratch, h.Handle, nil)
+//		h.Advance(len(chunk))
+//	}
+//
+// or to scan a stream which starts at the base offset of the object.
+type OffsetHandler struct {
+	// Base is the offset added to the reported matches.
+	Base    uint64
+	handler MatchHandler
+}
+
+// NewOffsetHandler returns a handler which adds the base offset to the reported matches.
+func NewOffsetHandler(base uint64, handler MatchHandler) *OffsetHandler {
+	return &OffsetHandler{Base: base, handler: handler}
+}
+
+// Handle the match event at the offsets relative to the base.
+func (h *OffsetHandler) Handle(id uint, from, to uint64, flags uint, context interface{}) error {
+	return h.handler(id, h.Base+from, h.Base+to, flags, context)
+}
+
+// Advance moves the base offset forward after n bytes were scanned.
+func (h *OffsetHandler) Advance(n int) { h.Base += uint64(n) }
@@ -0,0 +1,45 @@
+package hyperscan_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestOffsetHandler(t *testing.T) {
+	Convey("Given a block database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		var matches [][]uint64
+
+		handler := func(id uint, from, to uint64, flags uint, context interface{}) error {
+			matches = append(matches, []uint64{from, to})
+
+			return nil
+		}
+
+		Convey("When scan the data with a base offset", func() {
+			So(bdb.Scan([]byte("a foo"), nil, hyperscan.WithOffset(100, handler), nil), ShouldBeNil)
+
+			So(matches, ShouldResemble, [][]uint64{{102, 105}})
+		})
+
+		Convey("When scan the chunks with an offset handler", func() {
+			h := hyperscan.NewOffsetHandler(10, handler)
+
+			for _, chunk := range []string{"foo ", "bar foo"} {
+				So(hyperscan.ScanString(bdb, chunk, nil, h.Handle, nil), ShouldBeNil)
+
+				h.Advance(len(chunk))
+			}
+
+			So(matches, ShouldResemble, [][]uint64{{10, 13}, {18, 21}})
+			So(h.Base, ShouldEqual, 21)
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
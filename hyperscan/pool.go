@@ -0,0 +1,162 @@
+package hyperscan
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+type pooledScratch struct {
+	s   *Scratch
+	gen uint64
+}
+
+// ScratchPool hands out the scratch spaces cloned from a prototype allocated for one or more databases,
+// the idle scratch spaces are kept by the pool until it is closed.
+//
+// The scratch spaces are re-sized on demand when a database is added to the pool,
+// and the ones put back after the pool closed are freed.
+//
+//	s, err := pool.Get()
+//	defer pool.Put(s)
+type ScratchPool struct {
+	mu    sync.Mutex
+	dbs   []Database
+	proto *Scratch
+	gen   uint64
+	idle  []pooledScratch
+	gens  map[*Scratch]uint64 // the generations of the scratch spaces handed out.
+}
+
+// NewScratchPool returns a pool of scratch spaces for the databases.
+func NewScratchPool(dbs ...Database) (*ScratchPool, error) {
+	if len(dbs) == 0 {
+		return nil, fmt.Errorf("no database, %w", ErrInvalid)
+	}
+
+	proto, err := newScratch(dbs[0])
+	if err != nil {
+		return nil, fmt.Errorf("create scratch, %w", err)
+	}
+
+	for _, db := range dbs[1:] {
+		if err = proto.Realloc(db); err != nil {
+			_ = proto.Free()
+
+			return nil, fmt.Errorf("realloc scratch, %w", err)
+		}
+	}
+
+	return &ScratchPool{dbs: dbs, proto: proto, gens: make(map[*Scratch]uint64)}, nil
+}
+
+// AddDatabase grows the scratch spaces of the pool, so they could be used for the database.
+func (p *ScratchPool) AddDatabase(db Database) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.proto == nil {
+		return fmt.Errorf("scratch pool closed, %w", ErrInvalid)
+	}
+
+	if err := p.proto.Realloc(db); err != nil {
+		return fmt.Errorf("realloc scratch, %w", err)
+	}
+
+	p.dbs = append(p.dbs, db)
+	p.gen++
+
+	return nil
+}
+
+// Get returns a scratch space from the pool, or clones a new one from the prototype.
+func (p *ScratchPool) Get() (*Scratch, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.proto == nil {
+		return nil, fmt.Errorf("scratch pool closed, %w", ErrInvalid)
+	}
+
+	for n := len(p.idle); n > 0; n = len(p.idle) {
+		e := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+
+		if e.gen == p.gen || p.resize(e.s) == nil {
+			p.gens[e.s] = p.gen
+
+			return e.s, nil
+		}
+	}
+
+	s, err := hsCloneScratch(p.proto.s)
+	if err != nil {
+		return nil, fmt.Errorf("clone scratch, %w", err)
+	}
+
+	scratch := trackScratch(&Scratch{s})
+
+	p.gens[scratch] = p.gen
+
+	return scratch, nil
+}
+
+// resize reallocates the scratch for all databases of the pool, it frees the scratch if failed.
+func (p *ScratchPool) resize(s *Scratch) error {
+	for _, db := range p.dbs {
+		if err := s.Realloc(db); err != nil {
+			runtime.SetFinalizer(s, nil)
+			_ = s.Free()
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Put returns the scratch space got from the pool, it is freed if the pool has been closed.
+func (p *ScratchPool) Put(s *Scratch) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	gen, ok := p.gens[s]
+	if !ok {
+		return
+	}
+
+	delete(p.gens, s)
+
+	if p.proto == nil {
+		_ = s.Free()
+
+		return
+	}
+
+	p.idle = append(p.idle, pooledScratch{s, gen})
+}
+
+// Close frees the prototype and the idle scratch spaces,
+// the scratch spaces handed out are freed when they are put back.
+func (p *ScratchPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.proto == nil {
+		return nil
+	}
+
+	err := p.proto.Free()
+
+	for _, idle := range p.idle {
+		if e := idle.s.Free(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	p.proto = nil
+	p.dbs = nil
+	p.idle = nil
+
+	return err
+}
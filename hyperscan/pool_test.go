@@ -0,0 +1,85 @@
+package hyperscan_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestScratchPool(t *testing.T) {
+	Convey("Given a scratch pool for a block database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`, 0))
+		So(err, ShouldBeNil)
+
+		pool, err := hyperscan.NewScratchPool(bdb)
+		So(err, ShouldBeNil)
+
+		Convey("When scan concurrently with the scratch spaces from the pool", func() {
+			var wg sync.WaitGroup
+
+			errs := make(chan error, 8)
+
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					s, err := pool.Get()
+					if err != nil {
+						errs <- err
+
+						return
+					}
+
+					defer pool.Put(s)
+
+					errs <- bdb.Scan([]byte("foo"), s, func(id uint, from, to uint64, flags uint,
+						context interface{}) error {
+						return nil
+					}, nil)
+				}()
+			}
+
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				So(err, ShouldBeNil)
+			}
+		})
+
+		Convey("When add a larger database to the pool", func() {
+			sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`foo\d+bar`, 0))
+			So(err, ShouldBeNil)
+
+			s, err := pool.Get()
+			So(err, ShouldBeNil)
+			pool.Put(s)
+
+			So(pool.AddDatabase(sdb), ShouldBeNil)
+
+			Convey("Then the scratch spaces could be used for it", func() {
+				s, err := pool.Get()
+				So(err, ShouldBeNil)
+
+				So(sdb.Scan(strings.NewReader("foo123bar"), s, func(id uint, from, to uint64, flags uint,
+					context interface{}) error {
+					return nil
+				}, nil), ShouldBeNil)
+
+				pool.Put(s)
+			})
+
+			So(pool.Close(), ShouldBeNil)
+			So(sdb.Close(), ShouldBeNil)
+		})
+
+		So(pool.Close(), ShouldBeNil)
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
@@ -0,0 +1,209 @@
+package hyperscan
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Verifier confirms the candidate match reported by a prefilter database.
+type Verifier interface {
+	// Verify reports whether the pattern matches the data and the match ends at the offset `to`.
+	Verify(data []byte, id uint, from, to uint64) bool
+}
+
+// VerifierFunc is an adapter to allow the use of ordinary functions as verifier.
+type VerifierFunc func(data []byte, id uint, from, to uint64) bool
+
+// Verify calls f(data, id, from, to).
+func (f VerifierFunc) Verify(data []byte, id uint, from, to uint64) bool {
+	return f(data, id, from, to)
+}
+
+// RegexpVerifier confirms the candidate matches with the Go regular expressions of the pattern IDs.
+//
+// A candidate is confirmed if any match of the regular expression ends at its offset, including the overlapping ones,
+// the candidate of the pattern without a regular expression is always confirmed.
+type RegexpVerifier struct {
+	anchored map[uint]*regexp.Regexp // the regular expressions anchored at the end of text.
+}
+
+// NewRegexpVerifier returns a verifier with the Go regular expressions of the pattern IDs.
+func NewRegexpVerifier(exprs map[uint]*regexp.Regexp) (*RegexpVerifier, error) {
+	v := &RegexpVerifier{make(map[uint]*regexp.Regexp, len(exprs))}
+
+	for id, re := range exprs {
+		if err := v.add(id, re); err != nil {
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
+
+func (v *RegexpVerifier) add(id uint, re *regexp.Regexp) error {
+	anchored, err := regexp.Compile(`(?:` + re.String() + `)\z`)
+	if err != nil {
+		return fmt.Errorf("anchor regexp `%s`, %w", re, err)
+	}
+
+	v.anchored[id] = anchored
+
+	return nil
+}
+
+// Verify reports whether the regular expression of the pattern has a match ends at the offset `to`.
+func (v *RegexpVerifier) Verify(data []byte, id uint, from, to uint64) bool {
+	re, ok := v.anchored[id]
+	if !ok {
+		return true
+	}
+
+	if to > uint64(len(data)) {
+		return false
+	}
+
+	return re.Match(data[:to])
+}
+
+// PrefilterDatabase is a block database compiled in the prefilter mode,
+// its candidate matches are confirmed by the verifier before reporting to the handler.
+//
+// The prefilter mode allows Hyperscan to compile the patterns with unsupported constructs
+// (e.g. back references or lookaround assertions), but it may report the false positive matches.
+type PrefilterDatabase interface {
+	Database
+	BlockScanner
+}
+
+type prefilterDatabase struct {
+	*blockScanner
+	verifier    Verifier
+	prefiltered map[uint]bool // the IDs of patterns to verify, or all of them if nil.
+}
+
+// NewPrefilterDatabase compiles the patterns in the prefilter mode,
+// and confirms the candidate matches with the verifier.
+func NewPrefilterDatabase(verifier Verifier, patterns ...*Pattern) (PrefilterDatabase, error) {
+	prefiltered := make(Patterns, len(patterns))
+
+	for i, p := range patterns {
+		cloned := *p
+		cloned.Flags |= PrefilterMode
+		cloned.info = nil
+		prefiltered[i] = &cloned
+	}
+
+	return newPrefilterDatabase(verifier, prefiltered)
+}
+
+func newPrefilterDatabase(verifier Verifier, patterns Patterns) (PrefilterDatabase, error) {
+	db, err := hsCompileMulti(patterns, BlockMode, nil)
+	if err != nil {
+		return nil, fmt.Errorf("compile prefilter database, %w", err)
+	}
+
+	return &prefilterDatabase{newBlockScanner(newBaseDatabase(db)), verifier, nil}, nil
+}
+
+// CompileHybrid compiles the patterns to a block database,
+// the expressions rejected by Hyperscan are compiled in the prefilter mode,
+// and their candidate matches are confirmed by the verifier.
+//
+// The Go regular expressions of the prefiltered patterns are used if the verifier is nil,
+// which don't support the back references or lookaround assertions forcing most expressions to be prefiltered,
+// so a verifier should be given for them.
+//
+// The patterns are compiled together, and the one reported by the compile error is switched
+// to the prefilter mode before retrying, until the database is built or the error isn't recoverable.
+//
+// The ID of prefiltered pattern must be unique, and it doesn't report the start of match.
+func CompileHybrid(verifier Verifier, patterns ...*Pattern) (PrefilterDatabase, error) {
+	hybrid := append(Patterns(nil), patterns...)
+	prefiltered := make(map[uint]bool)
+	ids := make(map[int]int)
+
+	var regexps *RegexpVerifier
+
+	if verifier == nil {
+		regexps = &RegexpVerifier{make(map[uint]*regexp.Regexp)}
+		verifier = regexps
+	}
+
+	for _, p := range patterns {
+		ids[p.Id]++
+	}
+
+	for {
+		db, err := hsCompileMulti(hybrid, BlockMode, nil)
+		if err == nil {
+			return &prefilterDatabase{newBlockScanner(newBaseDatabase(db)), verifier, prefiltered}, nil
+		}
+
+		var compileErr *CompileError
+
+		if !errors.As(err, &compileErr) || compileErr.Index < 0 || compileErr.Index >= len(hybrid) ||
+			hybrid[compileErr.Index].Flags&PrefilterMode == PrefilterMode {
+			return nil, fmt.Errorf("compile hybrid database, %w", err)
+		}
+
+		p := hybrid[compileErr.Index]
+
+		if ids[p.Id] > 1 {
+			return nil, fmt.Errorf("prefiltered pattern id %d, %w", p.Id, ErrConflict)
+		}
+
+		if regexps != nil {
+			re, err := goRegexp(p)
+			if err != nil {
+				return nil, fmt.Errorf("pattern `%s` is not supported by Go regexp, %w", p.Expression, err)
+			}
+
+			if err = regexps.add(uint(p.Id), re); err != nil {
+				return nil, err
+			}
+		}
+
+		cloned := *p
+		cloned.Flags = (p.Flags | PrefilterMode) &^ SomLeftMost
+		cloned.info = nil
+
+		prefiltered[uint(p.Id)] = true
+		hybrid[compileErr.Index] = &cloned
+	}
+}
+
+// goRegexp compiles the expression of pattern with the flags as a Go regular expression.
+func goRegexp(p *Pattern) (*regexp.Regexp, error) {
+	var flags string
+
+	if p.Flags&Caseless == Caseless {
+		flags += "i"
+	}
+
+	if p.Flags&DotAll == DotAll {
+		flags += "s"
+	}
+
+	if p.Flags&MultiLine == MultiLine {
+		flags += "m"
+	}
+
+	expr := string(p.Expression)
+
+	if flags != "" {
+		expr = "(?" + flags + ")" + expr
+	}
+
+	return regexp.Compile(expr) // nolint: wrapcheck
+}
+
+func (db *prefilterDatabase) Scan(data []byte, s *Scratch, handler MatchHandler, context interface{}) error {
+	return db.blockScanner.Scan(data, s, func(id uint, from, to uint64, flags uint, context interface{}) error {
+		if (db.prefiltered == nil || db.prefiltered[id]) && !db.verifier.Verify(data, id, from, to) {
+			return nil
+		}
+
+		return handler(id, from, to, flags, context)
+	}, context)
+}
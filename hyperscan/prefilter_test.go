@@ -0,0 +1,199 @@
+package hyperscan_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestPrefilterDatabase(t *testing.T) {
+	Convey("Given a prefilter database with back reference", t, func() {
+		p := hyperscan.NewPattern(`(\w)\1`)
+		p.Id = 1
+
+		verifier := hyperscan.VerifierFunc(func(data []byte, id uint, from, to uint64) bool {
+			return to >= 2 && data[to-1] == data[to-2]
+		})
+
+		db, err := hyperscan.NewPrefilterDatabase(verifier, p)
+
+		So(err, ShouldBeNil)
+		So(db, ShouldNotBeNil)
+
+		Convey("When scan the data", func() {
+			var matched []uint64
+
+			err := db.Scan([]byte("abccd"), nil, func(id uint, from, to uint64, flags uint, context interface{}) error {
+				matched = append(matched, to)
+
+				return nil
+			}, nil)
+
+			So(err, ShouldBeNil)
+			So(matched, ShouldResemble, []uint64{4})
+			So(p.Flags&hyperscan.PrefilterMode, ShouldEqual, 0)
+		})
+
+		So(db.Close(), ShouldBeNil)
+	})
+
+	Convey("Given a prefilter database with regexp verifier", t, func() {
+		p := hyperscan.NewPattern(`foo\d{2}`)
+		p.Id = 1
+
+		verifier, err := hyperscan.NewRegexpVerifier(map[uint]*regexp.Regexp{1: regexp.MustCompile(`foo\d{2}`)})
+
+		So(err, ShouldBeNil)
+
+		db, err := hyperscan.NewPrefilterDatabase(verifier, p)
+
+		So(err, ShouldBeNil)
+
+		Convey("When scan the data", func() {
+			var matched []uint64
+
+			err := db.Scan([]byte("foo1 foo12"), nil, func(id uint, from, to uint64, flags uint, context interface{}) error {
+				matched = append(matched, to)
+
+				return nil
+			}, nil)
+
+			So(err, ShouldBeNil)
+			So(matched, ShouldResemble, []uint64{10})
+		})
+
+		So(db.Close(), ShouldBeNil)
+	})
+
+	Convey("Given a prefilter database with overlapping matches", t, func() {
+		p := hyperscan.NewPattern(`aa`)
+		p.Id = 1
+
+		verifier, err := hyperscan.NewRegexpVerifier(map[uint]*regexp.Regexp{1: regexp.MustCompile(`aa`)})
+
+		So(err, ShouldBeNil)
+
+		db, err := hyperscan.NewPrefilterDatabase(verifier, p)
+
+		So(err, ShouldBeNil)
+
+		Convey("When scan the data", func() {
+			var matched []uint64
+
+			err := db.Scan([]byte("aaa"), nil, func(id uint, from, to uint64, flags uint, context interface{}) error {
+				matched = append(matched, to)
+
+				return nil
+			}, nil)
+
+			Convey("Then the overlapping matches are confirmed", func() {
+				So(err, ShouldBeNil)
+				So(matched, ShouldResemble, []uint64{2, 3})
+			})
+		})
+
+		So(db.Close(), ShouldBeNil)
+	})
+}
+
+func TestCompileHybrid(t *testing.T) {
+	Convey("Given some patterns with an unsupported expression", t, func() {
+		foo := hyperscan.NewPattern(`foo`)
+		foo.Id = 1
+		large := hyperscan.NewPattern(`a(?:\w+\W){1000}b`)
+		large.Id = 2
+
+		Convey("When compile them with the prefilter fallback", func() {
+			db, err := hyperscan.CompileHybrid(nil, foo, large)
+
+			So(err, ShouldBeNil)
+
+			var matched []uint
+
+			err = db.Scan([]byte("foo a-b"), nil, func(id uint, from, to uint64, flags uint, context interface{}) error {
+				matched = append(matched, id)
+
+				return nil
+			}, nil)
+
+			So(err, ShouldBeNil)
+			So(matched, ShouldResemble, []uint{1})
+			So(db.Close(), ShouldBeNil)
+		})
+
+		Convey("When several patterns are rejected", func() {
+			digits := hyperscan.NewPattern(`c(?:\d+\D){1000}d`)
+			digits.Id = 3
+
+			db, err := hyperscan.CompileHybrid(nil, large, foo, digits)
+
+			So(err, ShouldBeNil)
+
+			var matched []uint
+
+			err = db.Scan([]byte("foo c1-d"), nil, func(id uint, from, to uint64, flags uint, context interface{}) error {
+				matched = append(matched, id)
+
+				return nil
+			}, nil)
+
+			So(err, ShouldBeNil)
+			So(matched, ShouldResemble, []uint{1})
+			So(large.Flags&hyperscan.PrefilterMode, ShouldEqual, 0)
+			So(db.Close(), ShouldBeNil)
+		})
+
+		Convey("When the unsupported pattern has a duplicate ID", func() {
+			large.Id = 1
+
+			_, err := hyperscan.CompileHybrid(nil, foo, large)
+
+			So(errors.Is(err, hyperscan.ErrConflict), ShouldBeTrue)
+		})
+
+		Convey("When the expression is not supported by Go regexp", func() {
+			backref := hyperscan.NewPattern(`(a)\1`)
+			backref.Id = 3
+
+			_, err := hyperscan.CompileHybrid(nil, foo, backref)
+
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("When compile a back reference with a verifier", func() {
+			backref := hyperscan.NewPattern(`(\w)\1`)
+			backref.Id = 3
+
+			var verified []uint
+
+			db, err := hyperscan.CompileHybrid(hyperscan.VerifierFunc(func(data []byte, id uint, from, to uint64) bool {
+				verified = append(verified, id)
+
+				return to >= 2 && data[to-1] == data[to-2]
+			}), foo, backref)
+
+			So(err, ShouldBeNil)
+
+			var matched []uint64
+
+			err = db.Scan([]byte("foo abccd"), nil, func(id uint, from, to uint64, flags uint, context interface{}) error {
+				matched = append(matched, uint64(id), to)
+
+				return nil
+			}, nil)
+
+			Convey("Then only the candidates of the prefiltered pattern are verified", func() {
+				So(err, ShouldBeNil)
+				So(matched, ShouldResemble, []uint64{1, 3, 3, 3, 3, 8})
+				So(verified, ShouldNotContain, uint(1))
+				So(verified, ShouldContain, uint(3))
+			})
+
+			So(db.Close(), ShouldBeNil)
+		})
+	})
+}
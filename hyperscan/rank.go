@@ -0,0 +1,76 @@
+package hyperscan
+
+import "sort"
+
+// RankedMatch is a match event with the priority of matched pattern.
+type RankedMatch struct {
+	Id       uint // nolint: golint,revive,stylecheck
+	From, To uint64
+	Priority int
+}
+
+// Ranker collects the match events and ranks them by the priority of patterns.
+//
+// The patterns should be compiled with `SomLeftMost` to resolve the overlapping matches.
+type Ranker struct {
+	priorities map[uint]int
+	matches    []RankedMatch
+}
+
+// Ranker returns a ranker with the priorities of patterns.
+func (p Patterns) Ranker() *Ranker {
+	priorities := make(map[uint]int, len(p))
+
+	for _, pattern := range p {
+		priorities[uint(pattern.Id)] = pattern.Priority
+	}
+
+	return &Ranker{priorities: priorities}
+}
+
+// Handle collects a match event, it could be used as a `MatchHandler`.
+func (r *Ranker) Handle(id uint, from, to uint64, flags uint, context interface{}) error {
+	r.matches = append(r.matches, RankedMatch{id, from, to, r.priorities[id]})
+
+	return nil
+}
+
+// Reset discards the collected matches.
+func (r *Ranker) Reset() { r.matches = r.matches[:0] }
+
+// Sorted returns the collected matches sorted by priority in descending order,
+// the matches with the same priority are kept in the order they were reported.
+func (r *Ranker) Sorted() []RankedMatch {
+	matches := append([]RankedMatch(nil), r.matches...)
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Priority > matches[j].Priority })
+
+	return matches
+}
+
+// Best returns the highest priority match of the overlapping matches in the order of offset,
+// the earlier reported match wins if there are the same priority.
+func (r *Ranker) Best() []RankedMatch {
+	var best []RankedMatch
+
+next:
+	for _, m := range r.Sorted() {
+		for _, b := range best {
+			if m.From < b.To && b.From < m.To {
+				continue next
+			}
+		}
+
+		best = append(best, m)
+	}
+
+	sort.Slice(best, func(i, j int) bool {
+		if best[i].From != best[j].From {
+			return best[i].From < best[j].From
+		}
+
+		return best[i].To < best[j].To
+	})
+
+	return best
+}
@@ -0,0 +1,58 @@
+package hyperscan_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestRanker(t *testing.T) {
+	Convey("Given some patterns with priority", t, func() {
+		patterns := hyperscan.Patterns{
+			{Expression: "foo", Flags: hyperscan.SomLeftMost, Id: 1, Priority: 1},
+			{Expression: "foobar", Flags: hyperscan.SomLeftMost, Id: 2, Priority: 10},
+			{Expression: "bar", Flags: hyperscan.SomLeftMost, Id: 3, Priority: 5},
+		}
+
+		r := patterns.Ranker()
+
+		Convey("When collect the overlapping matches", func() {
+			So(r.Handle(1, 0, 3, 0, nil), ShouldBeNil)
+			So(r.Handle(2, 0, 6, 0, nil), ShouldBeNil)
+			So(r.Handle(3, 3, 6, 0, nil), ShouldBeNil)
+			So(r.Handle(3, 7, 10, 0, nil), ShouldBeNil)
+
+			So(r.Sorted(), ShouldResemble, []hyperscan.RankedMatch{
+				{Id: 2, From: 0, To: 6, Priority: 10},
+				{Id: 3, From: 3, To: 6, Priority: 5},
+				{Id: 3, From: 7, To: 10, Priority: 5},
+				{Id: 1, From: 0, To: 3, Priority: 1},
+			})
+
+			So(r.Best(), ShouldResemble, []hyperscan.RankedMatch{
+				{Id: 2, From: 0, To: 6, Priority: 10},
+				{Id: 3, From: 7, To: 10, Priority: 5},
+			})
+
+			Convey("Then reset the ranker", func() {
+				r.Reset()
+
+				So(r.Best(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When scan with the ranker", func() {
+			db, err := hyperscan.NewBlockDatabase(patterns...)
+
+			So(err, ShouldBeNil)
+			So(db.Scan([]byte("foobar bar"), nil, r.Handle, nil), ShouldBeNil)
+			So(r.Best(), ShouldResemble, []hyperscan.RankedMatch{
+				{Id: 2, From: 0, To: 6, Priority: 10},
+				{Id: 3, From: 7, To: 10, Priority: 5},
+			})
+			So(db.Close(), ShouldBeNil)
+		})
+	})
+}
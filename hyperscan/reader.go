@@ -0,0 +1,107 @@
+package hyperscan
+
+import (
+	"fmt"
+	"io"
+)
+
+// ReaderScanner is the optional interface implemented by the databases
+// which could scan the data read from a reader without loading it fully into memory,
+// such as the block and stream databases compiled by the package.
+type ReaderScanner interface {
+	// ScanReader scans the data read from the reader in chunks of the size, and returns the aggregate information.
+	ScanReader(reader io.Reader, scratch *Scratch, handler MatchHandler, chunkSize int) (ReaderStats, error)
+}
+
+var (
+	_ ReaderScanner = (*blockDatabase)(nil)
+	_ ReaderScanner = (*streamDatabase)(nil)
+)
+
+type derivedStream struct {
+	gen  uint64 // the generation of block database it was compiled from.
+	db   *streamDatabase
+	pool *ScratchPool
+}
+
+func (d *derivedStream) close() {
+	_ = d.pool.Close()
+	_ = d.db.Close()
+}
+
+// streamDatabase returns the stream database compiled from the patterns of block database,
+// it is recompiled if the block database was replaced.
+func (db *blockDatabase) streamDatabase() (*derivedStream, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.derived != nil {
+		if db.derived.gen == db.gen {
+			return db.derived, nil
+		}
+
+		db.derived.close()
+		db.derived = nil
+	}
+
+	if len(db.inventory) == 0 {
+		return nil, fmt.Errorf("patterns of database, %w", ErrNoFound)
+	}
+
+	sdb, err := NewStreamDatabase(db.inventory...)
+	if err != nil {
+		return nil, fmt.Errorf("compile stream database, %w", err)
+	}
+
+	pool, err := NewScratchPool(sdb)
+	if err != nil {
+		_ = sdb.Close()
+
+		return nil, fmt.Errorf("create scratch pool, %w", err)
+	}
+
+	db.derived = &derivedStream{db.gen, sdb.(*streamDatabase), pool}
+
+	return db.derived, nil
+}
+
+// ScanReader scans the data read from the reader in chunks of the size,
+// with a stream database compiled from the same patterns on first use.
+//
+// The stream database is compiled from the patterns the block database was compiled with,
+// so it returns `ErrNoFound` for the unmarshaled or memory-mapped databases, which have no patterns.
+// The scratch is not used, the scratch spaces of the stream database are taken from its own pool.
+// The stream database is freed when the block database is closed.
+func (db *blockDatabase) ScanReader(reader io.Reader, scratch *Scratch, handler MatchHandler,
+	chunkSize int) (ReaderStats, error) {
+	return db.scanReader(reader, scratch, handler, nil, chunkSize)
+}
+
+func (db *blockDatabase) scanReader(reader io.Reader, scratch *Scratch, handler MatchHandler,
+	context interface{}, chunkSize int) (ReaderStats, error) {
+	derived, err := db.streamDatabase()
+	if err != nil {
+		return ReaderStats{}, err
+	}
+
+	s, err := derived.pool.Get()
+	if err != nil {
+		return ReaderStats{}, fmt.Errorf("get scratch, %w", err)
+	}
+
+	defer derived.pool.Put(s)
+
+	return derived.db.scanReader(reader, s, handler, context, chunkSize)
+}
+
+// Close frees the database and the stream database compiled for `ScanReader` and `ScanFile`.
+func (db *blockDatabase) Close() error {
+	db.mu.Lock()
+	if db.derived != nil {
+		db.derived.close()
+		db.derived = nil
+	}
+	db.mu.Unlock()
+
+	return db.blockMatcher.Close()
+}
@@ -0,0 +1,99 @@
+package hyperscan_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestBlockDatabaseScanReader(t *testing.T) {
+	Convey("Given a block database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo\d+`))
+		So(err, ShouldBeNil)
+
+		Convey("When scan a reader", func() {
+			var ends []uint64
+
+			stats, err := bdb.(hyperscan.ReaderScanner).ScanReader(
+				strings.NewReader(strings.Repeat("x", 5000)+"foo123"), nil,
+				func(id uint, from, to uint64, flags uint, context interface{}) error {
+					ends = append(ends, to)
+
+					return nil
+				}, 4096)
+
+			Convey("Then the matches across the chunks are reported", func() {
+				So(err, ShouldBeNil)
+				So(ends, ShouldResemble, []uint64{5004, 5005, 5006})
+				So(stats.Chunks, ShouldEqual, 2)
+			})
+		})
+
+		Convey("When scan a reader with a scratch of the block database", func() {
+			s, err := hyperscan.NewScratch(bdb)
+			So(err, ShouldBeNil)
+
+			size, err := s.Size()
+			So(err, ShouldBeNil)
+
+			stats, err := bdb.(hyperscan.ReaderScanner).ScanReader(strings.NewReader("foo123"), s,
+				func(id uint, from, to uint64, flags uint, context interface{}) error {
+					return nil
+				}, 0)
+
+			Convey("Then the scratch is not reallocated", func() {
+				So(err, ShouldBeNil)
+				So(stats.Matches, ShouldEqual, 3)
+
+				n, err := s.Size()
+				So(err, ShouldBeNil)
+				So(n, ShouldEqual, size)
+			})
+
+			So(s.Free(), ShouldBeNil)
+		})
+
+		Convey("When scan a reader after the database is unmarshaled", func() {
+			_, err := bdb.(hyperscan.ReaderScanner).ScanReader(strings.NewReader("foo123"), nil,
+				func(id uint, from, to uint64, flags uint, context interface{}) error {
+					return nil
+				}, 0)
+			So(err, ShouldBeNil)
+
+			data, err := bdb.Marshal()
+			So(err, ShouldBeNil)
+			So(bdb.Unmarshal(data), ShouldBeNil)
+
+			_, err = bdb.(hyperscan.ReaderScanner).ScanReader(strings.NewReader("foo123"), nil,
+				func(id uint, from, to uint64, flags uint, context interface{}) error {
+					return nil
+				}, 0)
+
+			Convey("Then the stream database compiled from the previous patterns is not used", func() {
+				So(errors.Is(err, hyperscan.ErrNoFound), ShouldBeTrue)
+			})
+		})
+
+		Convey("When scan a reader with an unmarshaled database", func() {
+			data, err := bdb.Marshal()
+			So(err, ShouldBeNil)
+
+			db, err := hyperscan.UnmarshalBlockDatabase(data)
+			So(err, ShouldBeNil)
+
+			_, err = db.(hyperscan.ReaderScanner).ScanReader(strings.NewReader("foo123"), nil,
+				func(id uint, from, to uint64, flags uint, context interface{}) error {
+					return nil
+				}, 0)
+
+			So(errors.Is(err, hyperscan.ErrNoFound), ShouldBeTrue)
+			So(db.Close(), ShouldBeNil)
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
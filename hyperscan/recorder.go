@@ -0,0 +1,105 @@
+package hyperscan
+
+import "sort"
+
+// RecordedMatch is a match event recorded by the MatchRecorder.
+type RecordedMatch struct {
+	ID       uint
+	From, To uint64
+	Flags    uint
+}
+
+// MatchOrder is the order of the recorded matches.
+type MatchOrder int
+
+const (
+	// ByEndOffset sorts the matches by the end offset, it's the order reported by the scanning.
+	ByEndOffset MatchOrder = iota
+	// ByStartOffset sorts the matches by the start offset, then the end offset.
+	ByStartOffset
+	// ByPattern sorts the matches by the pattern id, then the start and end offset.
+	ByPattern
+)
+
+// MatchRecorder records the match events into a reusable slice.
+//
+//	r := hyperscan.NewMatchRecorder(64)
+//	err := db.Scan(data, scratch, r.Handle, nil)
+//	matches := r.Matches()
+//	r.Reset()
+type MatchRecorder struct {
+	matches []RecordedMatch
+}
+
+// NewMatchRecorder returns a recorder with capacity pre-allocated for n matches.
+func NewMatchRecorder(n int) *MatchRecorder {
+	return &MatchRecorder{matches: make([]RecordedMatch, 0, n)}
+}
+
+// Handle records the match event.
+func (r *MatchRecorder) Handle(id uint, from, to uint64, flags uint, context interface{}) error {
+	r.matches = append(r.matches, RecordedMatch{id, from, to, flags})
+
+	return nil
+}
+
+// Len returns the number of matches recorded.
+func (r *MatchRecorder) Len() int { return len(r.matches) }
+
+// Matches returns the recorded matches, the slice is only valid until the next `Reset`.
+func (r *MatchRecorder) Matches() []RecordedMatch { return r.matches }
+
+// Reset drops the recorded matches but keeps the allocated buffer for reusing.
+func (r *MatchRecorder) Reset() { r.matches = r.matches[:0] }
+
+// Sort the recorded matches in the order.
+func (r *MatchRecorder) Sort(order MatchOrder) {
+	m := r.matches
+
+	switch order {
+	case ByEndOffset:
+		sort.SliceStable(m, func(i, j int) bool { return m[i].To < m[j].To })
+	case ByStartOffset:
+		sort.SliceStable(m, func(i, j int) bool {
+			if m[i].From != m[j].From {
+				return m[i].From < m[j].From
+			}
+
+			return m[i].To < m[j].To
+		})
+	case ByPattern:
+		sort.SliceStable(m, func(i, j int) bool {
+			if m[i].ID != m[j].ID {
+				return m[i].ID < m[j].ID
+			}
+
+			if m[i].From != m[j].From {
+				return m[i].From < m[j].From
+			}
+
+			return m[i].To < m[j].To
+		})
+	}
+}
+
+// GroupByPattern sorts the recorded matches by pattern, and groups them by the pattern id.
+//
+// The grouped slices share the buffer of recorder, they are only valid until the next `Reset`.
+func (r *MatchRecorder) GroupByPattern() map[uint][]RecordedMatch {
+	r.Sort(ByPattern)
+
+	groups := make(map[uint][]RecordedMatch)
+
+	for i := 0; i < len(r.matches); {
+		j := i + 1
+
+		for j < len(r.matches) && r.matches[j].ID == r.matches[i].ID {
+			j++
+		}
+
+		groups[r.matches[i].ID] = r.matches[i:j:j]
+		i = j
+	}
+
+	return groups
+}
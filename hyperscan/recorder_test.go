@@ -0,0 +1,63 @@
+package hyperscan_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestMatchRecorder(t *testing.T) {
+	Convey("Given a match recorder", t, func() {
+		r := hyperscan.NewMatchRecorder(4)
+
+		So(r.Handle(2, 3, 6, 0, nil), ShouldBeNil)
+		So(r.Handle(1, 0, 8, 0, nil), ShouldBeNil)
+		So(r.Handle(2, 0, 2, 0, nil), ShouldBeNil)
+
+		So(r.Len(), ShouldEqual, 3)
+
+		Convey("When sort the matches by end offset", func() {
+			r.Sort(hyperscan.ByEndOffset)
+
+			So(r.Matches(), ShouldResemble, []hyperscan.RecordedMatch{{2, 0, 2, 0}, {2, 3, 6, 0}, {1, 0, 8, 0}})
+		})
+
+		Convey("When sort the matches by start offset", func() {
+			r.Sort(hyperscan.ByStartOffset)
+
+			So(r.Matches(), ShouldResemble, []hyperscan.RecordedMatch{{2, 0, 2, 0}, {1, 0, 8, 0}, {2, 3, 6, 0}})
+		})
+
+		Convey("When group the matches by pattern", func() {
+			groups := r.GroupByPattern()
+
+			So(groups, ShouldResemble, map[uint][]hyperscan.RecordedMatch{
+				1: {{1, 0, 8, 0}},
+				2: {{2, 0, 2, 0}, {2, 3, 6, 0}},
+			})
+		})
+
+		Convey("When reset the recorder", func() {
+			r.Reset()
+
+			So(r.Len(), ShouldEqual, 0)
+			So(cap(r.Matches()), ShouldBeGreaterThanOrEqualTo, 4)
+		})
+	})
+
+	Convey("Given a block database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		Convey("When scan the data with the recorder", func() {
+			r := hyperscan.NewMatchRecorder(0)
+
+			So(bdb.Scan([]byte("foo foo"), nil, r.Handle, nil), ShouldBeNil)
+			So(r.Matches(), ShouldResemble, []hyperscan.RecordedMatch{{0, 0, 3, 0}, {0, 4, 7, 0}})
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
@@ -0,0 +1,110 @@
+package hyperscan
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Registry is a set of the databases registered under names, which could be looked up by the scanners.
+type Registry struct {
+	mu  sync.RWMutex
+	dbs map[string]Database
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{dbs: make(map[string]Database)}
+}
+
+// Register the database under the name, the name must be unique.
+func (r *Registry) Register(name string, db Database) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.dbs[name]; exists {
+		return fmt.Errorf("database `%s`, %w", name, ErrConflict)
+	}
+
+	r.dbs[name] = db
+
+	return nil
+}
+
+// Lookup the database registered under the name.
+func (r *Registry) Lookup(name string) (Database, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	db, exists := r.dbs[name]
+
+	return db, exists
+}
+
+// LookupBlock the block database registered under the name.
+func (r *Registry) LookupBlock(name string) (BlockDatabase, bool) {
+	db, _ := r.Lookup(name)
+	bdb, ok := db.(BlockDatabase)
+
+	return bdb, ok
+}
+
+// LookupStream the stream database registered under the name.
+func (r *Registry) LookupStream(name string) (StreamDatabase, bool) {
+	db, _ := r.Lookup(name)
+	sdb, ok := db.(StreamDatabase)
+
+	return sdb, ok
+}
+
+// LookupVectored the vectored database registered under the name.
+func (r *Registry) LookupVectored(name string) (VectoredDatabase, bool) {
+	db, _ := r.Lookup(name)
+	vdb, ok := db.(VectoredDatabase)
+
+	return vdb, ok
+}
+
+// Unregister the database under the name, and returns it without closing.
+func (r *Registry) Unregister(name string) (Database, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	db, exists := r.dbs[name]
+
+	delete(r.dbs, name)
+
+	return db, exists
+}
+
+// Names returns the sorted names of the registered databases.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.dbs))
+
+	for name := range r.dbs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Close all the registered databases and clear the registry, it returns the first error.
+func (r *Registry) Close() (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for name, db := range r.dbs {
+		if e := db.Close(); e != nil && err == nil {
+			err = fmt.Errorf("close database `%s`, %w", name, e)
+		}
+	}
+
+	r.dbs = make(map[string]Database)
+
+	return
+}
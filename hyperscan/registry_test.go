@@ -0,0 +1,70 @@
+package hyperscan_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestRegistry(t *testing.T) {
+	Convey("Given a registry", t, func() {
+		r := hyperscan.NewRegistry()
+
+		http, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`GET|POST`))
+
+		So(err, ShouldBeNil)
+
+		dns, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`example\.com`))
+
+		So(err, ShouldBeNil)
+
+		So(r.Register("http-rules", http), ShouldBeNil)
+		So(r.Register("dns-rules", dns), ShouldBeNil)
+
+		Convey("When register a duplicate name", func() {
+			err := r.Register("http-rules", dns)
+
+			So(errors.Is(err, hyperscan.ErrConflict), ShouldBeTrue)
+		})
+
+		Convey("When lookup the databases", func() {
+			So(r.Names(), ShouldResemble, []string{"dns-rules", "http-rules"})
+
+			db, ok := r.Lookup("http-rules")
+
+			So(ok, ShouldBeTrue)
+			So(db, ShouldEqual, http)
+
+			bdb, ok := r.LookupBlock("http-rules")
+
+			So(ok, ShouldBeTrue)
+			So(bdb.MatchString("GET /"), ShouldBeTrue)
+
+			_, ok = r.LookupBlock("dns-rules")
+
+			So(ok, ShouldBeFalse)
+
+			_, ok = r.LookupStream("dns-rules")
+
+			So(ok, ShouldBeTrue)
+
+			_, ok = r.Lookup("unknown")
+
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("When unregister a database", func() {
+			db, ok := r.Unregister("dns-rules")
+
+			So(ok, ShouldBeTrue)
+			So(r.Names(), ShouldResemble, []string{"http-rules"})
+			So(db.Close(), ShouldBeNil)
+		})
+
+		So(r.Close(), ShouldBeNil)
+		So(r.Names(), ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,104 @@
+package hyperscan
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ReloadableDatabase is a block database which could be atomically swapped under live traffic,
+// the scanning always uses the current database with the scratch spaces allocated for it.
+type ReloadableDatabase struct {
+	mu      sync.RWMutex
+	current *reloadableSnapshot
+}
+
+type reloadableSnapshot struct {
+	db BlockDatabase
+	scratchList
+}
+
+func newReloadableSnapshot(db BlockDatabase) (*reloadableSnapshot, error) {
+	s, err := NewScratch(db)
+	if err != nil {
+		return nil, fmt.Errorf("create scratch, %w", err)
+	}
+
+	return &reloadableSnapshot{db: db, scratchList: scratchList{proto: s}}, nil
+}
+
+// NewReloadableDatabase returns a reloadable database with the initial database.
+func NewReloadableDatabase(db BlockDatabase) (*ReloadableDatabase, error) {
+	snap, err := newReloadableSnapshot(db)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReloadableDatabase{current: snap}, nil
+}
+
+// Scan the data with the current database.
+func (r *ReloadableDatabase) Scan(data []byte, handler MatchHandler, context interface{}) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snap := r.current
+
+	if snap == nil {
+		return fmt.Errorf("database closed, %w", ErrInvalid)
+	}
+
+	s, err := snap.get()
+	if err != nil {
+		return err
+	}
+
+	defer snap.put(s)
+
+	return snap.db.Scan(data, s, handler, context)
+}
+
+// Swap the current database with the new one, the scratch space is allocated for the new database before swapping.
+//
+// It waits for the in-flight scans to complete, then closes the previous database.
+// The reloadable database can't be revived after closed, and the new database is left to the caller.
+func (r *ReloadableDatabase) Swap(db BlockDatabase) error {
+	snap, err := newReloadableSnapshot(db)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	prev := r.current
+
+	if prev == nil {
+		r.mu.Unlock()
+		snap.free()
+
+		return fmt.Errorf("database closed, %w", ErrInvalid)
+	}
+
+	r.current = snap
+	r.mu.Unlock()
+
+	return prev.close()
+}
+
+// Close the current database and its scratch spaces.
+func (r *ReloadableDatabase) Close() error {
+	r.mu.Lock()
+	prev := r.current
+	r.current = nil
+	r.mu.Unlock()
+
+	return prev.close()
+}
+
+func (snap *reloadableSnapshot) close() error {
+	if snap == nil {
+		return nil
+	}
+
+	snap.free()
+
+	return snap.db.Close()
+}
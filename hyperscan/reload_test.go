@@ -0,0 +1,77 @@
+package hyperscan_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestReloadableDatabase(t *testing.T) {
+	Convey("Given a reloadable database", t, func() {
+		foo, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`))
+
+		So(err, ShouldBeNil)
+
+		r, err := hyperscan.NewReloadableDatabase(foo)
+
+		So(err, ShouldBeNil)
+
+		matched := func(data string) (n int) {
+			err := r.Scan([]byte(data), func(id uint, from, to uint64, flags uint, context interface{}) error {
+				n++
+
+				return nil
+			}, nil)
+
+			So(err, ShouldBeNil)
+
+			return
+		}
+
+		So(matched("foo bar"), ShouldEqual, 1)
+
+		Convey("When swap the database under scanning", func() {
+			bar, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`bar`), hyperscan.NewPattern(`baz`))
+
+			So(err, ShouldBeNil)
+
+			var wg sync.WaitGroup
+
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					for j := 0; j < 100; j++ {
+						_ = r.Scan([]byte("foo bar baz"), func(id uint, from, to uint64, flags uint, context interface{}) error {
+							return nil
+						}, nil)
+					}
+				}()
+			}
+
+			So(r.Swap(bar), ShouldBeNil)
+
+			wg.Wait()
+
+			So(matched("foo bar baz"), ShouldEqual, 2)
+		})
+
+		So(r.Close(), ShouldBeNil)
+		So(r.Scan([]byte("foo"), nil, nil), ShouldNotBeNil)
+
+		Convey("When swap the database after closed", func() {
+			baz, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`baz`))
+
+			So(err, ShouldBeNil)
+			So(errors.Is(r.Swap(baz), hyperscan.ErrInvalid), ShouldBeTrue)
+			So(r.Scan([]byte("baz"), nil, nil), ShouldNotBeNil)
+			So(baz.Close(), ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,301 @@
+package hyperscan
+
+import (
+	"fmt"
+	"sync"
+)
+
+// RuleSet manages a large set of patterns in the shards of block databases,
+// the modified shards are recompiled and swapped into the scanner when commit.
+//
+// The patterns are distributed into the shards by their IDs, so the ID of pattern must be unique.
+type RuleSet struct {
+	commit  sync.Mutex
+	shards  []map[int]*Pattern
+	dirty   []bool
+	mu      sync.RWMutex
+	current *ruleSnapshot
+}
+
+type ruleSnapshot struct {
+	dbs []BlockDatabase
+	scratchList
+}
+
+// NewRuleSet returns an empty rule set with the number of shards.
+func NewRuleSet(shards int) *RuleSet {
+	if shards < 1 {
+		shards = 1
+	}
+
+	rs := &RuleSet{
+		shards:  make([]map[int]*Pattern, shards),
+		dirty:   make([]bool, shards),
+		current: &ruleSnapshot{dbs: make([]BlockDatabase, shards)},
+	}
+
+	for i := range rs.shards {
+		rs.shards[i] = make(map[int]*Pattern)
+	}
+
+	return rs
+}
+
+func (rs *RuleSet) shard(id int) int {
+	n := id % len(rs.shards)
+
+	if n < 0 {
+		n += len(rs.shards)
+	}
+
+	return n
+}
+
+// Add the patterns or replace the patterns with the same IDs, it will take effect after commit.
+func (rs *RuleSet) Add(patterns ...*Pattern) error {
+	rs.commit.Lock()
+	defer rs.commit.Unlock()
+
+	for _, p := range patterns {
+		if err := p.validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range patterns {
+		n := rs.shard(p.Id)
+
+		rs.shards[n][p.Id] = p
+		rs.dirty[n] = true
+	}
+
+	return nil
+}
+
+// Remove the patterns with the IDs, it will take effect after commit.
+func (rs *RuleSet) Remove(ids ...int) {
+	rs.commit.Lock()
+	defer rs.commit.Unlock()
+
+	for _, id := range ids {
+		n := rs.shard(id)
+
+		if _, exists := rs.shards[n][id]; exists {
+			delete(rs.shards[n], id)
+			rs.dirty[n] = true
+		}
+	}
+}
+
+// Len returns the number of patterns, including the uncommitted changes.
+func (rs *RuleSet) Len() (n int) {
+	rs.commit.Lock()
+	defer rs.commit.Unlock()
+
+	for _, shard := range rs.shards {
+		n += len(shard)
+	}
+
+	return
+}
+
+// Commit recompiles the modified shards, and atomically swaps them into the scanner.
+//
+// The current databases are kept if any shard failed to compile, and the error of closing the compiled databases
+// is appended to it. The new databases are swapped in even if the previous ones failed to close, with the first error.
+func (rs *RuleSet) Commit() error {
+	rs.commit.Lock()
+	defer rs.commit.Unlock()
+
+	rs.mu.RLock()
+	dbs := append([]BlockDatabase(nil), rs.current.dbs...)
+	rs.mu.RUnlock()
+
+	var compiled []BlockDatabase
+
+	// abort closes the compiled databases, and appends the first error of them to the error.
+	abort := func(err error) error {
+		var closeErr error
+
+		for _, db := range compiled {
+			if e := db.Close(); e != nil && closeErr == nil {
+				closeErr = e
+			}
+		}
+
+		if closeErr != nil {
+			return fmt.Errorf("%w, close database, %v", err, closeErr) // nolint: errorlint
+		}
+
+		return err
+	}
+
+	for n, dirty := range rs.dirty {
+		if !dirty {
+			continue
+		}
+
+		dbs[n] = nil
+
+		if len(rs.shards[n]) == 0 {
+			continue
+		}
+
+		patterns := make(Patterns, 0, len(rs.shards[n]))
+
+		for _, p := range rs.shards[n] {
+			patterns = append(patterns, p)
+		}
+
+		db, err := NewBlockDatabase(patterns...)
+		if err != nil {
+			return abort(fmt.Errorf("shard %d, %w", n, err))
+		}
+
+		dbs[n] = db
+		compiled = append(compiled, db)
+	}
+
+	snap := &ruleSnapshot{dbs: dbs}
+
+	for _, db := range dbs {
+		if db == nil {
+			continue
+		}
+
+		var err error
+
+		if snap.proto == nil {
+			snap.proto, err = NewScratch(db)
+		} else {
+			err = snap.proto.Realloc(db)
+		}
+
+		if err != nil {
+			if snap.proto != nil {
+				_ = snap.proto.Free()
+			}
+
+			return abort(fmt.Errorf("create scratch, %w", err))
+		}
+	}
+
+	rs.mu.Lock()
+	prev := rs.current
+	rs.current = snap
+	rs.mu.Unlock()
+
+	var err error
+
+	// No scanning is using the previous snapshot after the swapping.
+	for n, dirty := range rs.dirty {
+		if dirty && prev.dbs[n] != nil {
+			if e := prev.dbs[n].Close(); e != nil && err == nil {
+				err = fmt.Errorf("close shard %d, %w", n, e)
+			}
+		}
+
+		rs.dirty[n] = false
+	}
+
+	prev.free()
+
+	return err
+}
+
+// Scan the data with all the committed shards.
+func (rs *RuleSet) Scan(data []byte, handler MatchHandler, context interface{}) error {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	snap := rs.current
+
+	if snap.proto == nil {
+		return nil
+	}
+
+	s, err := snap.get()
+	if err != nil {
+		return err
+	}
+
+	defer snap.put(s)
+
+	for _, db := range snap.dbs {
+		if db == nil {
+			continue
+		}
+
+		if err := db.Scan(data, s, handler, context); err != nil {
+			return err // nolint: wrapcheck
+		}
+	}
+
+	return nil
+}
+
+// Close frees all the databases and scratch spaces.
+func (rs *RuleSet) Close() error {
+	rs.commit.Lock()
+	defer rs.commit.Unlock()
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	var err error
+
+	for _, db := range rs.current.dbs {
+		if db == nil {
+			continue
+		}
+
+		if e := db.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	rs.current.free()
+	rs.current = &ruleSnapshot{dbs: make([]BlockDatabase, len(rs.shards))}
+
+	return err
+}
+
+// scratchList is a list of the scratch spaces cloned from the prototype.
+type scratchList struct {
+	proto   *Scratch
+	mu      sync.Mutex
+	scratch []*Scratch
+}
+
+func (l *scratchList) get() (*Scratch, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if n := len(l.scratch); n > 0 {
+		s := l.scratch[n-1]
+		l.scratch = l.scratch[:n-1]
+
+		return s, nil
+	}
+
+	return l.proto.Clone()
+}
+
+func (l *scratchList) put(s *Scratch) {
+	l.mu.Lock()
+	l.scratch = append(l.scratch, s)
+	l.mu.Unlock()
+}
+
+func (l *scratchList) free() {
+	for _, s := range l.scratch {
+		_ = s.Free()
+	}
+
+	if l.proto != nil {
+		_ = l.proto.Free()
+	}
+
+	l.scratch = nil
+	l.proto = nil
+}
@@ -0,0 +1,65 @@
+package hyperscan_test
+
+import (
+	"sort"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestRuleSet(t *testing.T) {
+	Convey("Given a rule set with shards", t, func() {
+		rs := hyperscan.NewRuleSet(4)
+
+		scan := func(data string) (ids []int) {
+			err := rs.Scan([]byte(data), func(id uint, from, to uint64, flags uint, context interface{}) error {
+				ids = append(ids, int(id))
+
+				return nil
+			}, nil)
+
+			So(err, ShouldBeNil)
+
+			sort.Ints(ids)
+
+			return
+		}
+
+		So(scan("foo"), ShouldBeEmpty)
+
+		Convey("When add and commit some patterns", func() {
+			foo := hyperscan.NewPattern(`foo`, hyperscan.SingleMatch)
+			foo.Id = 1
+			bar := hyperscan.NewPattern(`bar`, hyperscan.SingleMatch)
+			bar.Id = 2
+			baz := hyperscan.NewPattern(`baz`, hyperscan.SingleMatch)
+			baz.Id = 5
+
+			So(rs.Add(foo, bar, baz), ShouldBeNil)
+			So(rs.Len(), ShouldEqual, 3)
+			So(scan("foo bar baz"), ShouldBeEmpty)
+
+			So(rs.Commit(), ShouldBeNil)
+			So(scan("foo bar baz"), ShouldResemble, []int{1, 2, 5})
+
+			Convey("Then remove a pattern and commit", func() {
+				rs.Remove(1)
+
+				So(rs.Commit(), ShouldBeNil)
+				So(scan("foo bar baz"), ShouldResemble, []int{2, 5})
+			})
+
+			Convey("Then add an invalid pattern", func() {
+				invalid := hyperscan.NewPattern(`\R`)
+				invalid.Id = 3
+
+				So(rs.Add(invalid), ShouldNotBeNil)
+				So(rs.Len(), ShouldEqual, 3)
+			})
+		})
+
+		So(rs.Close(), ShouldBeNil)
+	})
+}
@@ -12,6 +12,29 @@ const bufSize = 4096
 // ErrTooManyMatches means too many matches.
 var ErrTooManyMatches = errors.New("too many matches")
 
+// HandlerError is the error returned by the match handler which terminated the scanning.
+//
+// It matches ErrScanTerminated with `errors.Is`, and unwraps to the error of the handler.
+type HandlerError struct {
+	Err error
+}
+
+func (e *HandlerError) Error() string { return "match handler failed, " + e.Err.Error() }
+
+// Unwrap returns the error of the handler.
+func (e *HandlerError) Unwrap() error { return e.Err }
+
+// Is reports whether the target is ErrScanTerminated.
+func (e *HandlerError) Is(target error) bool { return target == ErrScanTerminated } // nolint: errorlint
+
+// IsHandlerError reports whether the scanning was failed by an error of the match handler,
+// rather than terminated by the handler returning ErrScanTerminated or failed by the engine.
+func IsHandlerError(err error) bool {
+	var e *HandlerError
+
+	return errors.As(err, &e)
+}
+
 // Scratch is a Hyperscan scratch space.
 type Scratch struct {
 	s hsScratch
@@ -21,7 +44,19 @@ type Scratch struct {
 // This is required for runtime use, and one scratch space per thread,
 // or concurrent caller, is required.
 func NewScratch(db Database) (*Scratch, error) {
+	s, err := newScratch(db)
+	if err != nil {
+		return nil, err
+	}
+
+	return trackScratch(s), nil
+}
+
+func newScratch(db Database) (*Scratch, error) {
 	s, err := hsAllocScratch(db.(database).Db())
+
+	runtime.KeepAlive(db)
+
 	if err != nil {
 		return nil, err
 	}
@@ -38,6 +73,7 @@ func NewManagedScratch(db Database) (*Scratch, error) {
 		return nil, err
 	}
 
+	runtime.SetFinalizer(s, nil)
 	runtime.SetFinalizer(s, func(scratch *Scratch) {
 		_ = scratch.Free()
 	})
@@ -47,11 +83,36 @@ func NewManagedScratch(db Database) (*Scratch, error) {
 // Size provides the size of the given scratch space.
 func (s *Scratch) Size() (int, error) { return hsScratchSize(s.s) }
 
-// Realloc reallocate the scratch for another database.
+// Realloc reallocate the scratch for another database,
+// it grows the scratch space so that it could be used for the database as well as the previous ones.
 func (s *Scratch) Realloc(db Database) error {
+	defer runtime.KeepAlive(db)
+
 	return hsReallocScratch(db.(database).Db(), &s.s)
 }
 
+// NewMultiScratch allocate a single scratch space which could be used for all the databases.
+func NewMultiScratch(dbs ...Database) (*Scratch, error) {
+	if len(dbs) == 0 {
+		return nil, fmt.Errorf("no database, %w", ErrInvalid)
+	}
+
+	s, err := NewScratch(dbs[0])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, db := range dbs[1:] {
+		if err = s.Realloc(db); err != nil {
+			_ = s.Free()
+
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
 // Clone allocate a scratch space that is a clone of an existing scratch space.
 func (s *Scratch) Clone() (*Scratch, error) {
 	cloned, err := hsCloneScratch(s.s)
@@ -59,11 +120,80 @@ func (s *Scratch) Clone() (*Scratch, error) {
 		return nil, err
 	}
 
-	return &Scratch{cloned}, nil
+	return trackScratch(&Scratch{cloned}), nil
+}
+
+// CloneN allocate n scratch spaces that are clones of an existing scratch space,
+// the allocated clones are freed if any of them failed.
+func (s *Scratch) CloneN(n int) ([]*Scratch, error) {
+	clones := make([]*Scratch, 0, n)
+
+	for i := 0; i < n; i++ {
+		cloned, err := s.Clone()
+		if err != nil {
+			_ = FreeScratches(clones)
+
+			return nil, err
+		}
+
+		clones = append(clones, cloned)
+	}
+
+	return clones, nil
+}
+
+// FreeScratches frees the scratch spaces, and returns the first error.
+func FreeScratches(scratches []*Scratch) (err error) {
+	for _, s := range scratches {
+		if e := s.Free(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return
+}
+
+// PrewarmScratch allocates n scratch spaces which could be used for all the databases,
+// so the services could fail fast at startup rather than at the first scanning.
+// It returns the scratch spaces and their total size in bytes.
+func PrewarmScratch(n int, dbs ...Database) ([]*Scratch, int, error) {
+	proto, err := NewMultiScratch(dbs...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("allocate scratch, %w", err)
+	}
+
+	defer func() {
+		_ = proto.Free()
+	}()
+
+	scratches, err := proto.CloneN(n)
+	if err != nil {
+		return nil, 0, fmt.Errorf("clone scratch, %w", err)
+	}
+
+	total := 0
+
+	for _, s := range scratches {
+		size, err := s.Size()
+		if err != nil {
+			_ = FreeScratches(scratches)
+
+			return nil, 0, fmt.Errorf("scratch size, %w", err)
+		}
+
+		total += size
+	}
+
+	return scratches, total, nil
 }
 
 // Free a scratch block previously allocated.
-func (s *Scratch) Free() error { return hsFreeScratch(s.s) }
+func (s *Scratch) Free() error {
+	err := hsFreeScratch(s.s)
+	s.s = nil
+
+	return err
+}
 
 // MatchContext represents a match context.
 type MatchContext interface {
@@ -86,8 +216,17 @@ type MatchEvent interface {
 }
 
 // MatchHandler handles match events.
+//
+// The handler could return ErrScanTerminated to stop the scanning, the scanning returns ErrScanTerminated;
+// or any other error to fail the scanning, the scanning returns a *HandlerError wrapping it.
 type MatchHandler hsMatchEventHandler
 
+// TaggedMatchHandler handles match events with the tags of the matched pattern.
+type TaggedMatchHandler func(id uint, from, to uint64, flags uint, tags []string, context interface{}) error
+
+// PatternMatchHandler handles match events with the matched pattern.
+type PatternMatchHandler func(pattern *Pattern, from, to uint64, flags uint, context interface{}) error
+
 // BlockScanner is the block (non-streaming) regular expression scanner.
 type BlockScanner interface {
 	// This is the function call in which the actual pattern matching takes place for block-mode pattern databases.
@@ -142,18 +281,30 @@ type BlockMatcher interface {
 
 // Stream exist in the Hyperscan library so that pattern matching state can be maintained
 // across multiple blocks of target data.
+//
+// Use `NewStreamWriter` to scan the data written with `io.Copy` or `io.MultiWriter`.
 type Stream interface {
+	// Scan the data as the next chunk of stream,
+	// the offsets of matches are absolute within the stream, counted from the start of the first chunk.
 	Scan(data []byte) error
 
+	// Close reports the end of data matches to the handler with the scratch given when the stream opened,
+	// or the one owned by the stream, and frees the stream.
 	Close() error
 
+	// Reset reports the end of data matches to the handler, and reinitializes the stream for a new session
+	// with the same flags, handler and context, the offsets of the following matches start from zero.
 	Reset() error
 
+	// Clone forks the matching state of the stream into a new stream with the same handler and context,
+	// so the following data could be scanned along the different paths without rescanning the history.
 	Clone() (Stream, error)
 }
 
 // StreamScanner is the streaming regular expression scanner.
 type StreamScanner interface {
+	// Open opens a stream, the context is the user data of the stream (e.g. a connection or tenant),
+	// which is passed to the handler for every match on the stream.
 	Open(flags ScanFlag, scratch *Scratch, handler MatchHandler, context interface{}) (Stream, error)
 
 	Scan(reader io.Reader, scratch *Scratch, handler MatchHandler, context interface{}) error
@@ -205,6 +356,7 @@ type VectoredMatcher interface{}
 
 type stream struct {
 	stream       hsStream
+	db           hsDatabase // The database which the stream was opened with.
 	flags        ScanFlag
 	scratch      hsScratch
 	handler      hsMatchEventHandler
@@ -213,23 +365,111 @@ type stream struct {
 }
 
 func (s *stream) Scan(data []byte) error {
+	defer runtime.KeepAlive(s)
+
 	return hsScanStream(s.stream, data, s.flags, s.scratch, s.handler, s.context)
 }
 
+// ScanStreamWith is like `Stream.Scan` but passes the user context of this scanning to the handler,
+// instead of the one given when the stream opened.
+func ScanStreamWith(s Stream, data []byte, context interface{}) error {
+	ss, ok := s.(*stream)
+	if !ok {
+		return fmt.Errorf("stream %T, %w", s, ErrUnexpected)
+	}
+
+	defer runtime.KeepAlive(ss)
+
+	return hsScanStream(ss.stream, data, ss.flags, ss.scratch, ss.handler, context)
+}
+
+// ScanStreamString is like `Stream.Scan` but scans the string without copying it.
+func ScanStreamString(s Stream, data string) error { return s.Scan(stringBytes(data)) }
+
+// Write scans the data as the next chunk of stream.
+func (s *stream) Write(p []byte) (int, error) { return streamWriter{s}.Write(p) }
+
 func (s *stream) Close() error {
-	err := hsCloseStream(s.stream, s.scratch, s.handler, s.context)
+	return s.close(s.scratch)
+}
+
+// CloseStreamWith is like Close but reports the end of data matches with the scratch, such as the one of worker.
+func CloseStreamWith(s Stream, scratch *Scratch) error {
+	if scratch == nil {
+		return s.Close()
+	}
+
+	ss, ok := s.(*stream)
+	if !ok {
+		return fmt.Errorf("stream %T, %w", s, ErrUnexpected)
+	}
+
+	return ss.close(scratch.s)
+}
+
+func (s *stream) close(scratch hsScratch) error {
+	if s.stream == nil {
+		return fmt.Errorf("stream closed, %w", ErrInvalid)
+	}
+
+	err := hsCloseStream(s.stream, scratch, s.handler, s.context)
+	s.stream = nil
+	s.freeScratch()
+
+	return err
+}
 
+// freeScratch frees the scratch owned by the stream once.
+func (s *stream) freeScratch() {
 	if s.ownedScratch {
 		_ = hsFreeScratch(s.scratch)
+		s.scratch = nil
+		s.ownedScratch = false
 	}
+}
+
+// discardStream frees the stream without reporting the end of data matches,
+// or closes it if the stream was not opened by the package.
+func discardStream(s Stream) error {
+	if ss, ok := s.(*stream); ok {
+		return ss.discard()
+	}
+
+	return s.Close() // nolint: wrapcheck
+}
+
+// discard frees the stream without reporting the end of data matches.
+func (s *stream) discard() error {
+	if s.stream == nil {
+		return fmt.Errorf("stream closed, %w", ErrInvalid)
+	}
+
+	err := hsFreeStream(s.stream)
+	s.stream = nil
+	s.freeScratch()
 
 	return err
 }
 
 func (s *stream) Reset() error {
+	defer runtime.KeepAlive(s)
+
 	return hsResetStream(s.stream, s.flags, s.scratch, s.handler, s.context)
 }
 
+// ResetStreamWith is like Reset but the following matches are passed with the new context.
+func ResetStreamWith(s Stream, context interface{}) error {
+	ss, ok := s.(*stream)
+	if !ok {
+		return fmt.Errorf("stream %T, %w", s, ErrUnexpected)
+	}
+
+	err := ss.Reset()
+	ss.context = context
+
+	return err
+}
+
 func (s *stream) Clone() (Stream, error) {
 	ss, err := hsCopyStream(s.stream)
 	if err != nil {
@@ -246,7 +486,107 @@ func (s *stream) Clone() (Stream, error) {
 		}
 	}
 
-	return &stream{ss, s.flags, scratch, s.handler, s.context, s.ownedScratch}, nil
+	return trackStream(&stream{ss, s.db, s.flags, scratch, s.handler, s.context, s.ownedScratch}), nil
+}
+
+// compressBufSize is the initial size of buffer to compress the stream state, which grows on demand.
+const compressBufSize = 256
+
+// CompressStream creates a compressed representation of the stream state without knowing its database,
+// which could be expanded with `ExpandStream` or `ResetAndExpandStream`.
+func CompressStream(s Stream) ([]byte, error) {
+	ss, ok := s.(*stream)
+	if !ok {
+		return nil, fmt.Errorf("stream %T, %w", s, ErrUnexpected)
+	}
+
+	defer runtime.KeepAlive(ss)
+
+	return hsCompressStream(ss.stream, make([]byte, compressBufSize))
+}
+
+// ResetAndExpandStream reports the end of data matches of the current state to the handler,
+// and replaces the state with the compressed representation in place,
+// the following matches are passed to the same handler and context.
+func ResetAndExpandStream(s Stream, buf []byte) error {
+	ss, ok := s.(*stream)
+	if !ok {
+		return fmt.Errorf("stream %T, %w", s, ErrUnexpected)
+	}
+
+	defer runtime.KeepAlive(ss)
+
+	return hsResetAndExpandStream(ss.stream, buf, ss.scratch, ss.handler, ss.context)
+}
+
+// ResetAndCopyStream reports the end of data matches of the current state of the stream to its handler,
+// and replaces the state with a copy of the source stream in place, without allocation.
+func ResetAndCopyStream(dst, src Stream) error {
+	to, ok := dst.(*stream)
+	if !ok {
+		return fmt.Errorf("stream %v, %w", dst, ErrUnexpected)
+	}
+
+	from, ok := src.(*stream)
+	if !ok {
+		return fmt.Errorf("stream %v, %w", src, ErrUnexpected)
+	}
+
+	defer runtime.KeepAlive(from)
+	defer runtime.KeepAlive(to)
+
+	return hsResetAndCopyStream(to.stream, from.stream, to.scratch, to.handler, to.context)
+}
+
+// StreamContext returns the user data associated with the stream when it opened,
+// or nil if the stream was not opened by the package.
+func StreamContext(s Stream) interface{} {
+	if ss, ok := s.(*stream); ok {
+		return ss.context
+	}
+
+	return nil
+}
+
+// SetStreamContext replaces the user data passed to the handler for the following matches,
+// including the end of data matches reported when the stream is closed or reset.
+func SetStreamContext(s Stream, context interface{}) error {
+	ss, ok := s.(*stream)
+	if !ok {
+		return fmt.Errorf("stream %T, %w", s, ErrUnexpected)
+	}
+
+	ss.context = context
+
+	return nil
+}
+
+var _ io.WriteCloser = (*stream)(nil)
+
+type streamWriter struct {
+	Stream
+}
+
+func (w streamWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if err := w.Scan(p); err != nil {
+		return 0, err // nolint: wrapcheck
+	}
+
+	return len(p), nil
+}
+
+// NewStreamWriter returns an `io.WriteCloser` which scans the written data as the next chunks of stream,
+// and closes the stream when closed.
+func NewStreamWriter(s Stream) io.WriteCloser {
+	if w, ok := s.(io.WriteCloser); ok {
+		return w
+	}
+
+	return streamWriter{s}
 }
 
 type streamScanner struct {
@@ -259,6 +599,9 @@ func newStreamScanner(db *baseDatabase) *streamScanner {
 
 func (ss *streamScanner) Open(flags ScanFlag, sc *Scratch, handler MatchHandler, context interface{}) (Stream, error) {
 	s, err := hsOpenStream(ss.db, flags)
+
+	runtime.KeepAlive(ss)
+
 	if err != nil {
 		return nil, fmt.Errorf("open stream, %w", err)
 	}
@@ -266,7 +609,7 @@ func (ss *streamScanner) Open(flags ScanFlag, sc *Scratch, handler MatchHandler,
 	ownedScratch := false
 
 	if sc == nil {
-		sc, err = NewScratch(ss)
+		sc, err = newScratch(ss)
 		if err != nil {
 			return nil, fmt.Errorf("create scratch, %w", err)
 		}
@@ -274,33 +617,72 @@ func (ss *streamScanner) Open(flags ScanFlag, sc *Scratch, handler MatchHandler,
 		ownedScratch = true
 	}
 
-	return &stream{s, flags, sc.s, hsMatchEventHandler(handler), context, ownedScratch}, nil
+	return trackStream(&stream{s, ss.db, flags, sc.s, hsMatchEventHandler(handler), context, ownedScratch}), nil
 }
 
 func (ss *streamScanner) Scan(reader io.Reader, scratch *Scratch, handler MatchHandler, context interface{}) error {
-	stream, err := ss.Open(0, nil, handler, context)
+	_, err := ss.scanReader(reader, scratch, handler, context, bufSize)
+
+	return err
+}
+
+// ReaderStats is the aggregate information of scanning the data read from a reader.
+type ReaderStats struct {
+	Bytes   int64 // The number of bytes scanned.
+	Chunks  int   // The number of chunks scanned.
+	Matches int   // The number of matches, including the end of data matches.
+}
+
+// ScanReader opens a stream, scans the data read from the reader in chunks of the size,
+// and closes the stream at the end of data to report the end of data matches.
+func (ss *streamScanner) ScanReader(reader io.Reader, scratch *Scratch, handler MatchHandler,
+	chunkSize int) (ReaderStats, error) {
+	return ss.scanReader(reader, scratch, handler, nil, chunkSize)
+}
+
+func (ss *streamScanner) scanReader(reader io.Reader, scratch *Scratch, handler MatchHandler,
+	context interface{}, chunkSize int) (stats ReaderStats, err error) {
+	if chunkSize <= 0 {
+		chunkSize = bufSize
+	}
+
+	stream, err := ss.Open(0, scratch, func(id uint, from, to uint64, flags uint, context interface{}) error {
+		stats.Matches++
+
+		return handler(id, from, to, flags, context)
+	}, context)
 	if err != nil {
-		return err
+		return
 	}
-	defer stream.Close()
 
-	buf := make([]byte, bufSize)
+	buf := make([]byte, chunkSize)
 
 	for {
-		n, err := reader.Read(buf)
+		n, readErr := reader.Read(buf)
 
-		if err == io.EOF {
-			return nil
+		if n > 0 {
+			stats.Bytes += int64(n)
+			stats.Chunks++
+
+			if err = stream.Scan(buf[:n]); err != nil {
+				_ = stream.Close()
+
+				return // nolint: wrapcheck
+			}
 		}
 
-		if err != nil {
-			return fmt.Errorf("read stream, %w", err)
+		if readErr == io.EOF {
+			break
 		}
 
-		if err = stream.Scan(buf[:n]); err != nil {
-			return err // nolint: wrapcheck
+		if readErr != nil {
+			_ = stream.Close()
+
+			return stats, fmt.Errorf("read stream, %w", readErr)
 		}
 	}
+
+	return stats, stream.Close() // nolint: wrapcheck
 }
 
 type vectoredScanner struct {
@@ -324,7 +706,23 @@ func (vs *vectoredScanner) Scan(data [][]byte, s *Scratch, handler MatchHandler,
 		}()
 	}
 
-	return hsScanVector(vs.db, data, 0, s.s, hsMatchEventHandler(handler), context)
+	err = hsScanVector(vs.db, data, 0, s.s, hsMatchEventHandler(handler), context)
+
+	runtime.KeepAlive(vs)
+	runtime.KeepAlive(s)
+
+	return err
+}
+
+// ScanStrings is like `VectoredScanner.Scan` but scans the strings without copying them.
+func ScanStrings(scanner VectoredScanner, data []string, s *Scratch, handler MatchHandler, context interface{}) error {
+	blocks := make([][]byte, len(data))
+
+	for i, d := range data {
+		blocks[i] = stringBytes(d)
+	}
+
+	return scanner.Scan(blocks, s, handler, context)
 }
 
 type blockScanner struct {
@@ -348,13 +746,24 @@ func (bs *blockScanner) Scan(data []byte, s *Scratch, handler MatchHandler, cont
 		}()
 	}
 
-	return hsScan(bs.db, data, 0, s.s, hsMatchEventHandler(handler), context)
+	err = hsScan(bs.db, data, 0, s.s, hsMatchEventHandler(handler), context)
+
+	runtime.KeepAlive(bs)
+	runtime.KeepAlive(s)
+
+	return err
+}
+
+// ScanString is like `BlockScanner.Scan` but scans the string without copying it.
+func ScanString(scanner BlockScanner, s string, scratch *Scratch, handler MatchHandler, context interface{}) error {
+	return scanner.Scan(stringBytes(s), scratch, handler, context)
 }
 
 type blockMatcher struct {
 	*blockScanner
 	*matchRecorder
-	n int
+	n       int
+	scratch *Scratch // the scratch used for matching, or allocated for each matching if nil.
 }
 
 func newBlockMatcher(scanner *blockScanner) *blockMatcher {
@@ -383,7 +792,7 @@ func (m *blockMatcher) Handle(id uint, from, to uint64, flags uint, context inte
 func (m *blockMatcher) scan(data []byte) error {
 	m.matchRecorder = &matchRecorder{}
 
-	return m.blockScanner.Scan(data, nil, m.Handle, nil)
+	return m.blockScanner.Scan(data, m.scratch, m.Handle, nil)
 }
 
 const findIndexMatches = 2
@@ -430,24 +839,67 @@ func (m *blockMatcher) FindAllIndex(data []byte, n int) (locs [][]int) {
 	return
 }
 
+// FindAllPatternIndex is like `BlockMatcher.FindAllIndex`, but groups the locations of matches by the IDs of patterns.
+// A return value of nil indicates no match.
+func FindAllPatternIndex(scanner BlockScanner, data []byte, n int) (locs map[uint][][]int) {
+	if n < 0 {
+		n = len(data) + 1
+	}
+
+	var r matchRecorder
+
+	err := scanner.Scan(data, nil, func(id uint, from, to uint64, flags uint, context interface{}) error {
+		if err := r.Handle(id, from, to, flags, context); err != nil {
+			return err
+		}
+
+		if n < len(r.matched) {
+			r.matched = r.matched[:n]
+
+			return ErrTooManyMatches
+		}
+
+		return nil
+	}, nil)
+
+	if (err == nil || errors.Is(err, ErrScanTerminated)) && len(r.matched) > 0 {
+		locs = make(map[uint][][]int)
+
+		for _, e := range r.matched {
+			locs[e.id] = append(locs[e.id], []int{int(e.from), int(e.to)})
+		}
+	}
+
+	return
+}
+
+// FindAllStringPatternIndex is the string version of `FindAllPatternIndex`.
+func FindAllStringPatternIndex(scanner BlockScanner, s string, n int) map[uint][][]int {
+	return FindAllPatternIndex(scanner, stringBytes(s), n)
+}
+
 func (m *blockMatcher) FindString(s string) string {
-	return string(m.Find([]byte(s)))
+	if loc := m.FindStringIndex(s); len(loc) == findIndexMatches {
+		return s[loc[0]:loc[1]]
+	}
+
+	return ""
 }
 
 func (m *blockMatcher) FindStringIndex(s string) (loc []int) {
-	return m.FindIndex([]byte(s))
+	return m.FindIndex(stringBytes(s))
 }
 
 func (m *blockMatcher) FindAllString(s string, n int) (results []string) {
-	for _, m := range m.FindAll([]byte(s), n) {
-		results = append(results, string(m))
+	for _, loc := range m.FindAllStringIndex(s, n) {
+		results = append(results, s[loc[0]:loc[1]])
 	}
 
 	return
 }
 
 func (m *blockMatcher) FindAllStringIndex(s string, n int) [][]int {
-	return m.FindAllIndex([]byte(s), n)
+	return m.FindAllIndex(stringBytes(s), n)
 }
 
 func (m *blockMatcher) Match(data []byte) bool {
@@ -459,7 +911,39 @@ func (m *blockMatcher) Match(data []byte) bool {
 }
 
 func (m *blockMatcher) MatchString(s string) bool {
-	return m.Match([]byte(s))
+	return m.Match(stringBytes(s))
+}
+
+// MatchFirst terminates the scanning at the first match in C and returns it,
+// a scratch is allocated for the scanning if the scratch is nil.
+func MatchFirst(db BlockDatabase, data []byte, s *Scratch) (RecordedMatch, bool, error) {
+	d, ok := db.(database)
+	if !ok {
+		return RecordedMatch{}, false, fmt.Errorf("database %v, %w", db, ErrUnexpected)
+	}
+
+	if s == nil {
+		var err error
+
+		if s, err = NewScratch(db); err != nil {
+			return RecordedMatch{}, false, err
+		}
+
+		defer func() {
+			_ = s.Free()
+		}()
+	}
+
+	match, err := hsScanFirst(d.Db(), data, 0, s.s)
+
+	runtime.KeepAlive(db)
+	runtime.KeepAlive(s)
+
+	if err != nil || match == nil {
+		return RecordedMatch{}, false, err
+	}
+
+	return *match, true, nil
 }
 
 type streamMatcher struct {
@@ -624,6 +1108,9 @@ func (db *streamDatabase) Expand(buf []byte, flags ScanFlag, sc *Scratch,
 	var s hsStream
 
 	err := hsExpandStream(db.db, &s, buf)
+
+	runtime.KeepAlive(db)
+
 	if err != nil {
 		return nil, fmt.Errorf("expand stream, %w", err)
 	}
@@ -631,7 +1118,7 @@ func (db *streamDatabase) Expand(buf []byte, flags ScanFlag, sc *Scratch,
 	ownedScratch := false
 
 	if sc == nil {
-		sc, err = NewScratch(db)
+		sc, err = newScratch(db)
 		if err != nil {
 			return nil, fmt.Errorf("create scratch, %w", err)
 		}
@@ -639,7 +1126,13 @@ func (db *streamDatabase) Expand(buf []byte, flags ScanFlag, sc *Scratch,
 		ownedScratch = true
 	}
 
-	return &stream{s, flags, sc.s, hsMatchEventHandler(handler), context, ownedScratch}, nil
+	return trackStream(&stream{s, db.db, flags, sc.s, hsMatchEventHandler(handler), context, ownedScratch}), nil
+}
+
+// ExpandStream decompresses the stream state created by `CompressStream` into a new stream,
+// with a scratch owned by the stream.
+func ExpandStream(db StreamDatabase, buf []byte, handler MatchHandler, context interface{}) (Stream, error) {
+	return db.Expand(buf, 0, nil, handler, context) // nolint: wrapcheck
 }
 
 func (db *streamDatabase) ResetAndExpand(s Stream, buf []byte, flags ScanFlag, sc *Scratch,
@@ -654,7 +1147,7 @@ func (db *streamDatabase) ResetAndExpand(s Stream, buf []byte, flags ScanFlag, s
 	if sc == nil {
 		var err error
 
-		sc, err = NewScratch(db)
+		sc, err = newScratch(db)
 		if err != nil {
 			return nil, fmt.Errorf("create scratch, %w", err)
 		}
@@ -667,5 +1160,7 @@ func (db *streamDatabase) ResetAndExpand(s Stream, buf []byte, flags ScanFlag, s
 		return nil, fmt.Errorf("reset and expand stream, %w", err)
 	}
 
-	return &stream{ss.stream, flags, sc.s, hsMatchEventHandler(handler), context, ownedScratch}, nil
+	runtime.SetFinalizer(ss, nil)
+
+	return trackStream(&stream{ss.stream, db.db, flags, sc.s, hsMatchEventHandler(handler), context, ownedScratch}), nil
 }
@@ -2,8 +2,12 @@
 package hyperscan_test
 
 import (
+	"errors"
+	"io"
+	"net"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	. "github.com/smartystreets/goconvey/convey"
 
@@ -25,6 +29,84 @@ var streamDatabaseConstructors = map[string]StreamDatabaseConstructor{
 	"managed": hyperscan.NewManagedStreamDatabase,
 }
 
+func TestMultiScratch(t *testing.T) {
+	Convey("Given a block and a stream database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`, 0))
+		So(err, ShouldBeNil)
+
+		sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`bar\d+`, 0))
+		So(err, ShouldBeNil)
+
+		handler := func(id uint, from, to uint64, flags uint, context interface{}) error { return nil }
+
+		Convey("When allocate a scratch for both databases", func() {
+			s, err := hyperscan.NewMultiScratch(bdb, sdb)
+			So(err, ShouldBeNil)
+
+			Convey("Then it could be used for scanning each of them", func() {
+				So(bdb.Scan([]byte("foo"), s, handler, nil), ShouldBeNil)
+				So(sdb.Scan(strings.NewReader("bar123"), s, handler, nil), ShouldBeNil)
+			})
+
+			So(s.Free(), ShouldBeNil)
+		})
+
+		Convey("When grow a scratch with another database", func() {
+			s, err := hyperscan.NewScratch(bdb)
+			So(err, ShouldBeNil)
+
+			So(s.Realloc(sdb), ShouldBeNil)
+			So(bdb.Scan([]byte("foo"), s, handler, nil), ShouldBeNil)
+			So(sdb.Scan(strings.NewReader("bar123"), s, handler, nil), ShouldBeNil)
+
+			So(s.Free(), ShouldBeNil)
+		})
+
+		So(sdb.Close(), ShouldBeNil)
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
+
+func TestPrewarmScratch(t *testing.T) {
+	Convey("Given a block and a stream database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`, 0))
+		So(err, ShouldBeNil)
+
+		sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`bar\d+`, 0))
+		So(err, ShouldBeNil)
+
+		Convey("When prewarm the scratch spaces for them", func() {
+			scratches, total, err := hyperscan.PrewarmScratch(4, bdb, sdb)
+
+			So(err, ShouldBeNil)
+			So(scratches, ShouldHaveLength, 4)
+
+			size, err := scratches[0].Size()
+
+			So(err, ShouldBeNil)
+			So(total, ShouldEqual, size*4)
+
+			So(hyperscan.FreeScratches(scratches), ShouldBeNil)
+		})
+
+		Convey("When clone a scratch for several times", func() {
+			s, err := hyperscan.NewScratch(bdb)
+			So(err, ShouldBeNil)
+
+			clones, err := s.CloneN(3)
+
+			So(err, ShouldBeNil)
+			So(clones, ShouldHaveLength, 3)
+
+			So(hyperscan.FreeScratches(clones), ShouldBeNil)
+			So(s.Free(), ShouldBeNil)
+		})
+
+		So(sdb.Close(), ShouldBeNil)
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
+
 func TestBlockScanner(t *testing.T) {
 	for dbType, dbConstructor := range blockDatabaseConstructors {
 		Convey("Given a "+dbType+" block database", t, func() {
@@ -97,6 +179,80 @@ func TestBlockMatcher(t *testing.T) {
 	}
 }
 
+func TestBlockMatcherPatternIndex(t *testing.T) {
+	Convey("Given a block database with some patterns", t, func() {
+		digits := hyperscan.NewPattern(`\d+`, hyperscan.SomLeftMost)
+		digits.Id = 1
+		letters := hyperscan.NewPattern(`[a-z]+`, hyperscan.SomLeftMost)
+		letters.Id = 2
+
+		bdb, err := hyperscan.NewBlockDatabase(digits, letters)
+
+		So(err, ShouldBeNil)
+
+		Convey("When find all the matched string index by patterns", func() {
+			So(hyperscan.FindAllStringPatternIndex(bdb, "abc123def456", -1), ShouldResemble, map[uint][][]int{
+				1: {{3, 6}, {9, 12}},
+				2: {{0, 3}, {6, 9}},
+			})
+		})
+
+		Convey("When find the first 2 matched string index by patterns", func() {
+			So(hyperscan.FindAllStringPatternIndex(bdb, "abc123def456", 2), ShouldResemble, map[uint][][]int{
+				1: {{3, 6}},
+				2: {{0, 3}},
+			})
+		})
+
+		Convey("When find nothing", func() {
+			So(hyperscan.FindAllStringPatternIndex(bdb, "...", -1), ShouldBeNil)
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
+
+func TestBlockMatchFirst(t *testing.T) {
+	Convey("Given a block database", t, func() {
+		foo, bar := hyperscan.NewPattern(`foo`, hyperscan.SomLeftMost), hyperscan.NewPattern(`bar`, hyperscan.SomLeftMost)
+		foo.Id, bar.Id = 1, 2
+
+		bdb, err := hyperscan.NewBlockDatabase(foo, bar)
+		So(err, ShouldBeNil)
+
+		Convey("When match the first match", func() {
+			m, found, err := hyperscan.MatchFirst(bdb, []byte("abc bar foo"), nil)
+
+			So(err, ShouldBeNil)
+			So(found, ShouldBeTrue)
+			So(m, ShouldResemble, hyperscan.RecordedMatch{ID: 2, From: 4, To: 7})
+		})
+
+		Convey("When match the first match with a scratch", func() {
+			s, err := hyperscan.NewScratch(bdb)
+			So(err, ShouldBeNil)
+
+			for _, data := range []string{"foo", "abc bar"} {
+				_, found, err := hyperscan.MatchFirst(bdb, []byte(data), s)
+
+				So(err, ShouldBeNil)
+				So(found, ShouldBeTrue)
+			}
+
+			So(s.Free(), ShouldBeNil)
+		})
+
+		Convey("When nothing matched", func() {
+			_, found, err := hyperscan.MatchFirst(bdb, []byte("abc"), nil)
+
+			So(err, ShouldBeNil)
+			So(found, ShouldBeFalse)
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
+
 func TestStreamScanner(t *testing.T) {
 	for dbType, dbConstructor := range streamDatabaseConstructors {
 		Convey("Given a "+dbType+" streaming database", t, func() {
@@ -191,6 +347,131 @@ func TestStreamMatcher(t *testing.T) {
 	}
 }
 
+func TestStreamResetAndCopy(t *testing.T) {
+	Convey("Given a streaming database", t, func() {
+		sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`abc`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		var matches [][]uint64
+
+		matched := func(id uint, from, to uint64, flags uint, context interface{}) error {
+			matches = append(matches, []uint64{from, to})
+
+			return nil
+		}
+
+		Convey("When reset a stream and copy the state from another", func() {
+			src, err := sdb.Open(0, nil, matched, nil)
+			So(err, ShouldBeNil)
+
+			dst, err := sdb.Open(0, nil, matched, nil)
+			So(err, ShouldBeNil)
+
+			So(src.Scan([]byte("12a")), ShouldBeNil)
+			So(dst.Scan([]byte("xyz")), ShouldBeNil)
+
+			So(hyperscan.ResetAndCopyStream(dst, src), ShouldBeNil)
+			So(dst.Scan([]byte("bc")), ShouldBeNil)
+
+			Convey("Then the stream continues from the copied state", func() {
+				So(matches, ShouldResemble, [][]uint64{{2, 5}})
+			})
+
+			So(src.Close(), ShouldBeNil)
+			So(dst.Close(), ShouldBeNil)
+		})
+
+		So(sdb.Close(), ShouldBeNil)
+	})
+}
+
+func TestStreamWriter(t *testing.T) {
+	Convey("Given a streaming database", t, func() {
+		sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`abc`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		Convey("When copy the data into a stream", func() {
+			var matches [][]uint64
+
+			s, err := sdb.Open(0, nil, func(id uint, from, to uint64, flags uint, context interface{}) error {
+				matches = append(matches, []uint64{from, to})
+
+				return nil
+			}, nil)
+			So(err, ShouldBeNil)
+
+			w := hyperscan.NewStreamWriter(s)
+
+			n, err := io.Copy(w, strings.NewReader("12abc34abc"))
+
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 10)
+			So(w.Close(), ShouldBeNil)
+
+			So(matches, ShouldResemble, [][]uint64{{2, 5}, {7, 10}})
+		})
+
+		Convey("When copy the data into a wrapped stream", func() {
+			var matches [][]uint64
+
+			s, err := sdb.Open(0, nil, func(id uint, from, to uint64, flags uint, context interface{}) error {
+				matches = append(matches, []uint64{from, to})
+
+				return nil
+			}, nil)
+			So(err, ShouldBeNil)
+
+			w := hyperscan.NewStreamWriter(struct{ hyperscan.Stream }{s})
+
+			n, err := io.Copy(w, strings.NewReader("12abc34abc"))
+
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 10)
+			So(w.Close(), ShouldBeNil)
+
+			So(matches, ShouldResemble, [][]uint64{{2, 5}, {7, 10}})
+
+			Convey("Then the closed stream is not written or closed again", func() {
+				_, err := w.Write([]byte("abc"))
+
+				So(err, ShouldNotBeNil)
+				So(errors.Is(w.Close(), hyperscan.ErrInvalid), ShouldBeTrue)
+			})
+		})
+
+		So(sdb.Close(), ShouldBeNil)
+	})
+}
+
+func TestStreamScanReader(t *testing.T) {
+	Convey("Given a streaming database", t, func() {
+		sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`abc`, hyperscan.SomLeftMost),
+			hyperscan.NewPattern(`xyz$`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		Convey("When scan a reader returning the data with EOF", func() {
+			var matches [][]uint64
+
+			r := iotest.DataErrReader(strings.NewReader("12abc34abcxyz"))
+
+			stats, err := sdb.(hyperscan.ReaderScanner).ScanReader(r, nil,
+				func(id uint, from, to uint64, flags uint, context interface{}) error {
+					matches = append(matches, []uint64{from, to})
+
+					return nil
+				}, 4)
+
+			Convey("Then all the data and the end of data matches are scanned", func() {
+				So(err, ShouldBeNil)
+				So(stats, ShouldResemble, hyperscan.ReaderStats{Bytes: 13, Chunks: 4, Matches: 3})
+				So(matches, ShouldResemble, [][]uint64{{2, 5}, {7, 10}, {10, 13}})
+			})
+		})
+
+		So(sdb.Close(), ShouldBeNil)
+	})
+}
+
 func TestStreamCompressor(t *testing.T) {
 	for dbType, dbConstructor := range streamDatabaseConstructors {
 		Convey("Given a "+dbType+" streaming database", t, func() {
@@ -262,7 +543,319 @@ func TestStreamCompressor(t *testing.T) {
 						})
 					})
 				})
+
+				Convey("When compress the stream state itself", func() {
+					buf, err := hyperscan.CompressStream(stream)
+
+					So(err, ShouldBeNil)
+					So(buf, ShouldNotBeEmpty)
+
+					Convey("When expand it into a new stream", func() {
+						stream2, err := hyperscan.ExpandStream(sdb, buf, matched, nil)
+
+						So(err, ShouldBeNil)
+						So(stream2.Scan([]byte("bc")), ShouldBeNil)
+						So(stream2.Close(), ShouldBeNil)
+
+						So(matches, ShouldResemble, [][]uint64{{3, 6}})
+					})
+
+					Convey("When reset and expand it in place", func() {
+						So(stream.Scan([]byte("xyz")), ShouldBeNil)
+						So(hyperscan.ResetAndExpandStream(stream, buf), ShouldBeNil)
+						So(stream.Scan([]byte("bc")), ShouldBeNil)
+
+						So(matches, ShouldResemble, [][]uint64{{3, 6}})
+					})
+				})
 			})
 		})
 	}
 }
+
+func TestVectoredScanner(t *testing.T) {
+	Convey("Given a vectored database", t, func() {
+		vdb, err := hyperscan.NewVectoredDatabase(hyperscan.NewPattern(`foobar`, hyperscan.SomLeftMost))
+
+		So(err, ShouldBeNil)
+
+		Convey("When scan the net buffers with the empty blocks", func() {
+			var matches [][]uint64
+
+			bufs := net.Buffers{[]byte("abcfoo"), nil, {}, []byte("bar"), []byte("def")}
+
+			err := vdb.Scan(bufs, nil, func(id uint, from, to uint64, flags uint, context interface{}) error {
+				matches = append(matches, []uint64{from, to})
+
+				return nil
+			}, nil)
+
+			Convey("Then the match across the blocks is reported", func() {
+				So(err, ShouldBeNil)
+				So(matches, ShouldResemble, [][]uint64{{3, 9}})
+			})
+		})
+
+		Convey("When scan the strings", func() {
+			var matches [][]uint64
+
+			err := hyperscan.ScanStrings(vdb, []string{"abcfoo", "", "bar"}, nil,
+				func(id uint, from, to uint64, flags uint, context interface{}) error {
+					matches = append(matches, []uint64{from, to})
+
+					return nil
+				}, nil)
+
+			So(err, ShouldBeNil)
+			So(matches, ShouldResemble, [][]uint64{{3, 9}})
+		})
+
+		So(vdb.Close(), ShouldBeNil)
+	})
+}
+
+func TestBlockScanString(t *testing.T) {
+	Convey("Given a block database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo\d+`, hyperscan.SomLeftMost))
+
+		So(err, ShouldBeNil)
+
+		Convey("When scan a string", func() {
+			var matches [][]uint64
+
+			err := hyperscan.ScanString(bdb, "abc foo123", nil, func(id uint, from, to uint64, flags uint,
+				context interface{}) error {
+				matches = append(matches, []uint64{from, to})
+
+				return nil
+			}, nil)
+
+			So(err, ShouldBeNil)
+			So(matches, ShouldResemble, [][]uint64{{4, 8}, {4, 9}, {4, 10}})
+		})
+
+		Convey("When scan an empty string", func() {
+			So(hyperscan.ScanString(bdb, "", nil, func(id uint, from, to uint64, flags uint, context interface{}) error {
+				return nil
+			}, nil), ShouldBeNil)
+		})
+
+		Convey("When find the strings", func() {
+			So(bdb.FindString("abc foo123"), ShouldEqual, "foo123")
+			So(bdb.FindAllString("foo1 foo2", -1), ShouldResemble, []string{"foo1", "foo2"})
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
+
+func TestStreamScanString(t *testing.T) {
+	Convey("Given a stream database", t, func() {
+		sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`foobar`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		Convey("When scan the strings in a stream", func() {
+			var matches [][]uint64
+
+			s, err := sdb.Open(0, nil, func(id uint, from, to uint64, flags uint, context interface{}) error {
+				matches = append(matches, []uint64{from, to})
+
+				return nil
+			}, nil)
+			So(err, ShouldBeNil)
+
+			So(hyperscan.ScanStreamString(s, "abcfoo"), ShouldBeNil)
+			So(hyperscan.ScanStreamString(s, "bar"), ShouldBeNil)
+			So(s.Close(), ShouldBeNil)
+
+			So(matches, ShouldResemble, [][]uint64{{3, 9}})
+		})
+
+		So(sdb.Close(), ShouldBeNil)
+	})
+}
+
+func TestStreamClone(t *testing.T) {
+	Convey("Given a stream database", t, func() {
+		sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`foobar`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		Convey("When fork a stream after scanning the prefix", func() {
+			matches := make(map[interface{}][]uint64)
+
+			s, err := sdb.Open(0, nil, func(id uint, from, to uint64, flags uint, context interface{}) error {
+				matches[context] = append(matches[context], from, to)
+
+				return nil
+			}, "origin")
+			So(err, ShouldBeNil)
+
+			So(s.Scan([]byte("abcfoo")), ShouldBeNil)
+
+			forked, err := s.Clone()
+			So(err, ShouldBeNil)
+
+			So(hyperscan.SetStreamContext(forked, "forked"), ShouldBeNil)
+
+			Convey("Then both streams continue from the shared history", func() {
+				So(s.Scan([]byte("baz")), ShouldBeNil)
+				So(forked.Scan([]byte("bar")), ShouldBeNil)
+
+				So(s.Close(), ShouldBeNil)
+				So(forked.Close(), ShouldBeNil)
+
+				So(matches, ShouldResemble, map[interface{}][]uint64{"forked": {3, 9}})
+			})
+		})
+
+		So(sdb.Close(), ShouldBeNil)
+	})
+}
+
+func TestStreamReset(t *testing.T) {
+	Convey("Given a stream database", t, func() {
+		sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`foo$`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		Convey("When reset the stream for a new session", func() {
+			var matches []interface{}
+
+			s, err := sdb.Open(0, nil, func(id uint, from, to uint64, flags uint, context interface{}) error {
+				matches = append(matches, context, from, to)
+
+				return nil
+			}, "s1")
+			So(err, ShouldBeNil)
+
+			So(s.Scan([]byte("foo")), ShouldBeNil)
+			So(hyperscan.ResetStreamWith(s, "s2"), ShouldBeNil)
+
+			So(s.Scan([]byte("xfoo")), ShouldBeNil)
+			So(s.Close(), ShouldBeNil)
+
+			Convey("Then the end of data matches are reported to the sessions in turn", func() {
+				So(matches, ShouldResemble, []interface{}{"s1", uint64(0), uint64(3), "s2", uint64(1), uint64(4)})
+			})
+		})
+
+		So(sdb.Close(), ShouldBeNil)
+	})
+}
+
+func TestStreamCloseWith(t *testing.T) {
+	Convey("Given a stream database", t, func() {
+		sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`foo$`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		scratch, err := hyperscan.NewScratch(sdb)
+		So(err, ShouldBeNil)
+
+		Convey("When close the stream with the scratch of worker", func() {
+			var matches [][]uint64
+
+			s, err := sdb.Open(0, nil, func(id uint, from, to uint64, flags uint, context interface{}) error {
+				matches = append(matches, []uint64{from, to})
+
+				return nil
+			}, nil)
+			So(err, ShouldBeNil)
+
+			So(s.Scan([]byte("xfoo")), ShouldBeNil)
+			So(hyperscan.CloseStreamWith(s, scratch), ShouldBeNil)
+
+			Convey("Then the end of data matches are reported", func() {
+				So(matches, ShouldResemble, [][]uint64{{1, 4}})
+			})
+		})
+
+		Convey("When close the stream twice", func() {
+			s, err := sdb.Open(0, nil, func(id uint, from, to uint64, flags uint, context interface{}) error {
+				return nil
+			}, nil)
+			So(err, ShouldBeNil)
+
+			So(hyperscan.CloseStreamWith(s, scratch), ShouldBeNil)
+
+			live := hyperscan.ScratchMetrics().Live
+
+			Convey("Then the owned scratch is not freed again", func() {
+				So(errors.Is(s.Close(), hyperscan.ErrInvalid), ShouldBeTrue)
+				So(errors.Is(hyperscan.CloseStreamWith(s, scratch), hyperscan.ErrInvalid), ShouldBeTrue)
+				So(hyperscan.ScratchMetrics().Live, ShouldEqual, live)
+			})
+		})
+
+		So(scratch.Free(), ShouldBeNil)
+		So(sdb.Close(), ShouldBeNil)
+	})
+}
+
+func TestStreamContext(t *testing.T) {
+	Convey("Given a stream database", t, func() {
+		sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`foo$`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		Convey("When open a stream with the user data", func() {
+			var contexts []interface{}
+
+			s, err := sdb.Open(0, nil, func(id uint, from, to uint64, flags uint, context interface{}) error {
+				contexts = append(contexts, context)
+
+				return nil
+			}, "conn-1")
+			So(err, ShouldBeNil)
+			So(hyperscan.StreamContext(s), ShouldEqual, "conn-1")
+
+			Convey("Then the user data is passed to the handler for every match", func() {
+				So(s.Scan([]byte("foo")), ShouldBeNil)
+				So(s.Reset(), ShouldBeNil)
+
+				So(hyperscan.SetStreamContext(s, "conn-2"), ShouldBeNil)
+				So(hyperscan.StreamContext(s), ShouldEqual, "conn-2")
+
+				So(s.Scan([]byte("foo")), ShouldBeNil)
+				So(s.Close(), ShouldBeNil)
+
+				So(contexts, ShouldResemble, []interface{}{"conn-1", "conn-2"})
+			})
+		})
+
+		So(sdb.Close(), ShouldBeNil)
+	})
+}
+
+func TestScanTermination(t *testing.T) {
+	Convey("Given a block database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`, 0))
+		So(err, ShouldBeNil)
+
+		data := []byte("foo foo")
+
+		Convey("When the handler requests to stop the scanning", func() {
+			err := bdb.Scan(data, nil, func(id uint, from, to uint64, flags uint, context interface{}) error {
+				return hyperscan.ErrScanTerminated
+			}, nil)
+
+			Convey("Then the scanning was terminated without handler error", func() {
+				So(err, ShouldEqual, hyperscan.ErrScanTerminated)
+				So(hyperscan.IsHandlerError(err), ShouldBeFalse)
+			})
+		})
+
+		Convey("When the handler fails with an error", func() {
+			errHandler := errors.New("handler failed")
+
+			err := bdb.Scan(data, nil, func(id uint, from, to uint64, flags uint, context interface{}) error {
+				return errHandler
+			}, nil)
+
+			Convey("Then the error of handler was returned", func() {
+				So(hyperscan.IsHandlerError(err), ShouldBeTrue)
+				So(errors.Is(err, errHandler), ShouldBeTrue)
+				So(errors.Is(err, hyperscan.ErrScanTerminated), ShouldBeTrue)
+			})
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
@@ -0,0 +1,144 @@
+package hyperscan
+
+import (
+	"fmt"
+	"sync"
+)
+
+// SafeMatcher is a matcher of block database which is safe for concurrent use.
+//
+// It takes a scratch from an internal pool for each call, which costs a little more than
+// scanning with a dedicated scratch; use the database with the manually managed scratch
+// spaces for the peak performance.
+//
+// The methods without an error result, such as Match and Find, report no match if failed to get a scratch,
+// the error is recorded and returned by Err.
+type SafeMatcher struct {
+	scanner *blockScanner
+	pool    *ScratchPool
+
+	mu  sync.Mutex
+	err error
+}
+
+// NewSafeMatcher returns a matcher of the block database which is safe for concurrent use,
+// the database should be kept open until the matcher is closed.
+//
+// The database should be compiled or unmarshaled by the package rather than a wrapper of it,
+// such as `AutoScratchDatabase`, `ErrUnexpected` otherwise.
+func NewSafeMatcher(db BlockDatabase) (*SafeMatcher, error) {
+	bdb, ok := db.(*blockDatabase)
+	if !ok {
+		return nil, fmt.Errorf("database %v, %w", db, ErrUnexpected)
+	}
+
+	pool, err := NewScratchPool(db)
+	if err != nil {
+		return nil, fmt.Errorf("create scratch pool, %w", err)
+	}
+
+	return &SafeMatcher{scanner: bdb.blockScanner, pool: pool}, nil
+}
+
+// Err returns the last error of getting a scratch from the pool, nil if none.
+func (m *SafeMatcher) Err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.err
+}
+
+func (m *SafeMatcher) with(f func(*blockMatcher)) error {
+	s, err := m.pool.Get()
+	if err != nil {
+		err = fmt.Errorf("get scratch, %w", err)
+
+		m.mu.Lock()
+		m.err = err
+		m.mu.Unlock()
+
+		return err
+	}
+
+	defer m.pool.Put(s)
+
+	bm := newBlockMatcher(m.scanner)
+	bm.scratch = s
+
+	f(bm)
+
+	return nil
+}
+
+// Scan the data with a pooled scratch.
+func (m *SafeMatcher) Scan(data []byte, handler MatchHandler, context interface{}) (err error) {
+	if e := m.with(func(bm *blockMatcher) { err = bm.Scan(data, bm.scratch, handler, context) }); e != nil {
+		return e
+	}
+
+	return
+}
+
+// ScanString scans the string with a pooled scratch.
+func (m *SafeMatcher) ScanString(s string, handler MatchHandler, context interface{}) error {
+	return m.Scan(stringBytes(s), handler, context)
+}
+
+// Match reports whether the database matches the data.
+func (m *SafeMatcher) Match(data []byte) (matched bool) {
+	_ = m.with(func(bm *blockMatcher) { matched = bm.Match(data) })
+
+	return
+}
+
+// MatchString reports whether the database matches the string.
+func (m *SafeMatcher) MatchString(s string) bool { return m.Match(stringBytes(s)) }
+
+// Find returns the text of the leftmost match in data, nil indicates no match.
+func (m *SafeMatcher) Find(data []byte) (match []byte) {
+	_ = m.with(func(bm *blockMatcher) { match = bm.Find(data) })
+
+	return
+}
+
+// FindIndex returns the location of the leftmost match in data, nil indicates no match.
+func (m *SafeMatcher) FindIndex(data []byte) (loc []int) {
+	_ = m.with(func(bm *blockMatcher) { loc = bm.FindIndex(data) })
+
+	return
+}
+
+// FindAll returns at most n successive matches in data, or all of them if n is negative.
+func (m *SafeMatcher) FindAll(data []byte, n int) (matches [][]byte) {
+	_ = m.with(func(bm *blockMatcher) { matches = bm.FindAll(data, n) })
+
+	return
+}
+
+// FindAllIndex returns the locations of at most n successive matches in data, or all of them if n is negative.
+func (m *SafeMatcher) FindAllIndex(data []byte, n int) (locs [][]int) {
+	_ = m.with(func(bm *blockMatcher) { locs = bm.FindAllIndex(data, n) })
+
+	return
+}
+
+// FindString returns the text of the leftmost match in s, or an empty string if no match.
+func (m *SafeMatcher) FindString(s string) string {
+	if loc := m.FindIndex(stringBytes(s)); len(loc) == findIndexMatches {
+		return s[loc[0]:loc[1]]
+	}
+
+	return ""
+}
+
+// FindAllString returns at most n successive matches in s, or all of them if n is negative.
+func (m *SafeMatcher) FindAllString(s string, n int) (results []string) {
+	for _, loc := range m.FindAllIndex(stringBytes(s), n) {
+		results = append(results, s[loc[0]:loc[1]])
+	}
+
+	return
+}
+
+// Close frees the scratch spaces of the matcher, the database is not closed.
+func (m *SafeMatcher) Close() error { return m.pool.Close() }
@@ -0,0 +1,83 @@
+package hyperscan_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestSafeMatcher(t *testing.T) {
+	Convey("Given a safe matcher", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo\d+`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		m, err := hyperscan.NewSafeMatcher(bdb)
+		So(err, ShouldBeNil)
+
+		Convey("When match the data", func() {
+			So(m.MatchString("abc foo123"), ShouldBeTrue)
+			So(m.MatchString("abc"), ShouldBeFalse)
+			So(m.FindString("abc foo123"), ShouldEqual, "foo123")
+			So(m.FindIndex([]byte("abc foo1")), ShouldResemble, []int{4, 8})
+			So(m.FindAllString("foo1 foo2", -1), ShouldResemble, []string{"foo1", "foo2"})
+			So(m.Err(), ShouldBeNil)
+		})
+
+		Convey("When use it concurrently", func() {
+			var wg sync.WaitGroup
+
+			results := make(chan bool, 16)
+
+			for i := 0; i < 16; i++ {
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					results <- m.Match([]byte("foo42"))
+				}()
+			}
+
+			wg.Wait()
+			close(results)
+
+			for matched := range results {
+				So(matched, ShouldBeTrue)
+			}
+		})
+
+		Convey("When use it after closed", func() {
+			So(m.Close(), ShouldBeNil)
+
+			Convey("Then the error is returned or recorded", func() {
+				So(errors.Is(m.Scan([]byte("foo1"), func(id uint, from, to uint64, flags uint,
+					context interface{}) error {
+					return nil
+				}, nil), hyperscan.ErrInvalid), ShouldBeTrue)
+
+				So(m.Match([]byte("foo1")), ShouldBeFalse)
+				So(errors.Is(m.Err(), hyperscan.ErrInvalid), ShouldBeTrue)
+			})
+		})
+
+		So(m.Close(), ShouldBeNil)
+		So(bdb.Close(), ShouldBeNil)
+	})
+
+	Convey("Given a wrapped block database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo\d+`))
+		So(err, ShouldBeNil)
+
+		db, err := hyperscan.NewAutoScratchDatabase(bdb)
+		So(err, ShouldBeNil)
+
+		_, err = hyperscan.NewSafeMatcher(db)
+		So(errors.Is(err, hyperscan.ErrUnexpected), ShouldBeTrue)
+
+		So(db.Close(), ShouldBeNil)
+	})
+}
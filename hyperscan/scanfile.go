@@ -0,0 +1,99 @@
+package hyperscan
+
+import (
+	"fmt"
+	"os"
+)
+
+// DefaultFileChunkSize is the default size above which the file is scanned in chunks.
+const DefaultFileChunkSize = 64 << 20
+
+// maxFileChunkSize is the largest chunk could be mapped or read at once on the platform.
+const maxFileChunkSize = int64(^uint(0) >> 1)
+
+// FileScanner is the optional interface implemented by the databases
+// which could scan the file without reading it fully into memory, such as the block databases compiled by the package.
+type FileScanner interface {
+	// ScanFile scans the file in place, and the file larger than the chunk size in chunks of it.
+	ScanFile(path string, scratch *Scratch, handler MatchHandler, context interface{}, chunkSize int64) error
+}
+
+var _ FileScanner = (*blockDatabase)(nil)
+
+// ScanFile maps the file in read-only mode and scans it in place,
+// or reads it if memory-mapped files are not supported on the platform.
+//
+// The file larger than the chunk size is scanned in chunks of it with a stream database compiled from
+// the same patterns on first use, so the matches across the chunks are reported, and only one chunk is mapped
+// at a time. Like `ScanReader` it returns `ErrNoFound` for the larger file with the unmarshaled databases,
+// and the scratch is only used for the smaller file. Zero or negative chunk size means `DefaultFileChunkSize`.
+func (db *blockDatabase) ScanFile(path string, scratch *Scratch, handler MatchHandler, context interface{},
+	chunkSize int64) error {
+	if chunkSize <= 0 {
+		chunkSize = DefaultFileChunkSize
+	} else if chunkSize > maxFileChunkSize {
+		chunkSize = maxFileChunkSize
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open file, %w", err)
+	}
+
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("stat file, %w", err)
+	}
+
+	size := fi.Size()
+
+	if size == 0 {
+		return db.Scan([]byte{}, scratch, handler, context)
+	}
+
+	if size <= chunkSize {
+		return scanFileChunk(f, 0, int(size), func(data []byte) error {
+			return db.Scan(data, scratch, handler, context)
+		})
+	}
+
+	return db.scanFileChunks(f, size, handler, context, chunkSize)
+}
+
+// scanFileChunks scans the file in chunks with the stream database compiled from the same patterns.
+func (db *blockDatabase) scanFileChunks(f *os.File, size int64, handler MatchHandler, context interface{},
+	chunkSize int64) error {
+	derived, err := db.streamDatabase()
+	if err != nil {
+		return err
+	}
+
+	scratch, err := derived.pool.Get()
+	if err != nil {
+		return fmt.Errorf("get scratch, %w", err)
+	}
+
+	defer derived.pool.Put(scratch)
+
+	s, err := derived.db.Open(0, scratch, handler, context)
+	if err != nil {
+		return fmt.Errorf("open stream, %w", err)
+	}
+
+	for off := int64(0); off < size; off += chunkSize {
+		n := size - off
+		if n > chunkSize {
+			n = chunkSize
+		}
+
+		if err = scanFileChunk(f, off, int(n), s.Scan); err != nil {
+			_ = s.Close()
+
+			return err
+		}
+	}
+
+	return s.Close() // nolint: wrapcheck
+}
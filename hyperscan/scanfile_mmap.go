@@ -0,0 +1,27 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package hyperscan
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// scanFileChunk maps the chunk of file at the offset in read-only mode, and scans it in place.
+func scanFileChunk(f *os.File, off int64, n int, scan func([]byte) error) error {
+	// the offset of mapping must be a multiple of the page size.
+	skip := int(off % int64(os.Getpagesize()))
+
+	data, err := syscall.Mmap(int(f.Fd()), off-int64(skip), skip+n, syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return fmt.Errorf("map file, %w", err)
+	}
+
+	defer func() {
+		_ = syscall.Munmap(data)
+	}()
+
+	return scan(data[skip:])
+}
@@ -0,0 +1,22 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package hyperscan
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// scanFileChunk reads the chunk of file at the offset and scans it,
+// since memory-mapped files are not supported on the platform.
+func scanFileChunk(f *os.File, off int64, n int, scan func([]byte) error) error {
+	data := make([]byte, n)
+
+	if _, err := io.ReadFull(io.NewSectionReader(f, off, int64(n)), data); err != nil {
+		return fmt.Errorf("read file, %w", err)
+	}
+
+	return scan(data)
+}
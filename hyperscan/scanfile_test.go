@@ -0,0 +1,109 @@
+package hyperscan_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestScanFile(t *testing.T) {
+	Convey("Given a block database", t, func() {
+		dir, err := ioutil.TempDir("", "gohs")
+
+		So(err, ShouldBeNil)
+
+		defer os.RemoveAll(dir)
+
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`, hyperscan.SomLeftMost))
+
+		So(err, ShouldBeNil)
+
+		scanner, ok := bdb.(hyperscan.FileScanner)
+
+		So(ok, ShouldBeTrue)
+
+		var matches [][]uint64
+
+		handler := func(id uint, from, to uint64, flags uint, context interface{}) error {
+			matches = append(matches, []uint64{from, to})
+
+			return nil
+		}
+
+		Convey("When scan a file", func() {
+			path := filepath.Join(dir, "data.txt")
+
+			So(ioutil.WriteFile(path, []byte("abc foo bar foo"), 0o644), ShouldBeNil)
+			So(scanner.ScanFile(path, nil, handler, nil, 0), ShouldBeNil)
+
+			So(matches, ShouldResemble, [][]uint64{{4, 7}, {12, 15}})
+		})
+
+		Convey("When scan an empty file", func() {
+			path := filepath.Join(dir, "empty.txt")
+
+			So(ioutil.WriteFile(path, nil, 0o644), ShouldBeNil)
+			So(scanner.ScanFile(path, nil, handler, nil, 0), ShouldBeNil)
+
+			So(matches, ShouldBeEmpty)
+		})
+
+		Convey("When scan a missing file", func() {
+			So(scanner.ScanFile(filepath.Join(dir, "missing.txt"), nil, handler, nil, 0), ShouldNotBeNil)
+		})
+
+		Convey("When scan a file larger than the chunk size", func() {
+			path := filepath.Join(dir, "large.txt")
+
+			So(ioutil.WriteFile(path, []byte("abc foo bar foo"), 0o644), ShouldBeNil)
+
+			Convey("Then it is scanned in chunks with the stream database", func() {
+				So(scanner.ScanFile(path, nil, handler, nil, 4), ShouldBeNil)
+				So(matches, ShouldResemble, [][]uint64{{4, 7}, {12, 15}})
+			})
+
+			Convey("Then it is scanned in chunks larger than the page", func() {
+				data := make([]byte, 3*os.Getpagesize())
+				copy(data[os.Getpagesize()-1:], "foo")
+
+				So(ioutil.WriteFile(path, data, 0o644), ShouldBeNil)
+				So(scanner.ScanFile(path, nil, handler, nil, int64(os.Getpagesize()+1)), ShouldBeNil)
+
+				n := uint64(os.Getpagesize())
+
+				So(matches, ShouldResemble, [][]uint64{{n - 1, n + 2}})
+			})
+		})
+
+		Convey("When scan a file with an unmarshaled database", func() {
+			path := filepath.Join(dir, "data.txt")
+
+			So(ioutil.WriteFile(path, []byte("abc foo"), 0o644), ShouldBeNil)
+
+			data, err := bdb.Marshal()
+			So(err, ShouldBeNil)
+
+			db, err := hyperscan.UnmarshalBlockDatabase(data)
+			So(err, ShouldBeNil)
+
+			So(db.(hyperscan.FileScanner).ScanFile(path, nil, handler, nil, 0), ShouldBeNil)
+			So(matches, ShouldResemble, [][]uint64{{4, 7}})
+
+			Convey("Then the larger file is not scanned in chunks", func() {
+				err := db.(hyperscan.FileScanner).ScanFile(path, nil, handler, nil, 4)
+
+				So(errors.Is(err, hyperscan.ErrNoFound), ShouldBeTrue)
+			})
+
+			So(db.Close(), ShouldBeNil)
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
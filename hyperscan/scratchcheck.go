@@ -0,0 +1,41 @@
+package hyperscan
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	scratchCheck int32
+	scratchInUse sync.Map // the scratch spaces being used by the scanning.
+)
+
+// SetScratchCheck enables or disables detecting the scratch space used by multiple goroutines concurrently.
+//
+// It's a debug mode, the scanning fails with a descriptive `ErrScratchInUse` error
+// instead of corrupting the memory inside Hyperscan, at the cost of tracking the scratch spaces in use.
+func SetScratchCheck(enabled bool) {
+	var v int32
+
+	if enabled {
+		v = 1
+	}
+
+	atomic.StoreInt32(&scratchCheck, v)
+}
+
+func releaseNothing() {}
+
+// acquireScratch marks the scratch space in use, and returns the function to release it.
+func acquireScratch(s hsScratch) (func(), error) {
+	if s == nil || atomic.LoadInt32(&scratchCheck) == 0 {
+		return releaseNothing, nil
+	}
+
+	if _, loaded := scratchInUse.LoadOrStore(s, struct{}{}); loaded {
+		return nil, fmt.Errorf("scratch %p is being used by another goroutine concurrently, %w", s, ErrScratchInUse)
+	}
+
+	return func() { scratchInUse.Delete(s) }, nil
+}
@@ -0,0 +1,52 @@
+package hyperscan_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestScratchCheck(t *testing.T) {
+	Convey("Given a block database and a scratch", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`, 0))
+		So(err, ShouldBeNil)
+
+		s, err := hyperscan.NewScratch(bdb)
+		So(err, ShouldBeNil)
+
+		hyperscan.SetScratchCheck(true)
+
+		Convey("When the scratch is used while scanning with it", func() {
+			var nested error
+
+			err := bdb.Scan([]byte("foo"), s, func(id uint, from, to uint64, flags uint, context interface{}) error {
+				nested = bdb.Scan([]byte("foo"), s, func(id uint, from, to uint64, flags uint,
+					context interface{}) error {
+					return nil
+				}, nil)
+
+				return nil
+			}, nil)
+
+			Convey("Then the misuse is detected", func() {
+				So(err, ShouldBeNil)
+				So(errors.Is(nested, hyperscan.ErrScratchInUse), ShouldBeTrue)
+			})
+		})
+
+		Convey("When the scratch is used in sequence", func() {
+			handler := func(id uint, from, to uint64, flags uint, context interface{}) error { return nil }
+
+			So(bdb.Scan([]byte("foo"), s, handler, nil), ShouldBeNil)
+			So(bdb.Scan([]byte("foo"), s, handler, nil), ShouldBeNil)
+		})
+
+		hyperscan.SetScratchCheck(false)
+
+		So(s.Free(), ShouldBeNil)
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
@@ -0,0 +1,47 @@
+package hyperscan
+
+import "sync/atomic"
+
+// ScratchStats is the statistics of the scratch spaces in the process.
+type ScratchStats struct {
+	// Live is the number of scratch spaces allocated and not freed yet.
+	Live int64
+	// Peak is the high-water mark of the live scratch spaces.
+	Peak int64
+	// Allocated is the number of scratch spaces allocated or cloned ever.
+	Allocated int64
+}
+
+var scratchStats struct {
+	live, peak, allocated int64
+}
+
+func scratchAllocated() {
+	atomic.AddInt64(&scratchStats.allocated, 1)
+
+	live := atomic.AddInt64(&scratchStats.live, 1)
+
+	for {
+		peak := atomic.LoadInt64(&scratchStats.peak)
+		if live <= peak || atomic.CompareAndSwapInt64(&scratchStats.peak, peak, live) {
+			return
+		}
+	}
+}
+
+func scratchFreed() { atomic.AddInt64(&scratchStats.live, -1) }
+
+// ScratchMetrics returns the statistics of the scratch spaces in the process,
+// use `Scratch.Size` for the footprint of each.
+func ScratchMetrics() ScratchStats {
+	return ScratchStats{
+		Live:      atomic.LoadInt64(&scratchStats.live),
+		Peak:      atomic.LoadInt64(&scratchStats.peak),
+		Allocated: atomic.LoadInt64(&scratchStats.allocated),
+	}
+}
+
+// ResetScratchPeak resets the high-water mark to the number of live scratch spaces.
+func ResetScratchPeak() {
+	atomic.StoreInt64(&scratchStats.peak, atomic.LoadInt64(&scratchStats.live))
+}
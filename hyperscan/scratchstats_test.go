@@ -0,0 +1,66 @@
+package hyperscan_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestScratchMetrics(t *testing.T) {
+	Convey("Given a block database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`, 0))
+		So(err, ShouldBeNil)
+
+		hyperscan.ResetScratchPeak()
+		before := hyperscan.ScratchMetrics()
+
+		Convey("When allocate and clone the scratch spaces", func() {
+			s, err := hyperscan.NewScratch(bdb)
+			So(err, ShouldBeNil)
+
+			size, err := s.Size()
+			So(err, ShouldBeNil)
+			So(size, ShouldBeGreaterThan, 0)
+
+			cloned, err := s.Clone()
+			So(err, ShouldBeNil)
+
+			stats := hyperscan.ScratchMetrics()
+
+			So(stats.Allocated-before.Allocated, ShouldBeGreaterThanOrEqualTo, 2)
+			So(stats.Peak, ShouldBeGreaterThanOrEqualTo, stats.Live)
+
+			Convey("Then the live scratch spaces are decreased when freed", func() {
+				So(cloned.Free(), ShouldBeNil)
+				So(s.Free(), ShouldBeNil)
+
+				freed := hyperscan.ScratchMetrics()
+
+				So(stats.Live-freed.Live, ShouldBeGreaterThanOrEqualTo, 2)
+				So(freed.Peak, ShouldBeGreaterThanOrEqualTo, stats.Live)
+			})
+		})
+
+		Convey("When get a scratch space from a pool", func() {
+			pool, err := hyperscan.NewScratchPool(bdb)
+			So(err, ShouldBeNil)
+
+			s, err := pool.Get()
+			So(err, ShouldBeNil)
+
+			Convey("Then it is counted as allocated", func() {
+				stats := hyperscan.ScratchMetrics()
+
+				So(stats.Allocated-before.Allocated, ShouldBeGreaterThanOrEqualTo, 2)
+				So(stats.Live, ShouldBeGreaterThan, 0)
+			})
+
+			pool.Put(s)
+			So(pool.Close(), ShouldBeNil)
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
@@ -0,0 +1,386 @@
+package hyperscan
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// EvictHook is called after the stream of the session was closed or compressed into the store,
+// with the error of it.
+type EvictHook func(id interface{}, err error)
+
+type session struct {
+	id     interface{}
+	stream Stream
+	active time.Time
+}
+
+// StreamSessions manages many logical streams keyed by the session ID, such as a connection or a flow tuple.
+//
+// The streams are opened on demand, and the handler of them is called with the session ID as the context.
+// The idle or least recently used streams are evicted by the policies, they are compressed into the Store if any,
+// and expanded transparently on the next chunk, otherwise they are closed with the end of data matches reported.
+//
+// The streams are scanned and evicted with the lock of the sessions held,
+// so the handler and the hook must not call the sessions.
+type StreamSessions struct {
+	db       StreamDatabase
+	handler  MatchHandler
+	handlers map[interface{}]MatchHandler
+	scratch  *Scratch
+
+	// IdleTimeout is the duration after which the streams without activity are evicted by Sweep.
+	IdleTimeout time.Duration
+
+	// MaxStreams limits the number of streams in memory if positive,
+	// the least recently used streams are evicted when opening or expanding one over the limit.
+	MaxStreams int
+
+	// Store keeps the evicted streams compressed by the session ID formatted as %v if not nil.
+	Store StreamStore
+
+	// OnEvict is called after a stream was closed or compressed into the store, if not nil.
+	OnEvict EvictHook
+
+	mu      sync.Mutex
+	streams map[interface{}]*list.Element
+	lru     *list.List
+}
+
+// NewStreamSessions returns the sessions of the streams of the database, with the default handler of them.
+func NewStreamSessions(db StreamDatabase, handler MatchHandler) (*StreamSessions, error) {
+	scratch, err := NewScratch(db)
+	if err != nil {
+		return nil, fmt.Errorf("create scratch, %w", err)
+	}
+
+	return &StreamSessions{
+		db:       db,
+		handler:  handler,
+		handlers: make(map[interface{}]MatchHandler),
+		scratch:  scratch,
+		streams:  make(map[interface{}]*list.Element),
+		lru:      list.New(),
+	}, nil
+}
+
+func (m *StreamSessions) handlerOf(id interface{}) MatchHandler {
+	if handler, exists := m.handlers[id]; exists {
+		return handler
+	}
+
+	return m.handler
+}
+
+// load returns the compressed stream state of the session from the store, or nil if not found.
+func (m *StreamSessions) load(id interface{}) ([]byte, error) {
+	if m.Store == nil {
+		return nil, nil
+	}
+
+	state, err := m.Store.Get(fmt.Sprint(id))
+	if err != nil {
+		return nil, fmt.Errorf("get stream %v, %w", id, err)
+	}
+
+	return state, nil
+}
+
+// expand the compressed stream state of the session, and removes it from the store.
+func (m *StreamSessions) expand(id interface{}, state []byte) (Stream, error) {
+	s, err := m.db.Expand(state, 0, m.scratch, m.handlerOf(id), id)
+	if err != nil {
+		return nil, fmt.Errorf("expand stream %v, %w", id, err)
+	}
+
+	if err = m.Store.Delete(fmt.Sprint(id)); err != nil {
+		_ = discardStream(s)
+
+		return nil, fmt.Errorf("delete stream %v, %w", id, err)
+	}
+
+	return s, nil
+}
+
+// stream returns the stream of the session in memory, expanded from the store or opened if not exists.
+func (m *StreamSessions) stream(id interface{}) (*session, error) {
+	if e, exists := m.streams[id]; exists {
+		m.lru.MoveToFront(e)
+
+		s, _ := e.Value.(*session)
+		s.active = time.Now()
+
+		return s, nil
+	}
+
+	for m.MaxStreams > 0 && m.lru.Len() >= m.MaxStreams {
+		// the error of closing the stream is reported to the hook, only the stream failed to compress is kept.
+		if err := m.evict(m.lru.Back()); err != nil && m.Store != nil {
+			return nil, err
+		}
+	}
+
+	state, err := m.load(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var st Stream
+
+	if state != nil {
+		if st, err = m.expand(id, state); err != nil {
+			return nil, err
+		}
+	} else if st, err = m.db.Open(0, m.scratch, m.handlerOf(id), id); err != nil {
+		return nil, fmt.Errorf("open stream %v, %w", id, err)
+	}
+
+	s := &session{id, st, time.Now()}
+	m.streams[id] = m.lru.PushFront(s)
+
+	return s, nil
+}
+
+func (m *StreamSessions) evicted(id interface{}, err error) {
+	if m.OnEvict != nil {
+		m.OnEvict(id, err)
+	}
+}
+
+// close the stream of the session in memory, the end of data matches are reported to the handler.
+func (m *StreamSessions) close(e *list.Element) error {
+	s, _ := m.lru.Remove(e).(*session)
+
+	delete(m.streams, s.id)
+	delete(m.handlers, s.id)
+
+	err := s.stream.Close()
+
+	m.evicted(s.id, err)
+
+	return err // nolint: wrapcheck
+}
+
+// evict compresses the stream of the session into the store if any, otherwise closes it.
+func (m *StreamSessions) evict(e *list.Element) error {
+	if m.Store == nil {
+		return m.close(e)
+	}
+
+	s, _ := e.Value.(*session)
+
+	state, err := CompressStream(s.stream)
+	if err != nil {
+		return fmt.Errorf("compress stream %v, %w", s.id, err)
+	}
+
+	if err = m.Store.Put(fmt.Sprint(s.id), state); err != nil {
+		return fmt.Errorf("put stream %v, %w", s.id, err)
+	}
+
+	m.lru.Remove(e)
+	delete(m.streams, s.id)
+
+	err = discardStream(s.stream)
+
+	m.evicted(s.id, err)
+
+	return err
+}
+
+// Open opens the stream of the session with its own handler.
+func (m *StreamSessions) Open(id interface{}, handler MatchHandler) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.streams[id]; exists {
+		return fmt.Errorf("stream %v, %w", id, ErrInvalid)
+	}
+
+	state, err := m.load(id)
+	if err != nil {
+		return err
+	}
+
+	if state != nil {
+		return fmt.Errorf("stream %v, %w", id, ErrInvalid)
+	}
+
+	m.handlers[id] = handler
+
+	if _, err = m.stream(id); err != nil {
+		delete(m.handlers, id)
+
+		return err
+	}
+
+	return nil
+}
+
+// Scan the next chunk of the session, the stream is expanded from the store,
+// or opened with the default handler if not exists.
+func (m *StreamSessions) Scan(id interface{}, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, err := m.stream(id)
+	if err != nil {
+		return err
+	}
+
+	return s.stream.Scan(data) // nolint: wrapcheck
+}
+
+// Has reports whether the stream of the session is in memory.
+func (m *StreamSessions) Has(id interface{}) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, exists := m.streams[id]
+
+	return exists
+}
+
+// Len returns the number of the streams in memory.
+func (m *StreamSessions) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.streams)
+}
+
+// Evict compresses the stream of the session into the store if any, otherwise closes it, if it's in memory.
+func (m *StreamSessions) Evict(id interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, exists := m.streams[id]; exists {
+		return m.evict(e)
+	}
+
+	return nil
+}
+
+// Sweep evicts the streams idle longer than the IdleTimeout, and returns the number of them with the first error.
+func (m *StreamSessions) Sweep() (n int, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deadline := time.Now().Add(-m.IdleTimeout)
+
+	// the streams are ordered by the last activity from the back.
+	for elem := m.lru.Back(); elem != nil; {
+		prev := elem.Prev()
+
+		s, _ := elem.Value.(*session)
+		if s.active.After(deadline) {
+			break
+		}
+
+		if e := m.evict(elem); e != nil && err == nil {
+			err = e
+		}
+
+		if _, exists := m.streams[s.id]; !exists {
+			n++
+		}
+
+		elem = prev
+	}
+
+	return
+}
+
+// Run sweeps the idle streams in the interval until the context is done.
+func (m *StreamSessions) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err() // nolint: wrapcheck
+		case <-ticker.C:
+			_, _ = m.Sweep()
+		}
+	}
+}
+
+// Close the stream of the session, the end of data matches are reported to the handler,
+// the stream in the store is expanded to report them, and nothing happens for an unknown session.
+func (m *StreamSessions) Close(id interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, exists := m.streams[id]; exists {
+		return m.close(e)
+	}
+
+	state, err := m.load(id)
+	if err != nil {
+		return err
+	}
+
+	if state == nil {
+		delete(m.handlers, id)
+
+		return nil
+	}
+
+	s, err := m.expand(id, state)
+	if err != nil {
+		return err
+	}
+
+	delete(m.handlers, id)
+
+	err = s.Close()
+
+	m.evicted(id, err)
+
+	return err // nolint: wrapcheck
+}
+
+// CloseAll closes all the streams in memory from the least recently used, and returns the first error,
+// the streams in the store are kept.
+func (m *StreamSessions) CloseAll() (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for m.lru.Len() > 0 {
+		if e := m.close(m.lru.Back()); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return
+}
+
+// Free evicts all the streams in memory, and frees the scratch,
+// the streams failed to compress into the store are closed.
+func (m *StreamSessions) Free() (err error) {
+	m.mu.Lock()
+
+	for elem := m.lru.Back(); elem != nil; elem = m.lru.Back() {
+		if e := m.evict(elem); e != nil {
+			if err == nil {
+				err = e
+			}
+
+			if m.lru.Back() == elem {
+				_ = m.close(elem)
+			}
+		}
+	}
+
+	m.mu.Unlock()
+
+	if e := m.scratch.Free(); err == nil {
+		err = e
+	}
+
+	return
+}
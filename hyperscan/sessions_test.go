@@ -0,0 +1,240 @@
+package hyperscan_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestStreamSessions(t *testing.T) {
+	Convey("Given the stream sessions", t, func() {
+		sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`foobar`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		matches := make(map[interface{}][]uint64)
+		handler := func(id uint, from, to uint64, flags uint, context interface{}) error {
+			matches[context] = append(matches[context], from, to)
+
+			return nil
+		}
+
+		sessions, err := hyperscan.NewStreamSessions(sdb, handler)
+		So(err, ShouldBeNil)
+
+		var evicted []interface{}
+
+		sessions.OnEvict = func(id interface{}, err error) {
+			evicted = append(evicted, id)
+		}
+
+		Convey("When scan the interleaved chunks of sessions", func() {
+			So(sessions.Scan(1, []byte("foo")), ShouldBeNil)
+			So(sessions.Scan(2, []byte("abcfoo")), ShouldBeNil)
+			So(sessions.Scan(1, []byte("bar")), ShouldBeNil)
+			So(sessions.Scan(2, []byte("bar")), ShouldBeNil)
+
+			Convey("Then the chunks are routed to the streams of sessions", func() {
+				So(sessions.Len(), ShouldEqual, 2)
+				So(matches, ShouldResemble, map[interface{}][]uint64{1: {0, 6}, 2: {3, 9}})
+			})
+
+			Convey("Then the streams could be closed", func() {
+				So(sessions.Close(1), ShouldBeNil)
+				So(sessions.Has(1), ShouldBeFalse)
+				So(evicted, ShouldResemble, []interface{}{1})
+
+				So(sessions.CloseAll(), ShouldBeNil)
+				So(sessions.Len(), ShouldEqual, 0)
+				So(evicted, ShouldResemble, []interface{}{1, 2})
+			})
+		})
+
+		Convey("When open a session with its own handler", func() {
+			var own int
+
+			So(sessions.Open("own", func(id uint, from, to uint64, flags uint, context interface{}) error {
+				own++
+
+				return nil
+			}), ShouldBeNil)
+			So(sessions.Open("own", handler), ShouldNotBeNil)
+
+			So(sessions.Scan("own", []byte("foobar")), ShouldBeNil)
+
+			Convey("Then the matches are reported to it", func() {
+				So(own, ShouldEqual, 1)
+				So(matches, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When the streams are idle longer than the timeout", func() {
+			So(sessions.Scan(1, []byte("foo")), ShouldBeNil)
+			So(sessions.Scan(2, []byte("foo")), ShouldBeNil)
+
+			sessions.IdleTimeout = time.Hour
+
+			n, err := sessions.Sweep()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 0)
+
+			sessions.IdleTimeout = 0
+
+			n, err = sessions.Sweep()
+
+			Convey("Then they are closed from the least recently used", func() {
+				So(err, ShouldBeNil)
+				So(n, ShouldEqual, 2)
+				So(sessions.Len(), ShouldEqual, 0)
+				So(evicted, ShouldResemble, []interface{}{1, 2})
+			})
+		})
+
+		Convey("When the streams are scanned while sweeping", func() {
+			var wg sync.WaitGroup
+
+			errs := make(chan error, 8)
+
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+
+				go func(id int) {
+					defer wg.Done()
+
+					for j := 0; j < 100; j++ {
+						if err := sessions.Scan(id, []byte("fo")); err != nil {
+							errs <- err
+
+							return
+						}
+					}
+				}(i)
+			}
+
+			for i := 0; i < 10; i++ {
+				_, err := sessions.Sweep()
+				So(err, ShouldBeNil)
+			}
+
+			wg.Wait()
+			close(errs)
+
+			Convey("Then the streams are not closed while scanning", func() {
+				for err := range errs {
+					So(err, ShouldBeNil)
+				}
+			})
+		})
+
+		Convey("When the streams in memory are limited", func() {
+			sessions.MaxStreams = 2
+
+			So(sessions.Scan(1, []byte("foo")), ShouldBeNil)
+			So(sessions.Scan(2, []byte("foo")), ShouldBeNil)
+			So(sessions.Scan(1, []byte("b")), ShouldBeNil)
+			So(sessions.Scan(3, []byte("foo")), ShouldBeNil)
+
+			Convey("Then the least recently used stream is closed", func() {
+				So(sessions.Len(), ShouldEqual, 2)
+				So(sessions.Has(2), ShouldBeFalse)
+				So(evicted, ShouldResemble, []interface{}{2})
+			})
+		})
+
+		Convey("When the streams are evicted into the store", func() {
+			store := hyperscan.NewMemoryStreamStore()
+			sessions.Store = store
+
+			So(sessions.Scan("foo", []byte("12foo")), ShouldBeNil)
+			So(sessions.Scan("bar", []byte("foob")), ShouldBeNil)
+
+			n, err := sessions.Sweep()
+
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 2)
+			So(sessions.Len(), ShouldEqual, 0)
+			So(store.Len(), ShouldEqual, 2)
+			So(evicted, ShouldResemble, []interface{}{"foo", "bar"})
+
+			Convey("Then they are expanded on the next chunk", func() {
+				So(sessions.Scan("foo", []byte("bar")), ShouldBeNil)
+				So(sessions.Scan("bar", []byte("ar")), ShouldBeNil)
+
+				So(matches, ShouldResemble, map[interface{}][]uint64{"foo": {2, 8}, "bar": {0, 6}})
+				So(sessions.Len(), ShouldEqual, 2)
+				So(store.Len(), ShouldEqual, 0)
+			})
+
+			Convey("Then they could be closed from the store", func() {
+				So(sessions.Close("foo"), ShouldBeNil)
+				So(sessions.Close("bar"), ShouldBeNil)
+
+				So(sessions.Len(), ShouldEqual, 0)
+				So(store.Len(), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When evict a stream into the store", func() {
+			store := hyperscan.NewMemoryStreamStore()
+			sessions.Store = store
+
+			var errs []error
+
+			sessions.OnEvict = func(id interface{}, err error) {
+				errs = append(errs, err)
+			}
+
+			So(sessions.Scan("foo", []byte("12foo")), ShouldBeNil)
+			So(sessions.Evict("foo"), ShouldBeNil)
+
+			Convey("Then the stream is freed without errors", func() {
+				So(errs, ShouldResemble, []error{nil})
+				So(sessions.Len(), ShouldEqual, 0)
+				So(store.Len(), ShouldEqual, 1)
+
+				So(sessions.Close("foo"), ShouldBeNil)
+				So(store.Len(), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When the streams in memory are limited with the store", func() {
+			store := hyperscan.NewMemoryStreamStore()
+			sessions.Store = store
+			sessions.MaxStreams = 2
+
+			So(sessions.Scan("foo", []byte("12f")), ShouldBeNil)
+			So(sessions.Scan("bar", []byte("foo")), ShouldBeNil)
+			So(sessions.Scan("foo", []byte("o")), ShouldBeNil)
+			So(sessions.Scan("baz", []byte("f")), ShouldBeNil)
+
+			Convey("Then the least recently used stream is compressed into the store", func() {
+				So(sessions.Len(), ShouldEqual, 2)
+				So(store.Len(), ShouldEqual, 1)
+				So(evicted, ShouldResemble, []interface{}{"bar"})
+
+				So(sessions.Scan("bar", []byte("bar")), ShouldBeNil)
+				So(matches, ShouldResemble, map[interface{}][]uint64{"bar": {0, 6}})
+				So(store.Len(), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When close an unknown session with the store", func() {
+			store := hyperscan.NewMemoryStreamStore()
+			sessions.Store = store
+
+			So(sessions.Close("foo"), ShouldBeNil)
+
+			Convey("Then no stream is opened", func() {
+				So(sessions.Len(), ShouldEqual, 0)
+				So(store.Len(), ShouldEqual, 0)
+				So(evicted, ShouldBeEmpty)
+			})
+		})
+
+		So(sessions.Free(), ShouldBeNil)
+		So(sdb.Close(), ShouldBeNil)
+	})
+}
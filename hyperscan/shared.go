@@ -0,0 +1,53 @@
+package hyperscan
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// SharedDatabase is a reference-counted database shared by multiple owners,
+// the underlying database is freed when the last reference is closed.
+//
+// The embedded database could be type asserted to scan, but it should not be closed directly.
+type SharedDatabase struct {
+	Database
+
+	refs int32
+}
+
+// NewSharedDatabase returns a shared database with one reference.
+func NewSharedDatabase(db Database) *SharedDatabase {
+	return &SharedDatabase{Database: db, refs: 1}
+}
+
+// Retain adds a reference to the shared database, each reference should be released with `Close`,
+// it returns nil if the last reference has been released.
+func (db *SharedDatabase) Retain() *SharedDatabase {
+	for {
+		refs := atomic.LoadInt32(&db.refs)
+		if refs <= 0 {
+			return nil
+		}
+
+		if atomic.CompareAndSwapInt32(&db.refs, refs, refs+1) {
+			return db
+		}
+	}
+}
+
+// Refs returns the number of references.
+func (db *SharedDatabase) Refs() int { return int(atomic.LoadInt32(&db.refs)) }
+
+// Close releases a reference, and frees the database when the last reference is released.
+func (db *SharedDatabase) Close() error {
+	switch refs := atomic.AddInt32(&db.refs, -1); {
+	case refs > 0:
+		return nil
+	case refs == 0:
+		return db.Database.Close() // nolint: wrapcheck
+	default:
+		atomic.AddInt32(&db.refs, 1)
+
+		return fmt.Errorf("shared database already closed, %w", ErrInvalid)
+	}
+}
@@ -0,0 +1,46 @@
+package hyperscan_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestSharedDatabase(t *testing.T) {
+	Convey("Given a shared database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`))
+
+		So(err, ShouldBeNil)
+
+		db := hyperscan.NewSharedDatabase(bdb)
+
+		So(db.Refs(), ShouldEqual, 1)
+
+		Convey("When retain it by another owner", func() {
+			So(db.Retain().Refs(), ShouldEqual, 2)
+
+			So(db.Close(), ShouldBeNil)
+			So(db.Refs(), ShouldEqual, 1)
+
+			Convey("Then the database is still usable", func() {
+				So(db.Database.(hyperscan.BlockDatabase).MatchString("foo"), ShouldBeTrue)
+			})
+
+			So(db.Close(), ShouldBeNil)
+			So(db.Refs(), ShouldEqual, 0)
+
+			Convey("Then close it again", func() {
+				So(errors.Is(db.Close(), hyperscan.ErrInvalid), ShouldBeTrue)
+				So(db.Refs(), ShouldEqual, 0)
+			})
+
+			Convey("Then retain it again", func() {
+				So(db.Retain(), ShouldBeNil)
+				So(db.Refs(), ShouldEqual, 0)
+			})
+		})
+	})
+}
@@ -0,0 +1,181 @@
+package hyperscan
+
+import "fmt"
+
+// MatchSnippet is the matched bytes with the leading and trailing context.
+//
+// The slices may refer to the internal buffer, they are only valid during the handler call.
+type MatchSnippet struct {
+	ID       uint
+	From, To uint64
+	Flags    uint
+	Before   []byte
+	Match    []byte
+	After    []byte
+}
+
+// SnippetHandler handles the match snippets.
+type SnippetHandler func(snippet *MatchSnippet, context interface{}) error
+
+// snippet extracts the match and context from the data which starts at the offset base, the ranges are clamped.
+func snippet(data []byte, base uint64, from, to uint64, before, after int) (b, m, a []byte) {
+	clamp := func(off uint64) int {
+		switch {
+		case off < base:
+			return 0
+		case off-base > uint64(len(data)):
+			return len(data)
+		default:
+			return int(off - base)
+		}
+	}
+
+	start := from
+	if start > uint64(before) {
+		start -= uint64(before)
+	} else {
+		start = 0
+	}
+
+	s, f, t, e := clamp(start), clamp(from), clamp(to), clamp(to+uint64(after))
+
+	return data[s:f], data[f:t], data[t:e]
+}
+
+// Snippet returns the matched bytes with at most before and after bytes of context, clamped to the data bounds.
+func Snippet(data []byte, from, to uint64, before, after int) (leading, match, trailing []byte) {
+	return snippet(data, 0, from, to, before, after)
+}
+
+// WithSnippets returns a handler which passes the match snippets of the scanned data to the handler.
+//
+//	err := db.Scan(data, nil, hyperscan.WithSnippets(data, 16, 16, handler), nil)
+func WithSnippets(data []byte, before, after int, handler SnippetHandler) MatchHandler {
+	return func(id uint, from, to uint64, flags uint, context interface{}) error {
+		s := MatchSnippet{ID: id, From: from, To: to, Flags: flags}
+		s.Before, s.Match, s.After = snippet(data, 0, from, to, before, after)
+
+		return handler(&s, context)
+	}
+}
+
+type pendingSnippet struct {
+	id       uint
+	from, to uint64
+	flags    uint
+	context  interface{}
+}
+
+// SnippetStream is a stream which passes the match snippets to the handler,
+// the context is buffered across the chunk boundaries.
+//
+// The snippet is passed after the trailing context was scanned or the stream was closed,
+// and at most maxMatch bytes of the match are kept, the longer match is truncated at the start.
+// The patterns should be compiled with SomLeftMost to locate the start of matches.
+type SnippetStream struct {
+	stream        Stream
+	handler       SnippetHandler
+	before, after int
+	maxMatch      int
+	buf           []byte
+	base          uint64
+	pending       []pendingSnippet
+}
+
+// NewSnippetStream opens a stream which passes the match snippets to the handler.
+func NewSnippetStream(db StreamDatabase, flags ScanFlag, scratch *Scratch, before, after, maxMatch int,
+	handler SnippetHandler, context interface{}) (*SnippetStream, error) {
+	s := &SnippetStream{handler: handler, before: before, after: after, maxMatch: maxMatch}
+
+	stream, err := db.Open(flags, scratch, s.handle, context)
+	if err != nil {
+		return nil, fmt.Errorf("open stream, %w", err)
+	}
+
+	s.stream = stream
+
+	return s, nil
+}
+
+func (s *SnippetStream) handle(id uint, from, to uint64, flags uint, context interface{}) error {
+	s.pending = append(s.pending, pendingSnippet{id, from, to, flags, context})
+
+	return nil
+}
+
+func (s *SnippetStream) end() uint64 { return s.base + uint64(len(s.buf)) }
+
+// emit passes the pending snippets which trailing context was scanned, or all of them if flush.
+func (s *SnippetStream) emit(flush bool) error {
+	n := 0
+
+	for i, p := range s.pending {
+		if !flush && p.to+uint64(s.after) > s.end() {
+			s.pending[n] = p
+			n++
+
+			continue
+		}
+
+		m := MatchSnippet{ID: p.id, From: p.from, To: p.to, Flags: p.flags}
+		m.Before, m.Match, m.After = snippet(s.buf, s.base, p.from, p.to, s.before, s.after)
+
+		if err := s.handler(&m, p.context); err != nil {
+			s.pending = s.pending[:n+copy(s.pending[n:], s.pending[i+1:])]
+
+			return err
+		}
+	}
+
+	s.pending = s.pending[:n]
+
+	return nil
+}
+
+// trim drops the buffered data which is not needed for the pending or following snippets.
+func (s *SnippetStream) trim() {
+	keep := s.end()
+
+	for _, p := range s.pending {
+		if p.to < keep {
+			keep = p.to
+		}
+	}
+
+	if window := uint64(s.maxMatch + s.before); keep > s.base+window {
+		cut := int(keep - window - s.base)
+
+		s.buf = s.buf[:copy(s.buf, s.buf[cut:])]
+		s.base += uint64(cut)
+	}
+}
+
+// Scan the chunk of data, and passes the complete snippets to the handler.
+func (s *SnippetStream) Scan(data []byte) error {
+	s.buf = append(s.buf, data...)
+
+	if err := s.stream.Scan(data); err != nil {
+		return err // nolint: wrapcheck
+	}
+
+	if err := s.emit(false); err != nil {
+		return err
+	}
+
+	s.trim()
+
+	return nil
+}
+
+// Close the stream, and passes the pending snippets to the handler.
+func (s *SnippetStream) Close() error {
+	if err := s.stream.Close(); err != nil {
+		return err // nolint: wrapcheck
+	}
+
+	err := s.emit(true)
+
+	s.buf, s.pending = nil, nil
+
+	return err
+}
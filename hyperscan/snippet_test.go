@@ -0,0 +1,83 @@
+package hyperscan_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestSnippet(t *testing.T) {
+	Convey("Given a match in the data", t, func() {
+		data := []byte("hello world foo")
+
+		Convey("When extract the snippet with context", func() {
+			b, m, a := hyperscan.Snippet(data, 6, 11, 3, 3)
+
+			So(string(b), ShouldEqual, "lo ")
+			So(string(m), ShouldEqual, "world")
+			So(string(a), ShouldEqual, " fo")
+		})
+
+		Convey("When the context exceeds the data bounds", func() {
+			b, m, a := hyperscan.Snippet(data, 0, 5, 10, 20)
+
+			So(string(b), ShouldEqual, "")
+			So(string(m), ShouldEqual, "hello")
+			So(string(a), ShouldEqual, " world foo")
+		})
+	})
+
+	Convey("Given a block database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`world`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		Convey("When scan the data with snippets", func() {
+			data := []byte("hello world foo")
+
+			var snippets []string
+
+			err := bdb.Scan(data, nil, hyperscan.WithSnippets(data, 2, 2,
+				func(s *hyperscan.MatchSnippet, context interface{}) error {
+					snippets = append(snippets, string(s.Before)+"["+string(s.Match)+"]"+string(s.After))
+
+					return nil
+				}), nil)
+
+			So(err, ShouldBeNil)
+			So(snippets, ShouldResemble, []string{"o [world] f"})
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+
+	Convey("Given a stream database", t, func() {
+		sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`world`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		Convey("When scan the chunks with snippets", func() {
+			var snippets []string
+
+			s, err := hyperscan.NewSnippetStream(sdb, 0, nil, 4, 4, 16,
+				func(s *hyperscan.MatchSnippet, context interface{}) error {
+					snippets = append(snippets, string(s.Before)+"["+string(s.Match)+"]"+string(s.After))
+
+					return nil
+				}, nil)
+			So(err, ShouldBeNil)
+
+			for _, chunk := range []string{"hel", "lo wo", "rld", " f", "oo world"} {
+				So(s.Scan([]byte(chunk)), ShouldBeNil)
+			}
+
+			So(s.Close(), ShouldBeNil)
+
+			Convey("Then the context is buffered across the chunk boundaries", func() {
+				So(snippets, ShouldResemble, []string{"llo [world] foo", "foo [world]"})
+			})
+		})
+
+		So(sdb.Close(), ShouldBeNil)
+	})
+}
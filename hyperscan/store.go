@@ -0,0 +1,60 @@
+package hyperscan
+
+import "sync"
+
+// StreamStore keeps the compressed stream states by key, such as in Redis or on disk.
+type StreamStore interface {
+	// Get returns the compressed stream state of the key, or nil if not found.
+	Get(key string) ([]byte, error)
+
+	// Put saves the compressed stream state of the key.
+	Put(key string, state []byte) error
+
+	// Delete removes the compressed stream state of the key.
+	Delete(key string) error
+}
+
+// MemoryStreamStore is a StreamStore in memory.
+type MemoryStreamStore struct {
+	mu     sync.Mutex
+	states map[string][]byte
+}
+
+// NewMemoryStreamStore returns an empty StreamStore in memory.
+func NewMemoryStreamStore() *MemoryStreamStore {
+	return &MemoryStreamStore{states: make(map[string][]byte)}
+}
+
+// Get returns the compressed stream state of the key, or nil if not found.
+func (s *MemoryStreamStore) Get(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.states[key], nil
+}
+
+// Put saves the compressed stream state of the key.
+func (s *MemoryStreamStore) Put(key string, state []byte) error {
+	s.mu.Lock()
+	s.states[key] = state
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Delete removes the compressed stream state of the key.
+func (s *MemoryStreamStore) Delete(key string) error {
+	s.mu.Lock()
+	delete(s.states, key)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Len returns the number of stream states in the store.
+func (s *MemoryStreamStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.states)
+}
@@ -0,0 +1,70 @@
+package hyperscan
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrStreamBudgetExceeded means the stream states of the database would blow the memory budget.
+var ErrStreamBudgetExceeded = errors.New("stream state budget exceeded")
+
+// StreamBudget is the memory budget of the stream states for the expected number of concurrent streams.
+type StreamBudget struct {
+	// Streams is the expected number of concurrent streams.
+	Streams int
+
+	// Bytes is the total size of stream states allowed, zero or negative means unlimited.
+	Bytes int64
+
+	// OnExceed warns the budget was exceeded instead of failing, if not nil.
+	OnExceed func(size int, total int64)
+}
+
+// Check returns the total size of stream states of the database, and `ErrStreamBudgetExceeded`
+// if it's over the budget without OnExceed.
+func (b StreamBudget) Check(db StreamDatabase) (int64, error) {
+	size, err := db.StreamSize()
+	if err != nil {
+		return 0, fmt.Errorf("stream size, %w", err)
+	}
+
+	total := int64(size) * int64(b.Streams)
+
+	if b.Bytes > 0 && total > b.Bytes {
+		if b.OnExceed != nil {
+			b.OnExceed(size, total)
+
+			return total, nil
+		}
+
+		return total, fmt.Errorf("%d streams of %d bytes take %d bytes over %d, %w",
+			b.Streams, size, total, b.Bytes, ErrStreamBudgetExceeded)
+	}
+
+	return total, nil
+}
+
+func (b StreamBudget) checked(db StreamDatabase, err error) (StreamDatabase, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = b.Check(db); err != nil {
+		_ = db.Close()
+
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// NewStreamDatabaseWithBudget compiles the patterns into a stream database, and checks it with the budget.
+func NewStreamDatabaseWithBudget(budget StreamBudget, patterns ...*Pattern) (StreamDatabase, error) {
+	return budget.checked(NewStreamDatabase(patterns...))
+}
+
+// UnmarshalStreamDatabaseWithBudget reconstructs a stream database from the serialized data,
+// and checks it with the budget.
+func UnmarshalStreamDatabaseWithBudget(budget StreamBudget, data []byte) (StreamDatabase, error) {
+	return budget.checked(UnmarshalStreamDatabase(data))
+}
@@ -0,0 +1,67 @@
+package hyperscan_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestStreamBudget(t *testing.T) {
+	Convey("Given a stream database", t, func() {
+		sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`foo.*bar`, 0))
+		So(err, ShouldBeNil)
+
+		size, err := sdb.StreamSize()
+		So(err, ShouldBeNil)
+		So(size, ShouldBeGreaterThan, 0)
+
+		Convey("When the stream states are within the budget", func() {
+			total, err := hyperscan.StreamBudget{Streams: 10, Bytes: int64(size) * 10}.Check(sdb)
+
+			So(err, ShouldBeNil)
+			So(total, ShouldEqual, size*10)
+		})
+
+		Convey("When the stream states are over the budget", func() {
+			budget := hyperscan.StreamBudget{Streams: 10, Bytes: int64(size)}
+
+			total, err := budget.Check(sdb)
+
+			So(errors.Is(err, hyperscan.ErrStreamBudgetExceeded), ShouldBeTrue)
+			So(total, ShouldEqual, size*10)
+
+			Convey("Then it warns instead of failing with the callback", func() {
+				var warned int64
+
+				budget.OnExceed = func(size int, total int64) { warned = total }
+
+				_, err := budget.Check(sdb)
+
+				So(err, ShouldBeNil)
+				So(warned, ShouldEqual, size*10)
+			})
+
+			Convey("Then the database is rejected at loading", func() {
+				data, err := sdb.Marshal()
+				So(err, ShouldBeNil)
+
+				db, err := hyperscan.UnmarshalStreamDatabaseWithBudget(budget, data)
+
+				So(errors.Is(err, hyperscan.ErrStreamBudgetExceeded), ShouldBeTrue)
+				So(db, ShouldBeNil)
+			})
+
+			Convey("Then the database is rejected at compiling", func() {
+				db, err := hyperscan.NewStreamDatabaseWithBudget(budget, hyperscan.NewPattern(`foo.*bar`, 0))
+
+				So(errors.Is(err, hyperscan.ErrStreamBudgetExceeded), ShouldBeTrue)
+				So(db, ShouldBeNil)
+			})
+		})
+
+		So(sdb.Close(), ShouldBeNil)
+	})
+}
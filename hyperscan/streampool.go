@@ -0,0 +1,102 @@
+package hyperscan
+
+import (
+	"fmt"
+	"sync"
+)
+
+// StreamPool recycles the streams of a database with `hs_reset_stream`, instead of closing and opening them.
+//
+// The streams are opened with the same flags, scratch and handler;
+// the scratch is shared by the streams, so they must be scanned in one goroutine if it's not nil.
+type StreamPool struct {
+	db      StreamDatabase
+	flags   ScanFlag
+	scratch *Scratch
+	handler MatchHandler
+	size    int
+
+	mu     sync.Mutex
+	idle   []*stream
+	closed bool
+}
+
+// NewStreamPool returns a pool which keeps at most size idle streams of the database.
+func NewStreamPool(db StreamDatabase, flags ScanFlag, scratch *Scratch, handler MatchHandler, size int) *StreamPool {
+	return &StreamPool{db: db, flags: flags, scratch: scratch, handler: handler, size: size}
+}
+
+// Get returns an idle stream with the user context, or opens a new one.
+func (p *StreamPool) Get(context interface{}) (Stream, error) {
+	p.mu.Lock()
+
+	if p.closed {
+		p.mu.Unlock()
+
+		return nil, fmt.Errorf("stream pool closed, %w", ErrInvalid)
+	}
+
+	if n := len(p.idle); n > 0 {
+		s := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+
+		s.context = context
+
+		return s, nil
+	}
+
+	p.mu.Unlock()
+
+	return p.db.Open(p.flags, p.scratch, p.handler, context) // nolint: wrapcheck
+}
+
+// Put resets the stream got from the pool, the end of data matches are reported to the handler,
+// and then keeps it for reusing, or closes it if the pool is full.
+//
+// The stream of another database is rejected with `ErrUnexpected`.
+func (p *StreamPool) Put(s Stream) error {
+	ss, ok := s.(*stream)
+	if !ok {
+		return fmt.Errorf("stream %v, %w", s, ErrUnexpected)
+	}
+
+	if d, ok := p.db.(database); !ok || ss.db != d.Db() {
+		return fmt.Errorf("stream of another database, %w", ErrUnexpected)
+	}
+
+	if err := ss.Reset(); err != nil {
+		_ = ss.Close()
+
+		return err
+	}
+
+	ss.context = nil
+
+	p.mu.Lock()
+	if !p.closed && len(p.idle) < p.size {
+		p.idle = append(p.idle, ss)
+		p.mu.Unlock()
+
+		return nil
+	}
+	p.mu.Unlock()
+
+	return ss.Close()
+}
+
+// Close closes the idle streams, the streams got from the pool should be put back or closed.
+func (p *StreamPool) Close() (err error) {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle, p.closed = nil, true
+	p.mu.Unlock()
+
+	for _, s := range idle {
+		if e := s.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return
+}
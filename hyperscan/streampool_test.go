@@ -0,0 +1,67 @@
+package hyperscan_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestStreamPool(t *testing.T) {
+	Convey("Given a stream pool", t, func() {
+		sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`abc`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		var matches []interface{}
+
+		pool := hyperscan.NewStreamPool(sdb, 0, nil, func(id uint, from, to uint64, flags uint,
+			context interface{}) error {
+			matches = append(matches, context)
+
+			return nil
+		}, 2)
+
+		Convey("When reuse a stream from the pool", func() {
+			s, err := pool.Get("first")
+			So(err, ShouldBeNil)
+
+			So(s.Scan([]byte("ab")), ShouldBeNil)
+			So(pool.Put(s), ShouldBeNil)
+
+			s2, err := pool.Get("second")
+			So(err, ShouldBeNil)
+
+			Convey("Then the state was reset and the context replaced", func() {
+				So(s2, ShouldEqual, s)
+				So(s2.Scan([]byte("c abc")), ShouldBeNil)
+				So(matches, ShouldResemble, []interface{}{"second"})
+			})
+
+			So(pool.Put(s2), ShouldBeNil)
+		})
+
+		Convey("When put a stream of another database", func() {
+			other, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`abc`, hyperscan.SomLeftMost))
+			So(err, ShouldBeNil)
+
+			s, err := other.Open(0, nil, nil, nil)
+			So(err, ShouldBeNil)
+
+			Convey("Then it is rejected", func() {
+				So(errors.Is(pool.Put(s), hyperscan.ErrUnexpected), ShouldBeTrue)
+			})
+
+			So(s.Close(), ShouldBeNil)
+			So(other.Close(), ShouldBeNil)
+		})
+
+		So(pool.Close(), ShouldBeNil)
+
+		_, err = pool.Get(nil)
+		So(err, ShouldNotBeNil)
+
+		So(sdb.Close(), ShouldBeNil)
+	})
+}
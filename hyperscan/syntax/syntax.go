@@ -0,0 +1,294 @@
+// Package syntax translates the RE2 syntax used by the Go regexp package
+// into the PCRE subset supported by Hyperscan.
+//
+// Hyperscan doesn't report the captured groups and it reports all the matches instead of the leftmost one,
+// so some of the RE2 constructs will be dropped or approximated during the translation,
+// and those are reported as notes of the result.
+package syntax
+
+import (
+	"errors"
+	"fmt"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrNoMatch means the expression can never match.
+var ErrNoMatch = errors.New("no match")
+
+// Note describes a construct that was dropped or approximated during the translation.
+type Note struct {
+	Op      syntax.Op // The operator of the construct.
+	Expr    string    // The RE2 expression of the construct.
+	Message string    // A human-readable message describing the change.
+}
+
+func (n Note) String() string { return fmt.Sprintf("`%s` %s", n.Expr, n.Message) }
+
+// Result is the translated expression.
+type Result struct {
+	// The expression in the syntax supported by Hyperscan.
+	Expression string
+	// The expression contains non-ASCII characters, it should be compiled with the UTF-8 mode.
+	UTF8 bool
+	// The constructs that were dropped or approximated.
+	Notes []Note
+}
+
+// Translate parses a RE2 expression with the Perl flags (as `regexp.Compile`)
+// and translates it into the syntax supported by Hyperscan.
+func Translate(expr string) (*Result, error) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, fmt.Errorf("parse expression, %w", err)
+	}
+
+	return TranslateRegexp(re)
+}
+
+// TranslateRegexp translates a parsed RE2 expression into the syntax supported by Hyperscan.
+func TranslateRegexp(re *syntax.Regexp) (*Result, error) {
+	t := &translator{}
+
+	if err := t.write(re); err != nil {
+		return nil, err
+	}
+
+	return &Result{t.b.String(), t.utf8, t.notes}, nil
+}
+
+type translator struct {
+	b     strings.Builder
+	utf8  bool
+	notes []Note
+}
+
+func (t *translator) note(re *syntax.Regexp, msg string) {
+	t.notes = append(t.notes, Note{re.Op, re.String(), msg})
+}
+
+// nolint: gocyclo,cyclop,funlen
+func (t *translator) write(re *syntax.Regexp) error {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		return fmt.Errorf("expression `%s`, %w", re, ErrNoMatch)
+
+	case syntax.OpEmptyMatch:
+		t.b.WriteString(`(?:)`)
+
+	case syntax.OpLiteral:
+		fold := re.Flags&syntax.FoldCase != 0
+
+		if fold {
+			t.b.WriteString(`(?i:`)
+		}
+
+		for _, r := range re.Rune {
+			t.literal(r, false)
+		}
+
+		if fold {
+			t.b.WriteString(`)`)
+		}
+
+	case syntax.OpCharClass:
+		if len(re.Rune) == 0 {
+			return fmt.Errorf("expression `%s`, %w", re, ErrNoMatch)
+		}
+
+		t.class(re.Rune)
+
+	case syntax.OpAnyCharNotNL:
+		t.b.WriteString(`[^\n]`)
+
+	case syntax.OpAnyChar:
+		t.b.WriteString(`(?s:.)`)
+
+	case syntax.OpBeginLine:
+		t.b.WriteString(`(?m:^)`)
+
+	case syntax.OpEndLine:
+		t.b.WriteString(`(?m:$)`)
+
+	case syntax.OpBeginText:
+		t.b.WriteString(`\A`)
+
+	case syntax.OpEndText:
+		t.b.WriteString(`\z`)
+
+	case syntax.OpWordBoundary:
+		t.b.WriteString(`\b`)
+
+	case syntax.OpNoWordBoundary:
+		t.b.WriteString(`\B`)
+
+	case syntax.OpCapture:
+		if re.Name != "" {
+			t.note(re, "named capture group is dropped")
+		}
+
+		t.b.WriteString(`(?:`)
+
+		if err := t.write(re.Sub[0]); err != nil {
+			return err
+		}
+
+		t.b.WriteString(`)`)
+
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		if err := t.operand(re.Sub[0]); err != nil {
+			return err
+		}
+
+		switch re.Op {
+		case syntax.OpStar:
+			t.b.WriteString(`*`)
+		case syntax.OpPlus:
+			t.b.WriteString(`+`)
+		case syntax.OpQuest:
+			t.b.WriteString(`?`)
+		default:
+			switch {
+			case re.Max == -1:
+				fmt.Fprintf(&t.b, `{%d,}`, re.Min)
+			case re.Min == re.Max:
+				fmt.Fprintf(&t.b, `{%d}`, re.Min)
+			default:
+				fmt.Fprintf(&t.b, `{%d,%d}`, re.Min, re.Max)
+			}
+		}
+
+		if re.Flags&syntax.NonGreedy != 0 {
+			t.note(re, "non-greedy repetition is approximated as greedy, Hyperscan reports all the matches")
+		}
+
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpAlternate {
+				if err := t.group(sub); err != nil {
+					return err
+				}
+			} else if err := t.write(sub); err != nil {
+				return err
+			}
+		}
+
+	case syntax.OpAlternate:
+		for i, sub := range re.Sub {
+			if i > 0 {
+				t.b.WriteString(`|`)
+			}
+
+			if err := t.write(sub); err != nil {
+				return err
+			}
+		}
+
+	default:
+		return fmt.Errorf("unsupported operator %v in `%s`", re.Op, re)
+	}
+
+	return nil
+}
+
+// operand writes the operand of a repetition, wraps it in a group if it isn't a single atom.
+func (t *translator) operand(re *syntax.Regexp) error {
+	switch re.Op {
+	case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL, syntax.OpCapture:
+		return t.write(re)
+
+	case syntax.OpLiteral:
+		if len(re.Rune) == 1 {
+			return t.write(re)
+		}
+	}
+
+	return t.group(re)
+}
+
+func (t *translator) group(re *syntax.Regexp) error {
+	t.b.WriteString(`(?:`)
+
+	if err := t.write(re); err != nil {
+		return err
+	}
+
+	t.b.WriteString(`)`)
+
+	return nil
+}
+
+const (
+	metaChars  = `\.+*?()|[]{}^$`
+	classChars = `\[]^-`
+)
+
+func (t *translator) literal(r rune, inClass bool) {
+	switch {
+	case r >= utf8.RuneSelf:
+		t.utf8 = true
+
+		fmt.Fprintf(&t.b, `\x{%X}`, r)
+
+	case !unicode.IsPrint(r):
+		fmt.Fprintf(&t.b, `\x%02X`, r)
+
+	case inClass && strings.ContainsRune(classChars, r), !inClass && strings.ContainsRune(metaChars, r):
+		t.b.WriteByte('\\')
+		t.b.WriteRune(r)
+
+	default:
+		t.b.WriteRune(r)
+	}
+}
+
+func (t *translator) class(ranges []rune) {
+	negated := len(ranges) > 0 && ranges[len(ranges)-1] == unicode.MaxRune
+
+	if negated {
+		// write the complement of the ranges, so it could match the non-UTF-8 input.
+		var complement []rune
+
+		next := rune(0)
+
+		for i := 0; i < len(ranges); i += 2 {
+			if ranges[i] > next {
+				complement = append(complement, next, ranges[i]-1)
+			}
+
+			next = ranges[i+1] + 1
+		}
+
+		if len(complement) == 0 {
+			t.b.WriteString(`(?s:.)`)
+
+			return
+		}
+
+		ranges = complement
+	}
+
+	t.b.WriteByte('[')
+
+	if negated {
+		t.b.WriteByte('^')
+	}
+
+	for i := 0; i < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+
+		t.literal(lo, true)
+
+		if hi > lo {
+			if hi > lo+1 {
+				t.b.WriteByte('-')
+			}
+
+			t.literal(hi, true)
+		}
+	}
+
+	t.b.WriteByte(']')
+}
@@ -0,0 +1,81 @@
+package syntax_test
+
+import (
+	"errors"
+	re2 "regexp/syntax"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan/syntax"
+)
+
+func TestTranslate(t *testing.T) {
+	Convey("Given some RE2 expressions", t, func() {
+		cases := []struct {
+			re2  string
+			expr string
+			utf8 bool
+		}{
+			{`abc`, `abc`, false},
+			{`a.b`, `a[^\n]b`, false},
+			{`(?s)a.b`, `a(?s:.)b`, false},
+			{`^foo$`, `\Afoo\z`, false},
+			{`(?m)^foo$`, `(?m:^)foo(?m:$)`, false},
+			{`(?i)foo`, `(?i:FOO)`, false},
+			{`(foo|bar)+`, `(?:foo|bar)+`, false},
+			{`a(?:b|c)d`, `a[bc]d`, false},
+			{`foo(bar|baz)`, `foo(?:ba[rz])`, false},
+			{`(?:ab){2,5}`, `(?:ab){2,5}`, false},
+			{`a{3}b{2,}`, `a{3}b{2,}`, false},
+			{`\d+\.\d*`, `[0-9]+\.[0-9]*`, false},
+			{`[^a-z]`, `[^a-z]`, false},
+			{`[\-\]]`, `[\-\]]`, false},
+			{`\bfoo\B`, `\bfoo\B`, false},
+			{`\x00\t`, `\x00\x09`, false},
+			{`héllo`, `h\x{E9}llo`, true},
+		}
+
+		for _, c := range cases {
+			Convey("When translate "+c.re2, func() {
+				r, err := syntax.Translate(c.re2)
+
+				So(err, ShouldBeNil)
+				So(r.Expression, ShouldEqual, c.expr)
+				So(r.UTF8, ShouldEqual, c.utf8)
+				So(r.Notes, ShouldBeEmpty)
+			})
+		}
+
+		Convey("When translate the non-greedy repetition", func() {
+			r, err := syntax.Translate(`a.*?b`)
+
+			So(err, ShouldBeNil)
+			So(r.Expression, ShouldEqual, `a[^\n]*b`)
+			So(r.Notes, ShouldHaveLength, 1)
+			So(r.Notes[0].Op, ShouldEqual, re2.OpStar)
+			So(r.Notes[0].Message, ShouldContainSubstring, "non-greedy")
+		})
+
+		Convey("When translate the named capture group", func() {
+			r, err := syntax.Translate(`(?P<name>\w+)`)
+
+			So(err, ShouldBeNil)
+			So(r.Expression, ShouldEqual, `(?:[0-9A-Z_a-z]+)`)
+			So(r.Notes, ShouldHaveLength, 1)
+			So(r.Notes[0].Message, ShouldContainSubstring, "named capture")
+		})
+
+		Convey("When translate an invalid expression", func() {
+			_, err := syntax.Translate(`a(b`)
+
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("When translate an expression never match", func() {
+			_, err := syntax.Translate(`[^\x00-\x{10FFFF}]`)
+
+			So(errors.Is(err, syntax.ErrNoMatch), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,5 @@
+// Package tcpscan scans the reassembled TCP payload with a stream database.
+//
+// The Factory implements `tcpassembly.StreamFactory` of gopacket, each TCP flow is scanned by a Hyperscan stream,
+// and the matches are reported with the network and transport flows, which make up the 5-tuple of the connection.
+package tcpscan
@@ -0,0 +1,136 @@
+package tcpscan
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/tcpassembly"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+// Result is a match in the TCP flow, the offsets are absolute in the payload of the flow.
+type Result struct {
+	Net, Transport gopacket.Flow
+	ID             uint
+	From, To       uint64
+	Flags          uint
+}
+
+// Handler handles the matches of flows, the following data of the flow is ignored if it returns an error.
+type Handler func(result *Result) error
+
+// Factory creates a stream for each TCP flow, which scans the reassembled payload.
+//
+// The streams share the scratch space of the factory, so use a factory for each assembler.
+type Factory struct {
+	db      hyperscan.StreamDatabase
+	handler Handler
+	scratch *hyperscan.Scratch
+
+	// OnError handles the errors of scanning flows other than `hyperscan.ErrScanTerminated`, if not nil.
+	OnError func(net, transport gopacket.Flow, err error)
+}
+
+// NewFactory returns a factory of the streams scanning the TCP flows with the database.
+func NewFactory(db hyperscan.StreamDatabase, handler Handler) (*Factory, error) {
+	scratch, err := hyperscan.NewScratch(db)
+	if err != nil {
+		return nil, fmt.Errorf("create scratch, %w", err)
+	}
+
+	return &Factory{db: db, handler: handler, scratch: scratch}, nil
+}
+
+// New returns the stream of the TCP flow.
+func (f *Factory) New(net, transport gopacket.Flow) tcpassembly.Stream {
+	s := &flowStream{factory: f, net: net, transport: transport}
+
+	s.stream, s.err = f.db.Open(0, f.scratch, s.handle, nil)
+	if s.err != nil {
+		f.fail(s, fmt.Errorf("open stream, %w", s.err))
+	}
+
+	return s
+}
+
+// Free frees the scratch, it should be called after all the flows completed.
+func (f *Factory) Free() error {
+	return f.scratch.Free() // nolint: wrapcheck
+}
+
+func (f *Factory) fail(s *flowStream, err error) {
+	if f.OnError != nil && !errors.Is(err, hyperscan.ErrScanTerminated) {
+		f.OnError(s.net, s.transport, err)
+	}
+}
+
+type flowStream struct {
+	factory        *Factory
+	net, transport gopacket.Flow
+	stream         hyperscan.Stream
+	base           uint64 // the offset in the flow where the stream started
+	scanned        uint64 // the number of bytes scanned by the stream
+	drop           bool   // the matches are dropped when the stream is reset at a gap or failed
+	err            error
+}
+
+func (s *flowStream) handle(id uint, from, to uint64, flags uint, context interface{}) error {
+	if s.drop {
+		return nil
+	}
+
+	return s.factory.handler(&Result{s.net, s.transport, id, s.base + from, s.base + to, flags})
+}
+
+// Reassembled scans the reassembled payload, the stream is reset at the gaps of skipped bytes,
+// so the matches never span the missing data. The unknown bytes skipped before the first payload are not counted.
+func (s *flowStream) Reassembled(reassembly []tcpassembly.Reassembly) {
+	for i := range reassembly {
+		if s.err != nil {
+			return
+		}
+
+		r := &reassembly[i]
+
+		if r.Skip > 0 && s.scanned > 0 {
+			s.drop = true
+			s.err = s.stream.Reset()
+			s.drop = false
+
+			s.base += s.scanned
+			s.scanned = 0
+		}
+
+		if r.Skip > 0 {
+			s.base += uint64(r.Skip)
+		}
+
+		if s.err == nil && len(r.Bytes) > 0 {
+			s.err = s.stream.Scan(r.Bytes)
+			s.scanned += uint64(len(r.Bytes))
+		}
+
+		if s.err != nil {
+			s.factory.fail(s, s.err)
+		}
+	}
+}
+
+// ReassemblyComplete closes the stream, the end of data matches are reported if the flow wasn't failed.
+func (s *flowStream) ReassemblyComplete() {
+	if s.stream == nil {
+		return
+	}
+
+	if s.err != nil {
+		s.drop = true
+	}
+
+	if err := s.stream.Close(); err != nil && s.err == nil {
+		s.factory.fail(s, err)
+	}
+
+	s.stream = nil
+}
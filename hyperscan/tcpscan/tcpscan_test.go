@@ -0,0 +1,63 @@
+package tcpscan_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/tcpassembly"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+	"github.com/flier/gohs/hyperscan/tcpscan"
+)
+
+func TestFactory(t *testing.T) {
+	Convey("Given a factory of the TCP flow streams", t, func() {
+		sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`foobar`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		var results []tcpscan.Result
+
+		f, err := tcpscan.NewFactory(sdb, func(result *tcpscan.Result) error {
+			results = append(results, *result)
+
+			return nil
+		})
+		So(err, ShouldBeNil)
+
+		netFlow := gopacket.NewFlow(layers.EndpointIPv4, net.IPv4(10, 0, 0, 1).To4(), net.IPv4(10, 0, 0, 2).To4())
+		tcpFlow := gopacket.NewFlow(layers.EndpointTCPPort, []byte{0x30, 0x39}, []byte{0, 80})
+
+		var _ tcpassembly.StreamFactory = f
+
+		s := f.New(netFlow, tcpFlow)
+
+		Convey("When the match spans the reassembled payload", func() {
+			s.Reassembled([]tcpassembly.Reassembly{{Bytes: []byte("abcfoo")}})
+			s.Reassembled([]tcpassembly.Reassembly{{Bytes: []byte("bar")}})
+			s.ReassemblyComplete()
+
+			Convey("Then it is reported with the flows", func() {
+				So(results, ShouldResemble, []tcpscan.Result{{Net: netFlow, Transport: tcpFlow, ID: 0, From: 3, To: 9}})
+			})
+		})
+
+		Convey("When the bytes were skipped in the flow", func() {
+			s.Reassembled([]tcpassembly.Reassembly{
+				{Bytes: []byte("abcfoo")},
+				{Bytes: []byte("bar"), Skip: 2},
+				{Bytes: []byte("foobar")},
+			})
+			s.ReassemblyComplete()
+
+			Convey("Then the match never spans the gap", func() {
+				So(results, ShouldResemble, []tcpscan.Result{{Net: netFlow, Transport: tcpFlow, ID: 0, From: 11, To: 17}})
+			})
+		})
+
+		So(f.Free(), ShouldBeNil)
+		So(sdb.Close(), ShouldBeNil)
+	})
+}
@@ -0,0 +1,26 @@
+//go:build go1.18
+// +build go1.18
+
+package hyperscan
+
+// TypedMatchHandler handles match events with the typed user context passed to the scanning.
+type TypedMatchHandler[T any] func(id uint, from, to uint64, flags uint, context T) error
+
+// Typed returns a match handler which passes the user context to the handler as the type,
+// so the handler could be created once and reused for all the scanning without capturing the state in closures.
+//
+// The zero value of type is passed if the user context is nil or in other type.
+//
+//	handler := hyperscan.Typed(func(id uint, from, to uint64, flags uint, stats *Stats) error {
+//		stats.Matches++
+//		return nil
+//	})
+//
+//	err := db.Scan(data, scratch, handler, &stats)
+func Typed[T any](handler TypedMatchHandler[T]) MatchHandler {
+	return func(id uint, from, to uint64, flags uint, context interface{}) error {
+		v, _ := context.(T)
+
+		return handler(id, from, to, flags, v)
+	}
+}
@@ -0,0 +1,58 @@
+//go:build go1.18
+// +build go1.18
+
+package hyperscan_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+type scanStats struct {
+	matches int
+}
+
+func TestTypedHandler(t *testing.T) {
+	Convey("Given a typed handler", t, func() {
+		handler := hyperscan.Typed(func(id uint, from, to uint64, flags uint, stats *scanStats) error {
+			stats.matches++
+
+			return nil
+		})
+
+		Convey("When scan the block database with the user context", func() {
+			bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`))
+			So(err, ShouldBeNil)
+
+			var first, second scanStats
+
+			So(bdb.Scan([]byte("foo foo"), nil, handler, &first), ShouldBeNil)
+			So(bdb.Scan([]byte("foo"), nil, handler, &second), ShouldBeNil)
+
+			So(first.matches, ShouldEqual, 2)
+			So(second.matches, ShouldEqual, 1)
+			So(bdb.Close(), ShouldBeNil)
+		})
+
+		Convey("When scan the stream with the user context of each scanning", func() {
+			sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`foo`))
+			So(err, ShouldBeNil)
+
+			var opened, scanned scanStats
+
+			s, err := sdb.Open(0, nil, handler, &opened)
+			So(err, ShouldBeNil)
+
+			So(s.Scan([]byte("foo")), ShouldBeNil)
+			So(hyperscan.ScanStreamWith(s, []byte("foo foo"), &scanned), ShouldBeNil)
+
+			So(opened.matches, ShouldEqual, 1)
+			So(scanned.matches, ShouldEqual, 2)
+			So(s.Close(), ShouldBeNil)
+			So(sdb.Close(), ShouldBeNil)
+		})
+	})
+}
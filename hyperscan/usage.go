@@ -0,0 +1,49 @@
+package hyperscan
+
+import "fmt"
+
+// MemoryUsage is the memory used by a scanning unit in bytes.
+type MemoryUsage struct {
+	Database int // The size of database.
+	Scratch  int // The size of a scratch space allocated for the database.
+	Stream   int // The size of a stream state, or zero if the database is not in the stream mode.
+}
+
+// Total returns the memory required by the database with the number of scratch spaces and streams.
+func (u MemoryUsage) Total(scratches, streams int) int {
+	return u.Database + u.Scratch*scratches + u.Stream*streams
+}
+
+// DatabaseMemoryUsage provides the memory used by the database, a scratch space and a stream.
+func DatabaseMemoryUsage(db Database) (u MemoryUsage, err error) {
+	d, ok := db.(database)
+	if !ok {
+		return u, fmt.Errorf("database %v, %w", db, ErrUnexpected)
+	}
+
+	if u.Database, err = db.Size(); err != nil {
+		return
+	}
+
+	s, err := hsAllocScratch(d.Db())
+	if err != nil {
+		return
+	}
+
+	defer func() { _ = hsFreeScratch(s) }()
+
+	if u.Scratch, err = hsScratchSize(s); err != nil {
+		return
+	}
+
+	mode, err := databaseMode(d.Db())
+	if err != nil {
+		return
+	}
+
+	if mode == StreamMode {
+		u.Stream, err = hsStreamSize(d.Db())
+	}
+
+	return // nolint: nakedret
+}
@@ -0,0 +1,48 @@
+package hyperscan_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestMemoryUsage(t *testing.T) {
+	Convey("Given a stream database", t, func() {
+		sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`foo\d+`))
+		So(err, ShouldBeNil)
+
+		Convey("When get the memory usage", func() {
+			u, err := hyperscan.DatabaseMemoryUsage(sdb)
+
+			So(err, ShouldBeNil)
+
+			Convey("Then it reports the sizes of database, scratch and stream", func() {
+				size, err := sdb.Size()
+				So(err, ShouldBeNil)
+				So(u.Database, ShouldEqual, size)
+
+				size, err = sdb.StreamSize()
+				So(err, ShouldBeNil)
+				So(u.Stream, ShouldEqual, size)
+
+				So(u.Scratch, ShouldBeGreaterThan, 0)
+				So(u.Total(2, 1000), ShouldEqual, u.Database+2*u.Scratch+1000*u.Stream)
+			})
+		})
+
+		So(sdb.Close(), ShouldBeNil)
+	})
+
+	Convey("Given a block database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo\d+`))
+		So(err, ShouldBeNil)
+
+		u, err := hyperscan.DatabaseMemoryUsage(bdb)
+
+		So(err, ShouldBeNil)
+		So(u.Stream, ShouldEqual, 0)
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
@@ -0,0 +1,67 @@
+package hyperscan
+
+// WindowScanner scans the consecutive chunks with a block database, and keeps an overlap window between them,
+// so the matches up to the window size spanning the chunk boundaries are still found without stream state.
+//
+// The offsets of matches are absolute from the start of the first chunk, and the matches ending in the overlap
+// are only reported once. The longer matches may be reported with the start truncated or even missed,
+// and the anchors like `^` or `\b` are evaluated at the start of the overlap instead of the chunk.
+type WindowScanner struct {
+	db      BlockDatabase
+	scratch *Scratch
+	window  int
+	handler MatchHandler
+	context interface{}
+	buf     []byte
+	tail    int    // the length of overlap at the start of buf
+	offset  uint64 // the absolute offset of buf
+}
+
+// NewWindowScanner returns a scanner which keeps the last window-1 bytes of the previous chunk as the overlap.
+func NewWindowScanner(db BlockDatabase, scratch *Scratch, window int, handler MatchHandler,
+	context interface{},
+) *WindowScanner {
+	return &WindowScanner{db: db, scratch: scratch, window: window, handler: handler, context: context}
+}
+
+func (ws *WindowScanner) handle(id uint, from, to uint64, flags uint, context interface{}) error {
+	if to <= uint64(ws.tail) {
+		return nil // reported by the previous chunk
+	}
+
+	return ws.handler(id, ws.offset+from, ws.offset+to, flags, context)
+}
+
+// Scan the data as the next chunk.
+func (ws *WindowScanner) Scan(data []byte) error {
+	ws.buf = append(ws.buf, data...)
+
+	if err := ws.db.Scan(ws.buf, ws.scratch, ws.handle, ws.context); err != nil {
+		return err // nolint: wrapcheck
+	}
+
+	n := ws.window - 1
+	if n < 0 {
+		n = 0
+	}
+
+	if n > len(ws.buf) {
+		n = len(ws.buf)
+	}
+
+	ws.offset += uint64(len(ws.buf) - n)
+	ws.buf = append(ws.buf[:0], ws.buf[len(ws.buf)-n:]...)
+	ws.tail = n
+
+	return nil
+}
+
+// Offset returns the number of bytes scanned.
+func (ws *WindowScanner) Offset() uint64 { return ws.offset + uint64(len(ws.buf)) }
+
+// Reset drops the overlap and the offset for the new data.
+func (ws *WindowScanner) Reset() {
+	ws.buf = ws.buf[:0]
+	ws.tail = 0
+	ws.offset = 0
+}
@@ -0,0 +1,56 @@
+package hyperscan_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestWindowScanner(t *testing.T) {
+	Convey("Given a block database", t, func() {
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foobar`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		var matches [][]uint64
+
+		ws := hyperscan.NewWindowScanner(bdb, nil, 6, func(id uint, from, to uint64, flags uint,
+			context interface{},
+		) error {
+			matches = append(matches, []uint64{from, to})
+
+			return nil
+		}, nil)
+
+		Convey("When the match spans the chunks", func() {
+			for _, chunk := range []string{"abcfo", "ob", "ar"} {
+				So(ws.Scan([]byte(chunk)), ShouldBeNil)
+			}
+
+			Convey("Then it is found with the absolute offsets", func() {
+				So(matches, ShouldResemble, [][]uint64{{3, 9}})
+				So(ws.Offset(), ShouldEqual, 9)
+			})
+		})
+
+		Convey("When the match is in the overlap", func() {
+			So(ws.Scan([]byte("xfoobar")), ShouldBeNil)
+			So(ws.Scan([]byte("foobar")), ShouldBeNil)
+
+			Convey("Then it is only reported once", func() {
+				So(matches, ShouldResemble, [][]uint64{{1, 7}, {7, 13}})
+			})
+
+			Convey("Then the offset restarts after reset", func() {
+				ws.Reset()
+
+				So(ws.Offset(), ShouldEqual, 0)
+				So(ws.Scan([]byte("foobar")), ShouldBeNil)
+				So(matches, ShouldResemble, [][]uint64{{1, 7}, {7, 13}, {0, 6}})
+			})
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+}
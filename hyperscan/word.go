@@ -0,0 +1,40 @@
+package hyperscan
+
+// isWordByte reports whether the byte is an ASCII word character `[0-9A-Za-z_]`.
+func isWordByte(b byte) bool {
+	return b == '_' || '0' <= b && b <= '9' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z'
+}
+
+// isWholeWord reports whether the match is bounded by the non-word characters or the edges of data.
+func isWholeWord(before, after []byte) bool {
+	return (len(before) == 0 || !isWordByte(before[len(before)-1])) && (len(after) == 0 || !isWordByte(after[0]))
+}
+
+// WholeWord returns a handler which suppresses the matches in the data not bounded by non-word characters.
+//
+// The patterns should be compiled with SomLeftMost to locate the start of matches.
+func WholeWord(data []byte, handler MatchHandler) MatchHandler {
+	return func(id uint, from, to uint64, flags uint, context interface{}) error {
+		if before, _, after := snippet(data, 0, from, to, 1, 1); !isWholeWord(before, after) {
+			return nil
+		}
+
+		return handler(id, from, to, flags, context)
+	}
+}
+
+// NewWholeWordStream opens a stream which suppresses the matches not bounded by non-word characters,
+// the adjacent bytes are buffered across the chunk boundaries.
+//
+// The match which ends at the chunk edge is reported after the next chunk was scanned or the stream was closed,
+// and the match longer than maxMatch bytes is treated as bounded at the start.
+func NewWholeWordStream(db StreamDatabase, flags ScanFlag, scratch *Scratch, maxMatch int,
+	handler MatchHandler, context interface{}) (*SnippetStream, error) {
+	return NewSnippetStream(db, flags, scratch, 1, 1, maxMatch, func(s *MatchSnippet, context interface{}) error {
+		if !isWholeWord(s.Before, s.After) {
+			return nil
+		}
+
+		return handler(s.ID, s.From, s.To, s.Flags, context)
+	}, context)
+}
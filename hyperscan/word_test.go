@@ -0,0 +1,60 @@
+package hyperscan_test
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/flier/gohs/hyperscan"
+)
+
+func TestWholeWord(t *testing.T) {
+	var matches [][]uint64
+
+	handler := func(id uint, from, to uint64, flags uint, context interface{}) error {
+		matches = append(matches, []uint64{from, to})
+
+		return nil
+	}
+
+	Convey("Given a block database", t, func() {
+		matches = nil
+
+		bdb, err := hyperscan.NewBlockDatabase(hyperscan.NewPattern(`foo`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		Convey("When scan the data with whole word filtering", func() {
+			data := []byte("foo foobar _foo (foo) barfoo foo")
+
+			So(bdb.Scan(data, nil, hyperscan.WholeWord(data, handler), nil), ShouldBeNil)
+
+			So(matches, ShouldResemble, [][]uint64{{0, 3}, {17, 20}, {29, 32}})
+		})
+
+		So(bdb.Close(), ShouldBeNil)
+	})
+
+	Convey("Given a stream database", t, func() {
+		matches = nil
+
+		sdb, err := hyperscan.NewStreamDatabase(hyperscan.NewPattern(`foo`, hyperscan.SomLeftMost))
+		So(err, ShouldBeNil)
+
+		Convey("When scan the chunks with whole word filtering", func() {
+			s, err := hyperscan.NewWholeWordStream(sdb, 0, nil, 16, handler, nil)
+			So(err, ShouldBeNil)
+
+			for _, chunk := range []string{"a fo", "o", "bar x", "foo", " foo"} {
+				So(s.Scan([]byte(chunk)), ShouldBeNil)
+			}
+
+			So(s.Close(), ShouldBeNil)
+
+			Convey("Then the boundaries at the chunk edges are checked", func() {
+				So(matches, ShouldResemble, [][]uint64{{14, 17}})
+			})
+		})
+
+		So(sdb.Close(), ShouldBeNil)
+	})
+}
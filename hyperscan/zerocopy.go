@@ -0,0 +1,25 @@
+//go:build !go1.20
+// +build !go1.20
+
+package hyperscan
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// stringBytes returns the backing bytes of string without copying, which must not be modified.
+func stringBytes(s string) []byte {
+	if len(s) == 0 {
+		return []byte{}
+	}
+
+	var b []byte
+
+	hdr := (*reflect.SliceHeader)(unsafe.Pointer(&b))
+	hdr.Data = (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+	hdr.Len = len(s)
+	hdr.Cap = len(s)
+
+	return b
+}
@@ -0,0 +1,15 @@
+//go:build go1.20
+// +build go1.20
+
+package hyperscan
+
+import "unsafe"
+
+// stringBytes returns the backing bytes of string without copying, which must not be modified.
+func stringBytes(s string) []byte {
+	if len(s) == 0 {
+		return []byte{}
+	}
+
+	return unsafe.Slice(unsafe.StringData(s), len(s))
+}